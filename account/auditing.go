@@ -0,0 +1,106 @@
+package account
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/audit"
+	"github.com/shopspring/decimal"
+)
+
+// Transactor runs fn in one transaction, handing it the service, the accounts and the audit log
+// bound to the transaction. The transaction is committed when fn returns nil.
+type Transactor func(fn func(s Service, accounts Repository, entries audit.Repository) error) error
+
+type auditingService struct {
+	recorder audit.Recorder
+	transact Transactor
+	Service
+}
+
+// NewAuditingService returns a Service recording its state-changing calls to the audit log.
+// Calls run on the service bound to a transaction of the transactor, their entries are appended
+// in the same transaction, together with states of accounts read from it around the calls.
+func NewAuditingService(recorder audit.Recorder, transact Transactor, s Service) Service {
+	return &auditingService{recorder: recorder, transact: transact, Service: s}
+}
+
+func (s *auditingService) New(ctx context.Context, id ID, customerID uuid.UUID, country Country, city City, currency Currency, balance decimal.Decimal) error {
+	return s.record(ctx, "account.create", string(id), func(svc Service, accounts Repository) (before, after interface{}, err error) {
+		err = svc.New(ctx, id, customerID, country, city, currency, balance)
+		return nil, found(accounts, id, err), err
+	})
+}
+
+func (s *auditingService) Delete(ctx context.Context, id ID) error {
+	return s.record(ctx, "account.delete", string(id), func(svc Service, accounts Repository) (before, after interface{}, err error) {
+		before = find(accounts, id)
+		return before, nil, svc.Delete(ctx, id)
+	})
+}
+
+func (s *auditingService) Restore(ctx context.Context, id ID) error {
+	return s.record(ctx, "account.restore", string(id), func(svc Service, accounts Repository) (before, after interface{}, err error) {
+		err = svc.Restore(ctx, id)
+		return nil, found(accounts, id, err), err
+	})
+}
+
+func (s *auditingService) Freeze(ctx context.Context, id ID) error {
+	return s.record(ctx, "account.freeze", string(id), func(svc Service, accounts Repository) (before, after interface{}, err error) {
+		before = find(accounts, id)
+		err = svc.Freeze(ctx, id)
+		return before, found(accounts, id, err), err
+	})
+}
+
+func (s *auditingService) Unfreeze(ctx context.Context, id ID) error {
+	return s.record(ctx, "account.unfreeze", string(id), func(svc Service, accounts Repository) (before, after interface{}, err error) {
+		before = find(accounts, id)
+		err = svc.Unfreeze(ctx, id)
+		return before, found(accounts, id, err), err
+	})
+}
+
+func (s *auditingService) AddOwner(ctx context.Context, id ID, principal string) error {
+	return s.record(ctx, "account.add_owner", string(id), func(svc Service, accounts Repository) (before, after interface{}, err error) {
+		return nil, Owner{AccountID: id, Principal: principal}, svc.AddOwner(ctx, id, principal)
+	})
+}
+
+// record runs the call and appends its entry in one transaction, so that no change is stored
+// without its entry. Failed calls are recorded too. When the transaction fails, the change is
+// rolled back and the call is recorded as failed outside of it.
+func (s *auditingService) record(ctx context.Context, action, resource string, call func(Service, Repository) (before, after interface{}, err error)) error {
+	var before interface{}
+	var callErr error
+	err := s.transact(func(svc Service, accounts Repository, entries audit.Repository) error {
+		var after interface{}
+		before, after, callErr = call(svc, accounts)
+		return entries.Append(audit.NewEntry(ctx, action, resource, before, after, callErr))
+	})
+	if err != nil {
+		if callErr == nil {
+			callErr = err
+		}
+		s.recorder.Record(ctx, action, resource, before, nil, callErr)
+	}
+	return callErr
+}
+
+// found returns the account resulting from a call, nil when the call failed.
+func found(accounts Repository, id ID, err error) *Account {
+	if err != nil {
+		return nil
+	}
+	return find(accounts, id)
+}
+
+// find returns the account for the record, nil when there is none.
+func find(accounts Repository, id ID) *Account {
+	a, err := accounts.Find(id)
+	if err != nil {
+		return nil
+	}
+	return a
+}
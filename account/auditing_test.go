@@ -0,0 +1,113 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ilyareist/task1/audit"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+)
+
+// freezer is a Service freezing accounts of the repository, failing with err.
+type freezer struct {
+	Service
+	accounts *accounts
+	err      error
+}
+
+func (s freezer) Freeze(ctx context.Context, id ID) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.accounts.stored[id].Frozen = true
+	return nil
+}
+
+func (r *accounts) Find(id ID) (*Account, error) {
+	a, ok := r.stored[id]
+	if !ok {
+		return nil, errs.ErrUnknownAccount
+	}
+	found := *a
+	return &found, nil
+}
+
+// entries is an audit log failing appends with err.
+type entries struct {
+	audit.Repository
+	appended []*audit.Entry
+	err      error
+}
+
+func (l *entries) Append(e *audit.Entry) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.appended = append(l.appended, e)
+	return nil
+}
+
+// recorder records entries outside of transactions.
+type recorder []*audit.Entry
+
+func (r *recorder) Record(ctx context.Context, action, resource string, before, after interface{}, err error) {
+	*r = append(*r, audit.NewEntry(ctx, action, resource, before, after, err))
+}
+
+func TestAuditingService(t *testing.T) {
+	storeErr := errors.New("connection reset")
+	tests := []struct {
+		name      string
+		callErr   error
+		appendErr error
+		want      error
+		committed bool
+	}{
+		{"frozen", nil, nil, nil, true},
+		{"failed call", errs.ErrForbidden, nil, errs.ErrForbidden, true},
+		{"failed append", nil, storeErr, storeErr, false},
+	}
+	for _, tt := range tests {
+		repo := &accounts{stored: map[ID]*Account{"acc": {ID: "acc"}}}
+		log := &entries{err: tt.appendErr}
+		var committed bool
+		transact := func(fn func(Service, Repository, audit.Repository) error) error {
+			// The transaction is bound to a copy of the accounts, stored back on commit.
+			tx := &accounts{stored: map[ID]*Account{"acc": {ID: "acc"}}}
+			if err := fn(freezer{accounts: tx, err: tt.callErr}, tx, log); err != nil {
+				return err
+			}
+			committed, repo.stored = true, tx.stored
+			return nil
+		}
+		var rec recorder
+		s := NewAuditingService(&rec, transact, nil)
+
+		err := s.Freeze(auth.NewContext(context.Background(), auth.Principal{ID: "op"}), "acc")
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+		if committed != tt.committed || repo.stored["acc"].Frozen != (tt.committed && tt.callErr == nil) {
+			t.Errorf("%s: committed %v, stored %+v", tt.name, committed, repo.stored["acc"])
+		}
+		// Committed calls are recorded in their transaction, rolled back ones outside of it.
+		recorded := log.appended
+		if !tt.committed {
+			recorded = rec
+		} else if len(rec) != 0 {
+			t.Errorf("%s: recorded outside of the transaction", tt.name)
+		}
+		if len(recorded) != 1 {
+			t.Fatalf("%s: recorded %d entries", tt.name, len(recorded))
+		}
+		e := recorded[0]
+		if e.Action != "account.freeze" || e.Resource != "acc" || e.Actor != "op" || e.Before == nil {
+			t.Errorf("%s: got %+v", tt.name, e)
+		}
+		if (e.After != nil) != (tt.want == nil && tt.committed) || (e.Error != "") != (tt.want != nil) {
+			t.Errorf("%s: got after %s, error %q", tt.name, e.After, e.Error)
+		}
+	}
+}
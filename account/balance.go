@@ -0,0 +1,142 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+// MaxBalanceDays is the longest balance history returned at once, in days.
+const MaxBalanceDays = 366
+
+const (
+	// day is the length of the days of balance histories, which are days UTC.
+	day = 24 * time.Hour
+	// dateLayout formats days of balance histories.
+	dateLayout = "2006-01-02"
+)
+
+// Balance of an account at a moment: its initial balance and every payment made before then.
+type Balance struct {
+	Account  ID              `json:"account"`
+	At       time.Time       `json:"at"`
+	Amount   decimal.Decimal `json:"balance"`
+	Currency Currency        `json:"currency"`
+}
+
+// DailyBalance is the balance of an account at the end of a day UTC, YYYY-MM-DD.
+type DailyBalance struct {
+	Date    string          `json:"date"`
+	Balance decimal.Decimal `json:"balance"`
+}
+
+// BalanceHistory is the balance of an account at the end of every day of a period, days UTC.
+type BalanceHistory struct {
+	Account  ID             `json:"account"`
+	Currency Currency       `json:"currency"`
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Days     []DailyBalance `json:"days"`
+}
+
+// Snapshot is the balance of an account as of a midnight UTC, made of the payments before it.
+// Snapshots are taken periodically, so that a balance at a moment is worked out of the last
+// snapshot before it and the payments since, rather than out of every payment ever made.
+type Snapshot struct {
+	TableName struct{}        `json:"-" sql:"balance_snapshots"`
+	AccountID ID              `json:"account" sql:"account_id,pk,type:varchar(255)"`
+	At        time.Time       `json:"at" sql:"at,pk"`
+	Balance   decimal.Decimal `json:"balance" sql:"balance,notnull,type:'decimal(16,4)'"`
+}
+
+// Balance returns the balance of an account at a moment, now when at is zero. Accounts hold
+// nothing before they are opened, their balance at earlier moments is zero.
+func (s *service) Balance(ctx context.Context, id ID, at time.Time) (Balance, error) {
+	if err := Authorize(ctx, s.accounts, id, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor); err != nil {
+		return Balance{}, err
+	}
+	a, err := s.accounts.Find(id)
+	if err != nil {
+		return Balance{}, err
+	}
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	b := Balance{Account: id, At: at, Currency: a.Currency}
+	if at.Before(a.CreatedAt) {
+		return b, nil
+	}
+	if b.Amount, err = s.accounts.FindBalance(id, at); err != nil {
+		return Balance{}, err
+	}
+	return b, nil
+}
+
+// Balances returns the balance of an account at the end of every day from one to another,
+// both included, days UTC. Days are given by their midnights; to is today when zero, and from
+// opens the 30 days up to it when zero. Days ending before the account was opened have a zero
+// balance.
+func (s *service) Balances(ctx context.Context, id ID, from, to time.Time) (BalanceHistory, error) {
+	if err := Authorize(ctx, s.accounts, id, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor); err != nil {
+		return BalanceHistory{}, err
+	}
+	if to.IsZero() {
+		to = time.Now().UTC().Truncate(day)
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -29)
+	}
+	from, to = from.UTC().Truncate(day), to.UTC().Truncate(day)
+	if to.Before(from) || to.Sub(from) >= MaxBalanceDays*day {
+		return BalanceHistory{}, errs.ErrInvalidArgument
+	}
+	a, err := s.accounts.Find(id)
+	if err != nil {
+		return BalanceHistory{}, err
+	}
+	balances, err := s.accounts.FindDailyBalances(id, from, to)
+	if err != nil {
+		return BalanceHistory{}, err
+	}
+	h := BalanceHistory{
+		Account:  id,
+		Currency: a.Currency,
+		From:     from.Format(dateLayout),
+		To:       to.Format(dateLayout),
+		Days:     make([]DailyBalance, len(balances)),
+	}
+	for i, b := range balances {
+		if !from.AddDate(0, 0, i+1).After(a.CreatedAt) {
+			b = decimal.Zero
+		}
+		h.Days[i] = DailyBalance{Date: from.AddDate(0, 0, i).Format(dateLayout), Balance: b}
+	}
+	return h, nil
+}
+
+// TakeSnapshots takes balance snapshots of accounts every interval until ctx is done, the
+// first one right away. Each snapshot is as of the last midnight UTC at least delay ago, so
+// that payments stamped before it by clocks running behind are committed by the time it is
+// taken.
+func TakeSnapshots(ctx context.Context, accounts Repository, interval, delay time.Duration, logger kitlog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		at := time.Now().UTC().Add(-delay).Truncate(day)
+		n, err := accounts.StoreSnapshots(at)
+		if err != nil {
+			_ = logger.Log("msg", "take balance snapshots", "error", err)
+		} else if n > 0 {
+			_ = logger.Log("msg", "balance snapshots taken", "at", at.Format(dateLayout), "count", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+// balances is a Repository of one account of alice opened at opened, worth 100 at every moment
+// and n at the end of day n of a history, counted from 1.
+type balances struct {
+	Repository
+	opened   time.Time
+	at       time.Time
+	from, to time.Time
+}
+
+func (r *balances) Find(id ID) (*Account, error) {
+	return &Account{ID: id, Currency: Currency("EUR"), CreatedAt: r.opened}, nil
+}
+
+func (r *balances) IsOwner(id ID, principal string) bool { return principal == "alice" }
+
+func (r *balances) FindBalance(id ID, at time.Time) (decimal.Decimal, error) {
+	r.at = at
+	return decimal.New(100, 0), nil
+}
+
+func (r *balances) FindDailyBalances(id ID, from, to time.Time) ([]decimal.Decimal, error) {
+	r.from, r.to = from, to
+	days := make([]decimal.Decimal, int(to.Sub(from)/day)+1)
+	for i := range days {
+		days[i] = decimal.New(int64(i+1), 0)
+	}
+	return days, nil
+}
+
+func date(s string) time.Time {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestBalance(t *testing.T) {
+	opened := date("2019-10-10").Add(12 * time.Hour)
+	tests := []struct {
+		name   string
+		opened time.Time
+		at     time.Time
+		want   int64
+	}{
+		{"after opening", opened, opened.Add(time.Minute), 100},
+		{"at opening", opened, opened, 100},
+		{"before opening", opened, opened.Add(-time.Minute), 0},
+		{"opening unknown", time.Time{}, date("2000-01-01"), 100},
+	}
+	for _, tt := range tests {
+		repo := &balances{opened: tt.opened}
+		b, err := NewService(repo, nil).Balance(as("auditor", auth.RoleAuditor), "acc", tt.at)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !b.Amount.Equal(decimal.New(tt.want, 0)) || !b.At.Equal(tt.at) || b.Currency != Currency("EUR") {
+			t.Errorf("%s: got %+v, want %d", tt.name, b, tt.want)
+		}
+	}
+
+	// The balance now is asked for when there is no moment.
+	repo := &balances{}
+	b, err := NewService(repo, nil).Balance(as("auditor", auth.RoleAuditor), "acc", time.Time{})
+	if err != nil || b.At.IsZero() || !repo.at.Equal(b.At) {
+		t.Errorf("now: got %+v, %v, asked at %v", b, err, repo.at)
+	}
+}
+
+func TestBalances(t *testing.T) {
+	tests := []struct {
+		name     string
+		opened   time.Time
+		from, to string
+		want     []int64
+		err      error
+	}{
+		{"one day", time.Time{}, "2019-10-10", "2019-10-10", []int64{1}, nil},
+		{"days", time.Time{}, "2019-10-10", "2019-10-12", []int64{1, 2, 3}, nil},
+		{"longest", time.Time{}, "2019-01-01", "2020-01-01", nil, nil},
+		{"too long", time.Time{}, "2019-01-01", "2020-01-02", nil, errs.ErrInvalidArgument},
+		{"backwards", time.Time{}, "2019-10-12", "2019-10-10", nil, errs.ErrInvalidArgument},
+		{"opened in the period", date("2019-10-11").Add(time.Hour), "2019-10-10", "2019-10-12", []int64{0, 2, 3}, nil},
+		{"opened at a midnight", date("2019-10-11"), "2019-10-09", "2019-10-12", []int64{0, 0, 3, 4}, nil},
+		{"opened after the period", date("2019-10-20"), "2019-10-10", "2019-10-12", []int64{0, 0, 0}, nil},
+	}
+	for _, tt := range tests {
+		repo := &balances{opened: tt.opened}
+		h, err := NewService(repo, nil).Balances(as("auditor", auth.RoleAuditor), "acc", date(tt.from), date(tt.to))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if h.From != tt.from || h.To != tt.to || h.Days[0].Date != tt.from || h.Days[len(h.Days)-1].Date != tt.to {
+			t.Errorf("%s: got %s to %s, days %s to %s", tt.name, h.From, h.To, h.Days[0].Date, h.Days[len(h.Days)-1].Date)
+		}
+		if tt.want == nil {
+			continue
+		}
+		if len(h.Days) != len(tt.want) {
+			t.Errorf("%s: got %d days, want %d", tt.name, len(h.Days), len(tt.want))
+			continue
+		}
+		for i, d := range h.Days {
+			if !d.Balance.Equal(decimal.New(tt.want[i], 0)) {
+				t.Errorf("%s: %s balance %v, want %d", tt.name, d.Date, d.Balance, tt.want[i])
+			}
+		}
+	}
+
+	// The 30 days up to today are asked for by default.
+	repo := &balances{}
+	h, err := NewService(repo, nil).Balances(as("auditor", auth.RoleAuditor), "acc", time.Time{}, time.Time{})
+	today := time.Now().UTC().Truncate(day)
+	if err != nil || len(h.Days) != 30 || !repo.to.Equal(today) || !repo.from.Equal(today.AddDate(0, 0, -29)) {
+		t.Errorf("default: got %d days, %v, asked from %v to %v", len(h.Days), err, repo.from, repo.to)
+	}
+}
+
+func TestBalanceForbidden(t *testing.T) {
+	s := NewService(&balances{}, nil)
+	if _, err := s.Balance(as("bob", auth.RoleCustomer), "acc", time.Time{}); !errors.Is(err, errs.ErrForbidden) {
+		t.Errorf("balance: got %v, want %v", err, errs.ErrForbidden)
+	}
+	if _, err := s.Balances(as("bob", auth.RoleCustomer), "acc", time.Time{}, time.Time{}); !errors.Is(err, errs.ErrForbidden) {
+		t.Errorf("balances: got %v, want %v", err, errs.ErrForbidden)
+	}
+	if _, err := s.Balance(as("alice", auth.RoleCustomer), "acc", time.Time{}); err != nil {
+		t.Errorf("balance of the owner: %v", err)
+	}
+}
+
+// snapshots is a Repository recording the moments snapshots are taken as of, and cancelling the
+// context after the second round.
+type snapshots struct {
+	Repository
+	taken  []time.Time
+	cancel context.CancelFunc
+}
+
+func (r *snapshots) StoreSnapshots(at time.Time) (int, error) {
+	r.taken = append(r.taken, at)
+	if len(r.taken) == 2 {
+		r.cancel()
+	}
+	return 1, nil
+}
+
+func TestTakeSnapshots(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	repo := &snapshots{cancel: cancel}
+	delay := 3 * time.Hour
+	before := time.Now().UTC().Add(-delay).Truncate(day)
+	TakeSnapshots(ctx, repo, time.Millisecond, delay, log.NewNopLogger())
+	after := time.Now().UTC().Add(-delay).Truncate(day)
+
+	if len(repo.taken) < 2 {
+		t.Fatalf("took %d rounds of snapshots", len(repo.taken))
+	}
+	for _, at := range repo.taken {
+		if at.Before(before) || at.After(after) || !at.Equal(at.Truncate(day)) {
+			t.Errorf("took snapshots as of %v, want the midnight %v ago", at, delay)
+		}
+	}
+}
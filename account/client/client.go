@@ -0,0 +1,392 @@
+// Package client provides account.Service implementation talking to a remote
+// account service over its HTTP API.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/kit/sd/lb"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+// Option configures a client.
+type Option func(*options)
+
+type options struct {
+	httpClient *http.Client
+	timeout    time.Duration
+	retries    int
+	before     []kithttp.RequestFunc
+}
+
+// WithHTTPClient sets HTTP client used for requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) { o.httpClient = c }
+}
+
+// WithAPIKey authenticates every call with the API key.
+func WithAPIKey(key string) Option {
+	return func(o *options) { o.before = append(o.before, kithttp.SetRequestHeader("X-API-Key", key)) }
+}
+
+// WithBearerToken authenticates every call with the JWT bearer token.
+func WithBearerToken(token string) Option {
+	return func(o *options) {
+		o.before = append(o.before, kithttp.SetRequestHeader("Authorization", "Bearer "+token))
+	}
+}
+
+// WithTimeout sets time limit for one call, all its retries included.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithRetries sets how many times idempotent calls are attempted on transport or server failure.
+func WithRetries(n int) Option {
+	return func(o *options) { o.retries = n }
+}
+
+type client struct {
+	timeout time.Duration
+
+	newAccount endpoint.Endpoint
+	load       endpoint.Endpoint
+	loadAll    endpoint.Endpoint
+	byCustomer endpoint.Endpoint
+	delete     endpoint.Endpoint
+	restore    endpoint.Endpoint
+	freeze     endpoint.Endpoint
+	unfreeze   endpoint.Endpoint
+	addOwner   endpoint.Endpoint
+	balance    endpoint.Endpoint
+	balances   endpoint.Endpoint
+}
+
+// New returns an account.Service backed by the HTTP API at instance, e.g. "http://accounts:8080".
+func New(instance string, opts ...Option) (account.Service, error) {
+	o := options{
+		httpClient: http.DefaultClient,
+		timeout:    10 * time.Second,
+		retries:    3,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if !strings.HasPrefix(instance, "http") {
+		instance = "http://" + instance
+	}
+	base, err := url.Parse(instance)
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSuffix(base.Path, "/") + "/api/accounts/v1"
+	customers := *base
+	customers.Path = root + "/customers"
+	base.Path = root + "/accounts"
+
+	copts := []kithttp.ClientOption{
+		kithttp.SetClient(o.httpClient),
+		kithttp.ClientBefore(o.before...),
+	}
+	idempotent := func(e endpoint.Endpoint) endpoint.Endpoint {
+		return retry(e, o.retries, o.timeout)
+	}
+
+	return &client{
+		timeout: o.timeout,
+		newAccount: kithttp.NewClient(
+			"POST", base, kithttp.EncodeJSONRequest, decodeErrorOnlyResponse, copts...,
+		).Endpoint(),
+		load: idempotent(kithttp.NewClient(
+			"GET", base, encodeIDRequest, decodeLoadAccountResponse, copts...,
+		).Endpoint()),
+		loadAll: idempotent(kithttp.NewClient(
+			"GET", base, encodeEmptyRequest, decodeLoadAllAccountsResponse, copts...,
+		).Endpoint()),
+		byCustomer: idempotent(kithttp.NewClient(
+			"GET", &customers, encodeCustomerIDRequest, decodeLoadAllAccountsResponse, copts...,
+		).Endpoint()),
+		delete: kithttp.NewClient(
+			"DELETE", base, encodeIDRequest, decodeErrorOnlyResponse, copts...,
+		).Endpoint(),
+		restore: kithttp.NewClient(
+			"POST", base, encodeRestoreRequest, decodeErrorOnlyResponse, copts...,
+		).Endpoint(),
+		freeze: kithttp.NewClient(
+			"POST", base, encodeActionRequest("freeze"), decodeErrorOnlyResponse, copts...,
+		).Endpoint(),
+		unfreeze: kithttp.NewClient(
+			"POST", base, encodeActionRequest("unfreeze"), decodeErrorOnlyResponse, copts...,
+		).Endpoint(),
+		addOwner: kithttp.NewClient(
+			"POST", base, encodeAddOwnerRequest, decodeErrorOnlyResponse, copts...,
+		).Endpoint(),
+		balance: idempotent(kithttp.NewClient(
+			"GET", base, encodeBalanceRequest, decodeBalanceResponse, copts...,
+		).Endpoint()),
+		balances: idempotent(kithttp.NewClient(
+			"GET", base, encodeBalancesRequest, decodeBalancesResponse, copts...,
+		).Endpoint()),
+	}, nil
+}
+
+// New registers a new account in the system, with desired Balance.
+func (c *client) New(ctx context.Context, id account.ID, customerID uuid.UUID, country account.Country, city account.City, currency account.Currency, balance decimal.Decimal) error {
+	_, err := c.call(ctx, c.newAccount, newAccountRequest{
+		ID:       id,
+		Customer: customerID,
+		Country:  country,
+		City:     city,
+		Currency: currency,
+		Balance:  balance,
+	})
+	return err
+}
+
+// Load returns a read model of an account.
+func (c *client) Load(ctx context.Context, id account.ID) (*account.Account, error) {
+	resp, err := c.call(ctx, c.load, id)
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*account.Account), nil
+}
+
+// LoadAll returns all accounts registered in the system, nil when the call fails.
+func (c *client) LoadAll(ctx context.Context) []*account.Account {
+	resp, err := c.call(ctx, c.loadAll, nil)
+	if err != nil {
+		return nil
+	}
+	return resp.([]*account.Account)
+}
+
+// LoadByCustomer returns accounts held by the customer.
+func (c *client) LoadByCustomer(ctx context.Context, customerID uuid.UUID) ([]*account.Account, error) {
+	resp, err := c.call(ctx, c.byCustomer, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return resp.([]*account.Account), nil
+}
+
+// Delete marks account as deleted.
+func (c *client) Delete(ctx context.Context, id account.ID) error {
+	_, err := c.call(ctx, c.delete, id)
+	return err
+}
+
+// Restore brings a deleted account back.
+func (c *client) Restore(ctx context.Context, id account.ID) error {
+	_, err := c.call(ctx, c.restore, id)
+	return err
+}
+
+// Freeze stops money from moving in or out of an account until it is unfrozen.
+func (c *client) Freeze(ctx context.Context, id account.ID) error {
+	_, err := c.call(ctx, c.freeze, id)
+	return err
+}
+
+// Unfreeze lets money move in and out of a frozen account again.
+func (c *client) Unfreeze(ctx context.Context, id account.ID) error {
+	_, err := c.call(ctx, c.unfreeze, id)
+	return err
+}
+
+// AddOwner makes the principal an owner of the account.
+func (c *client) AddOwner(ctx context.Context, id account.ID, principal string) error {
+	_, err := c.call(ctx, c.addOwner, addOwnerRequest{ID: id, Principal: principal})
+	return err
+}
+
+// Balance returns the balance of an account at a moment, now when at is zero.
+func (c *client) Balance(ctx context.Context, id account.ID, at time.Time) (account.Balance, error) {
+	resp, err := c.call(ctx, c.balance, balanceRequest{ID: id, At: at})
+	if err != nil {
+		return account.Balance{}, err
+	}
+	return resp.(account.Balance), nil
+}
+
+// Balances returns the balance of an account at the end of every day of a period, days UTC.
+func (c *client) Balances(ctx context.Context, id account.ID, from, to time.Time) (account.BalanceHistory, error) {
+	resp, err := c.call(ctx, c.balances, balancesRequest{ID: id, From: from, To: to})
+	if err != nil {
+		return account.BalanceHistory{}, err
+	}
+	return resp.(account.BalanceHistory), nil
+}
+
+func (c *client) call(ctx context.Context, e endpoint.Endpoint, request interface{}) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := e(ctx, request)
+	if re, ok := err.(lb.RetryError); ok {
+		err = re.Final
+	}
+	return resp, err
+}
+
+// retry repeats calls of the endpoint failed by transport or server errors, up to max attempts.
+func retry(e endpoint.Endpoint, max int, timeout time.Duration) endpoint.Endpoint {
+	balancer := lb.NewRoundRobin(sd.FixedEndpointer{e})
+	return lb.RetryWithCallback(timeout, balancer, func(n int, err error) (bool, error) {
+		return n < max && errs.Retryable(err), nil
+	})
+}
+
+type newAccountRequest struct {
+	ID       account.ID       `json:"id"`
+	Customer uuid.UUID        `json:"customer"`
+	Country  account.Country  `json:"country"`
+	City     account.City     `json:"city"`
+	Currency account.Currency `json:"currency,omitempty"`
+	Balance  decimal.Decimal  `json:"balance"`
+}
+
+// addPath appends segments to the path of u, each escaped once.
+func addPath(u *url.URL, segments ...string) {
+	raw := u.EscapedPath()
+	for _, s := range segments {
+		u.Path += "/" + s
+		raw += "/" + url.PathEscape(s)
+	}
+	u.RawPath = raw
+}
+
+func encodeIDRequest(_ context.Context, r *http.Request, request interface{}) error {
+	addPath(r.URL, string(request.(account.ID)))
+	return nil
+}
+
+type addOwnerRequest struct {
+	ID        account.ID `json:"-"`
+	Principal string     `json:"principal"`
+}
+
+func encodeCustomerIDRequest(_ context.Context, r *http.Request, request interface{}) error {
+	addPath(r.URL, request.(uuid.UUID).String(), "accounts")
+	return nil
+}
+
+func encodeRestoreRequest(_ context.Context, r *http.Request, request interface{}) error {
+	addPath(r.URL, string(request.(account.ID)), "restore")
+	return nil
+}
+
+// encodeActionRequest returns an encoder of calls posting the action to the account.
+func encodeActionRequest(action string) kithttp.EncodeRequestFunc {
+	return func(_ context.Context, r *http.Request, request interface{}) error {
+		addPath(r.URL, string(request.(account.ID)), action)
+		return nil
+	}
+}
+
+func encodeAddOwnerRequest(ctx context.Context, r *http.Request, request interface{}) error {
+	req := request.(addOwnerRequest)
+	addPath(r.URL, string(req.ID), "owners")
+	return kithttp.EncodeJSONRequest(ctx, r, req)
+}
+
+type balanceRequest struct {
+	ID account.ID
+	At time.Time
+}
+
+func encodeBalanceRequest(_ context.Context, r *http.Request, request interface{}) error {
+	req := request.(balanceRequest)
+	addPath(r.URL, string(req.ID), "balance")
+	if !req.At.IsZero() {
+		r.URL.RawQuery = url.Values{"at": {req.At.Format(time.RFC3339Nano)}}.Encode()
+	}
+	return nil
+}
+
+type balancesRequest struct {
+	ID       account.ID
+	From, To time.Time
+}
+
+func encodeBalancesRequest(_ context.Context, r *http.Request, request interface{}) error {
+	req := request.(balancesRequest)
+	addPath(r.URL, string(req.ID), "balances")
+	q := url.Values{}
+	if !req.From.IsZero() {
+		q.Set("from", req.From.UTC().Format("2006-01-02"))
+	}
+	if !req.To.IsZero() {
+		q.Set("to", req.To.UTC().Format("2006-01-02"))
+	}
+	r.URL.RawQuery = q.Encode()
+	return nil
+}
+
+func encodeEmptyRequest(_ context.Context, _ *http.Request, _ interface{}) error {
+	return nil
+}
+
+func decodeErrorOnlyResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	return nil, errs.DecodeErrorResponse(r)
+}
+
+func decodeLoadAccountResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if err := errs.DecodeErrorResponse(r); err != nil {
+		return nil, err
+	}
+	var body struct {
+		Account *account.Account `json:"account"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Account == nil {
+		return nil, errs.ErrUnknownAccount
+	}
+	return body.Account, nil
+}
+
+func decodeLoadAllAccountsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if err := errs.DecodeErrorResponse(r); err != nil {
+		return nil, err
+	}
+	var accounts []*account.Account
+	if err := json.NewDecoder(r.Body).Decode(&accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func decodeBalanceResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if err := errs.DecodeErrorResponse(r); err != nil {
+		return nil, err
+	}
+	var b account.Balance
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func decodeBalancesResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if err := errs.DecodeErrorResponse(r); err != nil {
+		return nil, err
+	}
+	var h account.BalanceHistory
+	if err := json.NewDecoder(r.Body).Decode(&h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
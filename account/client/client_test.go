@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+// call is a request the server received.
+type call struct {
+	method, path, query, apiKey string
+	body                        map[string]interface{}
+}
+
+// server answers every request with status and body, or with err encoded as a problem, and
+// records the requests.
+type server struct {
+	status int
+	body   string
+	err    error
+	calls  []call
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c := call{method: r.Method, path: r.URL.EscapedPath(), query: r.URL.RawQuery, apiKey: r.Header.Get("X-API-Key")}
+	if b, _ := ioutil.ReadAll(r.Body); len(b) > 0 {
+		_ = json.Unmarshal(b, &c.body)
+	}
+	s.calls = append(s.calls, c)
+	if s.err != nil {
+		errs.EncodeError(r.Context(), s.err, w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(s.status)
+	_, _ = w.Write([]byte(s.body))
+}
+
+// setup returns a client of a server answering with status and body.
+func setup(t *testing.T, status int, body string) (account.Service, *server, func()) {
+	s := &server{status: status, body: body}
+	srv := httptest.NewServer(s)
+	c, err := New(srv.URL, WithAPIKey("key.secret"), WithRetries(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, s, srv.Close
+}
+
+func TestRequests(t *testing.T) {
+	customerID := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	at := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		call        func(s account.Service) error
+		method      string
+		path, query string
+		body        map[string]interface{}
+	}{
+		{"new", func(s account.Service) error {
+			return s.New(ctx, "John", customerID, "US", "Austin", account.CurrencyUSD, decimal.RequireFromString("10.50"))
+		}, "POST", "/api/accounts/v1/accounts", "", map[string]interface{}{
+			"id": "John", "customer": customerID.String(), "country": "US", "city": "Austin", "currency": "USD", "balance": "10.5",
+		}},
+		{"delete", func(s account.Service) error { return s.Delete(ctx, "John Doe") }, "DELETE", "/api/accounts/v1/accounts/John%20Doe", "", nil},
+		{"restore", func(s account.Service) error { return s.Restore(ctx, "John") }, "POST", "/api/accounts/v1/accounts/John/restore", "", nil},
+		{"freeze", func(s account.Service) error { return s.Freeze(ctx, "John") }, "POST", "/api/accounts/v1/accounts/John/freeze", "", nil},
+		{"unfreeze", func(s account.Service) error { return s.Unfreeze(ctx, "John") }, "POST", "/api/accounts/v1/accounts/John/unfreeze", "", nil},
+		{"add owner", func(s account.Service) error { return s.AddOwner(ctx, "John", "alice") }, "POST", "/api/accounts/v1/accounts/John/owners", "",
+			map[string]interface{}{"principal": "alice"}},
+		{"balance", func(s account.Service) error {
+			_, err := s.Balance(ctx, "John", at)
+			return err
+		}, "GET", "/api/accounts/v1/accounts/John/balance", "at=2019-10-01T12%3A00%3A00Z", nil},
+		{"balances", func(s account.Service) error {
+			_, err := s.Balances(ctx, "John", at, at.AddDate(0, 0, 2))
+			return err
+		}, "GET", "/api/accounts/v1/accounts/John/balances", "from=2019-10-01&to=2019-10-03", nil},
+		{"by customer", func(s account.Service) error {
+			_, err := s.LoadByCustomer(ctx, customerID)
+			return err
+		}, "GET", "/api/accounts/v1/customers/" + customerID.String() + "/accounts", "", nil},
+	}
+	for _, tt := range tests {
+		// Answers are decoded into what every call expects, an empty object or list.
+		body := "{}"
+		if tt.name == "by customer" {
+			body = "[]"
+		}
+		c, s, done := setup(t, http.StatusOK, body)
+		err := tt.call(c)
+		done()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(s.calls) != 1 {
+			t.Errorf("%s: %d requests", tt.name, len(s.calls))
+			continue
+		}
+		got := s.calls[0]
+		if got.method != tt.method || got.path != tt.path || got.query != tt.query || got.apiKey != "key.secret" {
+			t.Errorf("%s: got %+v", tt.name, got)
+		}
+		for k, v := range tt.body {
+			if fmt.Sprint(got.body[k]) != fmt.Sprint(v) {
+				t.Errorf("%s: %s is %v, want %v", tt.name, k, got.body[k], v)
+			}
+		}
+	}
+}
+
+func TestResponses(t *testing.T) {
+	ctx := context.Background()
+
+	c, _, done := setup(t, http.StatusOK, `{"account": {"id": "John", "country": "US", "city": "Austin", "balance": "10.5", "currency": "USD", "frozen": true}}`)
+	a, err := c.Load(ctx, "John")
+	done()
+	if err != nil || a.ID != "John" || a.City != "Austin" || !a.Balance.Equal(decimal.RequireFromString("10.5")) || !a.Frozen {
+		t.Errorf("load: got %+v, %v", a, err)
+	}
+
+	c, _, done = setup(t, http.StatusOK, `[{"id": "John"}, {"id": "Jane"}]`)
+	all := c.LoadAll(ctx)
+	done()
+	if len(all) != 2 || all[1].ID != "Jane" {
+		t.Errorf("load all: got %+v", all)
+	}
+
+	c, _, done = setup(t, http.StatusOK, `{"account": "John", "from": "2019-10-01", "to": "2019-10-02", "currency": "USD",
+		"days": [{"date": "2019-10-01", "balance": "1"}, {"date": "2019-10-02", "balance": "2"}]}`)
+	h, err := c.Balances(ctx, "John", time.Time{}, time.Time{})
+	done()
+	if err != nil || h.Account != "John" || len(h.Days) != 2 || !h.Days[1].Balance.Equal(decimal.New(2, 0)) {
+		t.Errorf("balances: got %+v, %v", h, err)
+	}
+
+	// An answer without the account means there is none.
+	c, _, done = setup(t, http.StatusOK, `{}`)
+	_, err = c.Load(ctx, "John")
+	done()
+	if !errors.Is(err, errs.ErrUnknownAccount) {
+		t.Errorf("load without account: got %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		body     string
+		want     func(error) bool
+		attempts int
+	}{
+		{"errs value", errs.ErrAccountFrozen, 0, "", func(err error) bool { return errors.Is(err, errs.ErrAccountFrozen) }, 1},
+		{"validation", errs.ValidationError{Err: errors.New("balance is invalid")}, 0, "", func(err error) bool {
+			var v errs.ValidationError
+			return errors.As(err, &v)
+		}, 1},
+		{"unknown problem", nil, http.StatusTeapot, `{"code": "teapot", "title": "short and stout"}`, func(err error) bool {
+			var s errs.StatusError
+			return errors.As(err, &s) && s.Code == http.StatusTeapot && s.ErrorCode == "teapot"
+		}, 1},
+		// Server errors are retried by idempotent calls.
+		{"server error", nil, http.StatusBadGateway, "", func(err error) bool {
+			var s errs.StatusError
+			return errors.As(err, &s) && s.Code == http.StatusBadGateway
+		}, 2},
+	}
+	for _, tt := range tests {
+		c, s, done := setup(t, tt.status, tt.body)
+		s.err = tt.err
+		_, err := c.Load(ctx, "John")
+		if !tt.want(err) || len(s.calls) != tt.attempts {
+			t.Errorf("%s: load got %v after %d requests", tt.name, err, len(s.calls))
+		}
+		// Calls changing accounts are never retried.
+		s.calls = nil
+		if err := c.Freeze(ctx, "John"); !tt.want(err) || len(s.calls) != 1 {
+			t.Errorf("%s: freeze got %v after %d requests", tt.name, err, len(s.calls))
+		}
+		done()
+	}
+}
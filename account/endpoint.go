@@ -2,7 +2,10 @@ package account
 
 import (
 	"context"
+	"time"
+
 	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
 
 	"github.com/ilyareist/task1/errs"
 
@@ -24,23 +27,64 @@ func init() {
 	})
 }
 
+// Endpoints collects all of the endpoints that compose an account service,
+// so that every transport serves the very same set.
+type Endpoints struct {
+	NewAccountEndpoint      endpoint.Endpoint
+	LoadAccountEndpoint     endpoint.Endpoint
+	LoadAllAccountsEndpoint endpoint.Endpoint
+	LoadByCustomerEndpoint  endpoint.Endpoint
+	DeleteAccountEndpoint   endpoint.Endpoint
+	RestoreAccountEndpoint  endpoint.Endpoint
+	FreezeAccountEndpoint   endpoint.Endpoint
+	UnfreezeAccountEndpoint endpoint.Endpoint
+	AddOwnerEndpoint        endpoint.Endpoint
+	BalanceEndpoint         endpoint.Endpoint
+	BalancesEndpoint        endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints wired to the provided service, each wrapped
+// by middlewares in the given order, the first one being the outermost.
+func MakeEndpoints(s Service, mws ...endpoint.Middleware) Endpoints {
+	wrap := func(e endpoint.Endpoint) endpoint.Endpoint {
+		for i := len(mws) - 1; i >= 0; i-- {
+			e = mws[i](e)
+		}
+		return e
+	}
+	return Endpoints{
+		NewAccountEndpoint:      wrap(makeNewAccountEndpoint(s)),
+		LoadAccountEndpoint:     wrap(makeLoadAccountEndpoint(s)),
+		LoadAllAccountsEndpoint: wrap(makeLoadAllAccountsEndpoint(s)),
+		LoadByCustomerEndpoint:  wrap(makeLoadByCustomerEndpoint(s)),
+		DeleteAccountEndpoint:   wrap(makeDeleteAccountEndpoint(s)),
+		RestoreAccountEndpoint:  wrap(makeRestoreAccountEndpoint(s)),
+		FreezeAccountEndpoint:   wrap(makeFreezeAccountEndpoint(s)),
+		UnfreezeAccountEndpoint: wrap(makeUnfreezeAccountEndpoint(s)),
+		AddOwnerEndpoint:        wrap(makeAddOwnerEndpoint(s)),
+		BalanceEndpoint:         wrap(makeBalanceEndpoint(s)),
+		BalancesEndpoint:        wrap(makeBalancesEndpoint(s)),
+	}
+}
+
 type idField struct {
-	ID  ID `json:"id" valid:"alphanum,required"`
-	Amount  decimal.Decimal `json:"amount" valid:"decimal"`
+	ID     ID              `json:"id" valid:"alphanum,required"`
+	Amount decimal.Decimal `json:"amount" valid:"decimal"`
 }
 
 type newAccountRequest struct {
 	ID       ID              `json:"id" valid:"alphanum,required,stringlength(1|255)"`
+	Customer uuid.UUID       `json:"customer" valid:"required"`
 	Country  Country         `json:"country"`
 	City     City            `json:"city"`
 	Currency Currency        `json:"currency" valid:"in(USD|RUB)"`
-	Balance  decimal.Decimal `json:"balance" valid:"decimal"`
+	Balance  decimal.Decimal `json:"balance" valid:"decimal,nonnegative,money"`
 }
 
 func makeNewAccountEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(newAccountRequest)
-		err := s.New(req.ID, req.Country, req.City, req.Currency, req.Balance)
+		err := s.New(ctx, req.ID, req.Customer, req.Country, req.City, req.Currency, req.Balance)
 		return errs.ErrorOnlyResponse{Err: err}, nil
 	}
 }
@@ -55,14 +99,29 @@ func (r loadAccountResponse) ErrError() error { return r.Err }
 func makeLoadAccountEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(idField)
-		a, err := s.Load(req.ID)
+		a, err := s.Load(ctx, req.ID)
 		return loadAccountResponse{Account: a, Err: err}, nil
 	}
 }
 
 func makeLoadAllAccountsEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		r := s.LoadAll()
+		r := s.LoadAll(ctx)
+		return r, nil
+	}
+}
+
+type customerIDRequest struct {
+	Customer uuid.UUID
+}
+
+func makeLoadByCustomerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(customerIDRequest)
+		r, err := s.LoadByCustomer(ctx, req.Customer)
+		if err != nil {
+			return nil, err
+		}
 		return r, nil
 	}
 }
@@ -70,7 +129,84 @@ func makeLoadAllAccountsEndpoint(s Service) endpoint.Endpoint {
 func makeDeleteAccountEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(idField)
-		err := s.Delete(req.ID)
+		err := s.Delete(ctx, req.ID)
+		return errs.ErrorOnlyResponse{Err: err}, nil
+	}
+}
+
+func makeRestoreAccountEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idField)
+		err := s.Restore(ctx, req.ID)
 		return errs.ErrorOnlyResponse{Err: err}, nil
 	}
 }
+
+func makeFreezeAccountEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idField)
+		err := s.Freeze(ctx, req.ID)
+		return errs.ErrorOnlyResponse{Err: err}, nil
+	}
+}
+
+func makeUnfreezeAccountEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idField)
+		err := s.Unfreeze(ctx, req.ID)
+		return errs.ErrorOnlyResponse{Err: err}, nil
+	}
+}
+
+type addOwnerRequest struct {
+	ID        ID     `json:"-"`
+	Principal string `json:"principal" valid:"required,stringlength(1|255)"`
+}
+
+func makeAddOwnerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addOwnerRequest)
+		err := s.AddOwner(ctx, req.ID, req.Principal)
+		return errs.ErrorOnlyResponse{Err: err}, nil
+	}
+}
+
+type balanceRequest struct {
+	ID ID
+	At time.Time
+}
+
+type balanceResponse struct {
+	Balance
+	Err error `json:"error,omitempty"`
+}
+
+func (r balanceResponse) ErrError() error { return r.Err }
+
+func makeBalanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(balanceRequest)
+		b, err := s.Balance(ctx, req.ID, req.At)
+		return balanceResponse{Balance: b, Err: err}, nil
+	}
+}
+
+type balancesRequest struct {
+	ID       ID
+	From, To time.Time
+}
+
+type balancesResponse struct {
+	BalanceHistory
+	Err error `json:"error,omitempty"`
+}
+
+func (r balancesResponse) ErrError() error { return r.Err }
+
+func makeBalancesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(balancesRequest)
+		h, err := s.Balances(ctx, req.ID, req.From, req.To)
+		return balancesResponse{BalanceHistory: h, Err: err}, nil
+	}
+}
@@ -0,0 +1,88 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+type instrumentingService struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	Service
+}
+
+// NewInstrumentingService returns an instance of an instrumenting Service. Requests are counted
+// and timed by method and by errs label of the outcome, see errs.Label.
+func NewInstrumentingService(counter metrics.Counter, latency metrics.Histogram, s Service) Service {
+	return &instrumentingService{
+		requestCount:   counter,
+		requestLatency: latency,
+		Service:        s,
+	}
+}
+
+func (s *instrumentingService) observe(method string, begin time.Time, err error) {
+	lvs := []string{"method", method, "error", errs.Label(err)}
+	s.requestCount.With(lvs...).Add(1)
+	s.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+}
+
+func (s *instrumentingService) New(ctx context.Context, id ID, customerID uuid.UUID, country Country, city City, currency Currency, balance decimal.Decimal) (err error) {
+	defer func(begin time.Time) { s.observe("new", begin, err) }(time.Now())
+	return s.Service.New(ctx, id, customerID, country, city, currency, balance)
+}
+
+func (s *instrumentingService) Load(ctx context.Context, id ID) (a *Account, err error) {
+	defer func(begin time.Time) { s.observe("load", begin, err) }(time.Now())
+	return s.Service.Load(ctx, id)
+}
+
+func (s *instrumentingService) LoadAll(ctx context.Context) []*Account {
+	defer func(begin time.Time) { s.observe("load_all", begin, nil) }(time.Now())
+	return s.Service.LoadAll(ctx)
+}
+
+func (s *instrumentingService) LoadByCustomer(ctx context.Context, customerID uuid.UUID) (accounts []*Account, err error) {
+	defer func(begin time.Time) { s.observe("load_by_customer", begin, err) }(time.Now())
+	return s.Service.LoadByCustomer(ctx, customerID)
+}
+
+func (s *instrumentingService) Delete(ctx context.Context, id ID) (err error) {
+	defer func(begin time.Time) { s.observe("delete", begin, err) }(time.Now())
+	return s.Service.Delete(ctx, id)
+}
+
+func (s *instrumentingService) Restore(ctx context.Context, id ID) (err error) {
+	defer func(begin time.Time) { s.observe("restore", begin, err) }(time.Now())
+	return s.Service.Restore(ctx, id)
+}
+
+func (s *instrumentingService) Freeze(ctx context.Context, id ID) (err error) {
+	defer func(begin time.Time) { s.observe("freeze", begin, err) }(time.Now())
+	return s.Service.Freeze(ctx, id)
+}
+
+func (s *instrumentingService) Unfreeze(ctx context.Context, id ID) (err error) {
+	defer func(begin time.Time) { s.observe("unfreeze", begin, err) }(time.Now())
+	return s.Service.Unfreeze(ctx, id)
+}
+
+func (s *instrumentingService) AddOwner(ctx context.Context, id ID, principal string) (err error) {
+	defer func(begin time.Time) { s.observe("add_owner", begin, err) }(time.Now())
+	return s.Service.AddOwner(ctx, id, principal)
+}
+
+func (s *instrumentingService) Balance(ctx context.Context, id ID, at time.Time) (b Balance, err error) {
+	defer func(begin time.Time) { s.observe("balance", begin, err) }(time.Now())
+	return s.Service.Balance(ctx, id, at)
+}
+
+func (s *instrumentingService) Balances(ctx context.Context, id ID, from, to time.Time) (h BalanceHistory, err error) {
+	defer func(begin time.Time) { s.observe("balances", begin, err) }(time.Now())
+	return s.Service.Balances(ctx, id, from, to)
+}
@@ -0,0 +1,115 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/requestid"
+	"github.com/shopspring/decimal"
+)
+
+type loggingService struct {
+	logger log.Logger
+	Service
+}
+
+// NewLoggingService returns a new instance of a logging Service. Every call is logged
+// with the request id and the principal found in the context.
+func NewLoggingService(logger log.Logger, s Service) Service {
+	return &loggingService{logger, s}
+}
+
+func (s *loggingService) log(ctx context.Context, keyvals ...interface{}) {
+	p, _ := auth.FromContext(ctx)
+	_ = log.With(s.logger, "request_id", requestid.FromContext(ctx), "principal", p.ID).Log(keyvals...)
+}
+
+func (s *loggingService) New(ctx context.Context, id ID, customerID uuid.UUID, country Country, city City, currency Currency, balance decimal.Decimal) (err error) {
+	defer func(begin time.Time) {
+		s.log(ctx,
+			"method", "new",
+			"account_id", id,
+			"customer_id", customerID,
+			"country", country,
+			"city", city,
+			"currency", currency,
+			"balance", balance,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.New(ctx, id, customerID, country, city, currency, balance)
+}
+
+func (s *loggingService) Load(ctx context.Context, id ID) (a *Account, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "load", "account_id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Load(ctx, id)
+}
+
+func (s *loggingService) LoadAll(ctx context.Context) (accounts []*Account) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "load_all", "count", len(accounts), "took", time.Since(begin))
+	}(time.Now())
+	return s.Service.LoadAll(ctx)
+}
+
+func (s *loggingService) LoadByCustomer(ctx context.Context, customerID uuid.UUID) (accounts []*Account, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "load_by_customer", "customer_id", customerID, "count", len(accounts), "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.LoadByCustomer(ctx, customerID)
+}
+
+func (s *loggingService) Delete(ctx context.Context, id ID) (err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "delete", "account_id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Delete(ctx, id)
+}
+
+func (s *loggingService) Restore(ctx context.Context, id ID) (err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "restore", "account_id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Restore(ctx, id)
+}
+
+func (s *loggingService) Freeze(ctx context.Context, id ID) (err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "freeze", "account_id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Freeze(ctx, id)
+}
+
+func (s *loggingService) Unfreeze(ctx context.Context, id ID) (err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "unfreeze", "account_id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Unfreeze(ctx, id)
+}
+
+func (s *loggingService) AddOwner(ctx context.Context, id ID, principal string) (err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "add_owner", "account_id", id, "owner", principal, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.AddOwner(ctx, id, principal)
+}
+
+func (s *loggingService) Balance(ctx context.Context, id ID, at time.Time) (b Balance, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "balance", "account_id", id, "at", at, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Balance(ctx, id, at)
+}
+
+func (s *loggingService) Balances(ctx context.Context, id ID, from, to time.Time) (h BalanceHistory, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "balances", "account_id", id, "from", from, "to", to, "count", len(h.Days), "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Balances(ctx, id, from, to)
+}
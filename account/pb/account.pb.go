@@ -0,0 +1,730 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: account.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Empty struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Empty) Reset()         { *m = Empty{} }
+func (m *Empty) String() string { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()    {}
+func (*Empty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e28828dcb8d24f0, []int{0}
+}
+
+func (m *Empty) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Empty.Unmarshal(m, b)
+}
+func (m *Empty) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Empty.Marshal(b, m, deterministic)
+}
+func (m *Empty) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Empty.Merge(m, src)
+}
+func (m *Empty) XXX_Size() int {
+	return xxx_messageInfo_Empty.Size(m)
+}
+func (m *Empty) XXX_DiscardUnknown() {
+	xxx_messageInfo_Empty.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Empty proto.InternalMessageInfo
+
+// Account is a wallet in the system. Balance is a decimal number in string form.
+type Account struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Country              string   `protobuf:"bytes,2,opt,name=country,proto3" json:"country,omitempty"`
+	City                 string   `protobuf:"bytes,3,opt,name=city,proto3" json:"city,omitempty"`
+	Balance              string   `protobuf:"bytes,4,opt,name=balance,proto3" json:"balance,omitempty"`
+	Currency             string   `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
+	Customer             string   `protobuf:"bytes,6,opt,name=customer,proto3" json:"customer,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Account) Reset()         { *m = Account{} }
+func (m *Account) String() string { return proto.CompactTextString(m) }
+func (*Account) ProtoMessage()    {}
+func (*Account) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e28828dcb8d24f0, []int{1}
+}
+
+func (m *Account) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Account.Unmarshal(m, b)
+}
+func (m *Account) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Account.Marshal(b, m, deterministic)
+}
+func (m *Account) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Account.Merge(m, src)
+}
+func (m *Account) XXX_Size() int {
+	return xxx_messageInfo_Account.Size(m)
+}
+func (m *Account) XXX_DiscardUnknown() {
+	xxx_messageInfo_Account.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Account proto.InternalMessageInfo
+
+func (m *Account) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Account) GetCountry() string {
+	if m != nil {
+		return m.Country
+	}
+	return ""
+}
+
+func (m *Account) GetCity() string {
+	if m != nil {
+		return m.City
+	}
+	return ""
+}
+
+func (m *Account) GetBalance() string {
+	if m != nil {
+		return m.Balance
+	}
+	return ""
+}
+
+func (m *Account) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+func (m *Account) GetCustomer() string {
+	if m != nil {
+		return m.Customer
+	}
+	return ""
+}
+
+type NewAccountRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Country              string   `protobuf:"bytes,2,opt,name=country,proto3" json:"country,omitempty"`
+	City                 string   `protobuf:"bytes,3,opt,name=city,proto3" json:"city,omitempty"`
+	Currency             string   `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	Balance              string   `protobuf:"bytes,5,opt,name=balance,proto3" json:"balance,omitempty"`
+	Customer             string   `protobuf:"bytes,6,opt,name=customer,proto3" json:"customer,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NewAccountRequest) Reset()         { *m = NewAccountRequest{} }
+func (m *NewAccountRequest) String() string { return proto.CompactTextString(m) }
+func (*NewAccountRequest) ProtoMessage()    {}
+func (*NewAccountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e28828dcb8d24f0, []int{2}
+}
+
+func (m *NewAccountRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NewAccountRequest.Unmarshal(m, b)
+}
+func (m *NewAccountRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NewAccountRequest.Marshal(b, m, deterministic)
+}
+func (m *NewAccountRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NewAccountRequest.Merge(m, src)
+}
+func (m *NewAccountRequest) XXX_Size() int {
+	return xxx_messageInfo_NewAccountRequest.Size(m)
+}
+func (m *NewAccountRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NewAccountRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NewAccountRequest proto.InternalMessageInfo
+
+func (m *NewAccountRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *NewAccountRequest) GetCountry() string {
+	if m != nil {
+		return m.Country
+	}
+	return ""
+}
+
+func (m *NewAccountRequest) GetCity() string {
+	if m != nil {
+		return m.City
+	}
+	return ""
+}
+
+func (m *NewAccountRequest) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+func (m *NewAccountRequest) GetBalance() string {
+	if m != nil {
+		return m.Balance
+	}
+	return ""
+}
+
+func (m *NewAccountRequest) GetCustomer() string {
+	if m != nil {
+		return m.Customer
+	}
+	return ""
+}
+
+type AccountIDRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AccountIDRequest) Reset()         { *m = AccountIDRequest{} }
+func (m *AccountIDRequest) String() string { return proto.CompactTextString(m) }
+func (*AccountIDRequest) ProtoMessage()    {}
+func (*AccountIDRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e28828dcb8d24f0, []int{3}
+}
+
+func (m *AccountIDRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccountIDRequest.Unmarshal(m, b)
+}
+func (m *AccountIDRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccountIDRequest.Marshal(b, m, deterministic)
+}
+func (m *AccountIDRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccountIDRequest.Merge(m, src)
+}
+func (m *AccountIDRequest) XXX_Size() int {
+	return xxx_messageInfo_AccountIDRequest.Size(m)
+}
+func (m *AccountIDRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccountIDRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccountIDRequest proto.InternalMessageInfo
+
+func (m *AccountIDRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type CustomerIDRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CustomerIDRequest) Reset()         { *m = CustomerIDRequest{} }
+func (m *CustomerIDRequest) String() string { return proto.CompactTextString(m) }
+func (*CustomerIDRequest) ProtoMessage()    {}
+func (*CustomerIDRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e28828dcb8d24f0, []int{4}
+}
+
+func (m *CustomerIDRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CustomerIDRequest.Unmarshal(m, b)
+}
+func (m *CustomerIDRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CustomerIDRequest.Marshal(b, m, deterministic)
+}
+func (m *CustomerIDRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CustomerIDRequest.Merge(m, src)
+}
+func (m *CustomerIDRequest) XXX_Size() int {
+	return xxx_messageInfo_CustomerIDRequest.Size(m)
+}
+func (m *CustomerIDRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CustomerIDRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CustomerIDRequest proto.InternalMessageInfo
+
+func (m *CustomerIDRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type AddOwnerRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Principal            string   `protobuf:"bytes,2,opt,name=principal,proto3" json:"principal,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddOwnerRequest) Reset()         { *m = AddOwnerRequest{} }
+func (m *AddOwnerRequest) String() string { return proto.CompactTextString(m) }
+func (*AddOwnerRequest) ProtoMessage()    {}
+func (*AddOwnerRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e28828dcb8d24f0, []int{5}
+}
+
+func (m *AddOwnerRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddOwnerRequest.Unmarshal(m, b)
+}
+func (m *AddOwnerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddOwnerRequest.Marshal(b, m, deterministic)
+}
+func (m *AddOwnerRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddOwnerRequest.Merge(m, src)
+}
+func (m *AddOwnerRequest) XXX_Size() int {
+	return xxx_messageInfo_AddOwnerRequest.Size(m)
+}
+func (m *AddOwnerRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddOwnerRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddOwnerRequest proto.InternalMessageInfo
+
+func (m *AddOwnerRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *AddOwnerRequest) GetPrincipal() string {
+	if m != nil {
+		return m.Principal
+	}
+	return ""
+}
+
+type AccountsReply struct {
+	Accounts             []*Account `protobuf:"bytes,1,rep,name=accounts,proto3" json:"accounts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *AccountsReply) Reset()         { *m = AccountsReply{} }
+func (m *AccountsReply) String() string { return proto.CompactTextString(m) }
+func (*AccountsReply) ProtoMessage()    {}
+func (*AccountsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e28828dcb8d24f0, []int{6}
+}
+
+func (m *AccountsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccountsReply.Unmarshal(m, b)
+}
+func (m *AccountsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccountsReply.Marshal(b, m, deterministic)
+}
+func (m *AccountsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccountsReply.Merge(m, src)
+}
+func (m *AccountsReply) XXX_Size() int {
+	return xxx_messageInfo_AccountsReply.Size(m)
+}
+func (m *AccountsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccountsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccountsReply proto.InternalMessageInfo
+
+func (m *AccountsReply) GetAccounts() []*Account {
+	if m != nil {
+		return m.Accounts
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Empty)(nil), "account.v1.Empty")
+	proto.RegisterType((*Account)(nil), "account.v1.Account")
+	proto.RegisterType((*NewAccountRequest)(nil), "account.v1.NewAccountRequest")
+	proto.RegisterType((*AccountIDRequest)(nil), "account.v1.AccountIDRequest")
+	proto.RegisterType((*CustomerIDRequest)(nil), "account.v1.CustomerIDRequest")
+	proto.RegisterType((*AddOwnerRequest)(nil), "account.v1.AddOwnerRequest")
+	proto.RegisterType((*AccountsReply)(nil), "account.v1.AccountsReply")
+}
+
+func init() { proto.RegisterFile("account.proto", fileDescriptor_8e28828dcb8d24f0) }
+
+var fileDescriptor_8e28828dcb8d24f0 = []byte{
+	// 395 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x93, 0xcd, 0x6e, 0xda, 0x40,
+	0x10, 0xc7, 0xf1, 0x07, 0x18, 0xa6, 0x82, 0x96, 0xed, 0x65, 0xeb, 0x52, 0x09, 0x6d, 0x2f, 0x9c,
+	0xa8, 0x4a, 0x4f, 0x88, 0x4a, 0x09, 0x84, 0x1c, 0x22, 0x21, 0x22, 0x39, 0xb7, 0xdc, 0xcc, 0x7a,
+	0x0e, 0x96, 0x8c, 0xed, 0xac, 0x0d, 0xc8, 0x8f, 0x92, 0x73, 0xde, 0x26, 0x4f, 0x15, 0x61, 0xaf,
+	0xc1, 0x04, 0x3b, 0x91, 0x92, 0x1b, 0xf3, 0xf1, 0x5f, 0x7e, 0xff, 0x99, 0x31, 0xb4, 0x6d, 0xce,
+	0x83, 0x8d, 0x1f, 0x0f, 0x43, 0x11, 0xc4, 0x01, 0x81, 0x3c, 0xdc, 0xfe, 0x65, 0x06, 0xd4, 0xaf,
+	0xd7, 0x61, 0x9c, 0xb0, 0x47, 0x05, 0x8c, 0x69, 0x96, 0x27, 0x1d, 0x50, 0x5d, 0x87, 0x2a, 0x7d,
+	0x65, 0xd0, 0xb2, 0x54, 0xd7, 0x21, 0x14, 0x8c, 0xb4, 0x20, 0x12, 0xaa, 0xa6, 0xc9, 0x3c, 0x24,
+	0x04, 0x74, 0xee, 0xc6, 0x09, 0xd5, 0xd2, 0x74, 0xfa, 0x7b, 0xdf, 0xbd, 0xb2, 0x3d, 0xdb, 0xe7,
+	0x48, 0xf5, 0xac, 0x5b, 0x86, 0xc4, 0x84, 0x26, 0xdf, 0x08, 0x81, 0x3e, 0x4f, 0x68, 0x3d, 0x2d,
+	0x1d, 0xe2, 0xac, 0x16, 0xc5, 0xc1, 0x1a, 0x05, 0x6d, 0xe4, 0xb5, 0x2c, 0x66, 0x4f, 0x0a, 0x74,
+	0x97, 0xb8, 0x93, 0x78, 0x16, 0x3e, 0x6c, 0x30, 0xfa, 0x2c, 0x65, 0x91, 0x45, 0x7f, 0xc5, 0x52,
+	0x70, 0x50, 0x2f, 0x71, 0x50, 0x41, 0xc9, 0xe0, 0x9b, 0x24, 0xbc, 0x99, 0x57, 0x30, 0xb2, 0xdf,
+	0xd0, 0xbd, 0x92, 0xfd, 0xd5, 0x4d, 0x17, 0xf0, 0x75, 0xea, 0x38, 0xb7, 0x3b, 0x1f, 0x45, 0x95,
+	0xd7, 0x1e, 0xb4, 0x42, 0xe1, 0xfa, 0xdc, 0x0d, 0x6d, 0x4f, 0xba, 0x3d, 0x26, 0xd8, 0x25, 0xb4,
+	0x25, 0x49, 0x64, 0x61, 0xe8, 0x25, 0xe4, 0x0f, 0x34, 0xe5, 0xce, 0x23, 0xaa, 0xf4, 0xb5, 0xc1,
+	0x97, 0xd1, 0xf7, 0xe1, 0xf1, 0x08, 0x86, 0xf9, 0x60, 0x0f, 0x4d, 0xa3, 0x67, 0x0d, 0x3a, 0x32,
+	0x7b, 0x87, 0x62, 0xeb, 0x72, 0x24, 0x63, 0xd0, 0x96, 0xb8, 0x23, 0xbf, 0x8a, 0xc2, 0xb3, 0xa5,
+	0x98, 0xdd, 0x62, 0x39, 0xbb, 0xac, 0x1a, 0x99, 0x80, 0xbe, 0x08, 0x6c, 0x87, 0xf4, 0x4a, 0xfe,
+	0xf4, 0x30, 0x06, 0xb3, 0x0c, 0x89, 0xd5, 0xc8, 0x18, 0x8c, 0xbd, 0x78, 0xea, 0x79, 0xe4, 0xfc,
+	0x71, 0xf3, 0x47, 0x89, 0x28, 0x33, 0xcd, 0x6a, 0x64, 0x01, 0x9d, 0xbd, 0x74, 0x96, 0xe4, 0x33,
+	0x3f, 0xa5, 0x3f, 0xdb, 0xc4, 0xdb, 0xaf, 0x4d, 0xa0, 0x31, 0x47, 0x0f, 0x63, 0x7c, 0xc7, 0x47,
+	0xe9, 0x08, 0xfe, 0x83, 0x61, 0x61, 0x14, 0x07, 0xe2, 0x83, 0xea, 0x66, 0x7e, 0x11, 0xe4, 0xe7,
+	0x89, 0xfc, 0xf4, 0x4e, 0x4a, 0xd5, 0x33, 0xfd, 0x5e, 0x0d, 0x57, 0xab, 0x46, 0xfa, 0xf1, 0xff,
+	0x7b, 0x19, 0x00, 0x89, 0x03, 0x15, 0x4b, 0x0d, 0x04, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// AccountServiceClient is the client API for AccountService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AccountServiceClient interface {
+	// New registers a new account in the system, with desired balance.
+	New(ctx context.Context, in *NewAccountRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Load returns a read model of an account.
+	Load(ctx context.Context, in *AccountIDRequest, opts ...grpc.CallOption) (*Account, error)
+	// LoadAll returns all accounts registered in the system.
+	LoadAll(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*AccountsReply, error)
+	// LoadByCustomer returns accounts held by the customer.
+	LoadByCustomer(ctx context.Context, in *CustomerIDRequest, opts ...grpc.CallOption) (*AccountsReply, error)
+	// Delete marks account as deleted.
+	Delete(ctx context.Context, in *AccountIDRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Restore brings a deleted account back.
+	Restore(ctx context.Context, in *AccountIDRequest, opts ...grpc.CallOption) (*Empty, error)
+	// AddOwner makes the principal an owner of the account.
+	AddOwner(ctx context.Context, in *AddOwnerRequest, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type accountServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAccountServiceClient(cc *grpc.ClientConn) AccountServiceClient {
+	return &accountServiceClient{cc}
+}
+
+func (c *accountServiceClient) New(ctx context.Context, in *NewAccountRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/account.v1.AccountService/New", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) Load(ctx context.Context, in *AccountIDRequest, opts ...grpc.CallOption) (*Account, error) {
+	out := new(Account)
+	err := c.cc.Invoke(ctx, "/account.v1.AccountService/Load", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) LoadAll(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*AccountsReply, error) {
+	out := new(AccountsReply)
+	err := c.cc.Invoke(ctx, "/account.v1.AccountService/LoadAll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) LoadByCustomer(ctx context.Context, in *CustomerIDRequest, opts ...grpc.CallOption) (*AccountsReply, error) {
+	out := new(AccountsReply)
+	err := c.cc.Invoke(ctx, "/account.v1.AccountService/LoadByCustomer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) Delete(ctx context.Context, in *AccountIDRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/account.v1.AccountService/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) Restore(ctx context.Context, in *AccountIDRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/account.v1.AccountService/Restore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountServiceClient) AddOwner(ctx context.Context, in *AddOwnerRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/account.v1.AccountService/AddOwner", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AccountServiceServer is the server API for AccountService service.
+type AccountServiceServer interface {
+	// New registers a new account in the system, with desired balance.
+	New(context.Context, *NewAccountRequest) (*Empty, error)
+	// Load returns a read model of an account.
+	Load(context.Context, *AccountIDRequest) (*Account, error)
+	// LoadAll returns all accounts registered in the system.
+	LoadAll(context.Context, *Empty) (*AccountsReply, error)
+	// LoadByCustomer returns accounts held by the customer.
+	LoadByCustomer(context.Context, *CustomerIDRequest) (*AccountsReply, error)
+	// Delete marks account as deleted.
+	Delete(context.Context, *AccountIDRequest) (*Empty, error)
+	// Restore brings a deleted account back.
+	Restore(context.Context, *AccountIDRequest) (*Empty, error)
+	// AddOwner makes the principal an owner of the account.
+	AddOwner(context.Context, *AddOwnerRequest) (*Empty, error)
+}
+
+// UnimplementedAccountServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedAccountServiceServer struct {
+}
+
+func (*UnimplementedAccountServiceServer) New(ctx context.Context, req *NewAccountRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method New not implemented")
+}
+func (*UnimplementedAccountServiceServer) Load(ctx context.Context, req *AccountIDRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Load not implemented")
+}
+func (*UnimplementedAccountServiceServer) LoadAll(ctx context.Context, req *Empty) (*AccountsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoadAll not implemented")
+}
+func (*UnimplementedAccountServiceServer) LoadByCustomer(ctx context.Context, req *CustomerIDRequest) (*AccountsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoadByCustomer not implemented")
+}
+func (*UnimplementedAccountServiceServer) Delete(ctx context.Context, req *AccountIDRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (*UnimplementedAccountServiceServer) Restore(ctx context.Context, req *AccountIDRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (*UnimplementedAccountServiceServer) AddOwner(ctx context.Context, req *AddOwnerRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddOwner not implemented")
+}
+
+func RegisterAccountServiceServer(s *grpc.Server, srv AccountServiceServer) {
+	s.RegisterService(&_AccountService_serviceDesc, srv)
+}
+
+func _AccountService_New_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).New(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.v1.AccountService/New",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).New(ctx, req.(*NewAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_Load_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).Load(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.v1.AccountService/Load",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).Load(ctx, req.(*AccountIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_LoadAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).LoadAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.v1.AccountService/LoadAll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).LoadAll(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_LoadByCustomer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CustomerIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).LoadByCustomer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.v1.AccountService/LoadByCustomer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).LoadByCustomer(ctx, req.(*CustomerIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.v1.AccountService/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).Delete(ctx, req.(*AccountIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.v1.AccountService/Restore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).Restore(ctx, req.(*AccountIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountService_AddOwner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddOwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).AddOwner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.v1.AccountService/AddOwner",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).AddOwner(ctx, req.(*AddOwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _AccountService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "account.v1.AccountService",
+	HandlerType: (*AccountServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "New",
+			Handler:    _AccountService_New_Handler,
+		},
+		{
+			MethodName: "Load",
+			Handler:    _AccountService_Load_Handler,
+		},
+		{
+			MethodName: "LoadAll",
+			Handler:    _AccountService_LoadAll_Handler,
+		},
+		{
+			MethodName: "LoadByCustomer",
+			Handler:    _AccountService_LoadByCustomer_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _AccountService_Delete_Handler,
+		},
+		{
+			MethodName: "Restore",
+			Handler:    _AccountService_Restore_Handler,
+		},
+		{
+			MethodName: "AddOwner",
+			Handler:    _AccountService_AddOwner_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "account.proto",
+}
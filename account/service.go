@@ -2,6 +2,14 @@
 package account
 
 import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/customer"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/outbox"
 	"github.com/shopspring/decimal"
 )
 
@@ -17,52 +25,114 @@ const (
 // ID type used for accounts identification.
 type ID string
 
-// Account is a wallet in the system.
+// Account is a wallet in the system, held by a customer.
 type Account struct {
 	TableName struct{}        `json:"-" sql:"select:accounts_view,alias:accounts"`
 	ID        ID              `json:"id" sql:"id,pk,type:varchar(255)"`
+	Customer  uuid.UUID       `json:"customer" sql:"customer_id,type:varchar(36)"`
 	Country   Country         `json:"country" sql:"country,notnull,type:varchar(50)"`
 	City      City            `json:"city" sql:"city,notnull,type:varchar(50)"`
 	Balance   decimal.Decimal `json:"balance" sql:"balance,notnull,type:'decimal(16,4)'"`
 	Currency  Currency        `json:"currency" sql:"currency,notnull,type:varchar(3)"`
 	Deleted   bool            `json:"-" sql:"deleted,notnull"`
+	Frozen    bool            `json:"frozen" sql:"frozen,notnull"`
+	// CreatedAt is when the account was opened, zero for accounts opened before it was recorded.
+	CreatedAt time.Time `json:"-" sql:"created_at"`
+}
+
+// Owner links a principal to an account it owns.
+type Owner struct {
+	TableName struct{} `json:"-" sql:"account_owners"`
+	AccountID ID       `json:"account" sql:"account_id,pk,type:varchar(255)"`
+	Principal string   `json:"principal" sql:"principal,pk,type:varchar(255)"`
 }
 
 // Service is the interface that provides account methods.
+// Every method acts on behalf of the principal authenticated in the context.
 type Service interface {
-	// New registers a new account in the system, with desired Balance.
-	New(id ID, country Country, city City, currency Currency, balance decimal.Decimal) error
+	// New registers a new account of a verified customer in the system, with desired Balance.
+	// The account is owned by the principal of the customer; country defaults to customer residence.
+	// Only admins and operators may open an account with a balance, customers open empty ones.
+	New(ctx context.Context, id ID, customerID uuid.UUID, country Country, city City, currency Currency, balance decimal.Decimal) error
 
 	// Load returns a read model of an account.
-	Load(id ID) (*Account, error)
+	Load(ctx context.Context, id ID) (*Account, error)
+
+	// LoadAll returns all accounts registered in the system, which the caller may read.
+	LoadAll(ctx context.Context) []*Account
 
-	// LoadAll returns all accounts registered in the system.
-	LoadAll() []*Account
+	// LoadByCustomer returns accounts held by the customer.
+	LoadByCustomer(ctx context.Context, customerID uuid.UUID) ([]*Account, error)
 
 	// Delete uses to delete account from the system. Actually mark it as deleted.
-	Delete(id ID) error
+	Delete(ctx context.Context, id ID) error
+
+	// Restore brings a deleted account back.
+	Restore(ctx context.Context, id ID) error
+
+	// Freeze stops money from moving in or out of an account until it is unfrozen.
+	Freeze(ctx context.Context, id ID) error
+
+	// Unfreeze lets money move in and out of a frozen account again.
+	Unfreeze(ctx context.Context, id ID) error
+
+	// AddOwner makes the principal an owner of the account.
+	AddOwner(ctx context.Context, id ID, principal string) error
+
+	// Balance returns the balance of an account at a moment, now when at is zero.
+	Balance(ctx context.Context, id ID, at time.Time) (Balance, error)
+
+	// Balances returns the balance of an account at the end of every day of a period, days UTC.
+	Balances(ctx context.Context, id ID, from, to time.Time) (BalanceHistory, error)
 }
 
 type service struct {
-	accounts Repository
+	accounts  Repository
+	customers customer.Repository
 }
 
-// New registers a new account in the system, with zero Balance.
-func (s *service) New(id ID, country Country, city City, currency Currency, balance decimal.Decimal) error {
+// New registers a new account of a verified customer in the system, with desired Balance.
+func (s *service) New(ctx context.Context, id ID, customerID uuid.UUID, country Country, city City, currency Currency, balance decimal.Decimal) error {
+	p, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator, auth.RoleCustomer)
+	if err != nil {
+		return err
+	}
+	c, err := s.customers.Find(customerID)
+	if err != nil {
+		return err
+	}
+	if !p.HasRole(auth.RoleAdmin, auth.RoleOperator) && (c.Principal != p.ID || !balance.IsZero()) {
+		return errs.ErrForbidden
+	}
+	if !c.Verified() {
+		return errs.ErrCustomerNotVerified
+	}
+	var owners []string
+	if c.Principal != "" {
+		owners = append(owners, c.Principal)
+	}
+	if country == "" {
+		country = Country(c.Country)
+	}
 	if currency == "" {
 		currency = CurrencyUSD
 	}
 	return s.accounts.Store(&Account{
-		ID:       id,
-		Country:  country,
-		City:     city,
-		Balance:  balance,
-		Currency: currency,
-	})
+		ID:        id,
+		Customer:  customerID,
+		Country:   country,
+		City:      city,
+		Balance:   balance,
+		Currency:  currency,
+		CreatedAt: time.Now().UTC(),
+	}, owners...)
 }
 
 // Load returns a read model of an account.
-func (s *service) Load(id ID) (*Account, error) {
+func (s *service) Load(ctx context.Context, id ID) (*Account, error) {
+	if err := Authorize(ctx, s.accounts, id, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor); err != nil {
+		return nil, err
+	}
 	a, err := s.accounts.Find(id)
 	if err != nil {
 		return nil, err
@@ -70,27 +140,108 @@ func (s *service) Load(id ID) (*Account, error) {
 	return a, nil
 }
 
-// LoadAll returns all accounts registered in the system.
-func (s *service) LoadAll() []*Account {
-	return s.accounts.FindAll()
+// LoadAll returns all accounts registered in the system, which the caller may read.
+func (s *service) LoadAll(ctx context.Context) []*Account {
+	p, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor, auth.RoleCustomer)
+	if err != nil {
+		return nil
+	}
+	if p.HasRole(auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor) {
+		return s.accounts.FindAll()
+	}
+	return s.accounts.FindOwned(p.ID)
+}
+
+// LoadByCustomer returns accounts held by the customer.
+func (s *service) LoadByCustomer(ctx context.Context, customerID uuid.UUID) ([]*Account, error) {
+	p, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor, auth.RoleCustomer)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.customers.Find(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if !p.HasRole(auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor) && c.Principal != p.ID {
+		return nil, errs.ErrForbidden
+	}
+	return s.accounts.FindByCustomer(customerID), nil
 }
 
 // Delete uses to delete account from the system. Actually mark it as deleted.
-func (s *service) Delete(id ID) error {
-	return s.accounts.MarkDeleted(id)
+func (s *service) Delete(ctx context.Context, id ID) error {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+	event, err := outbox.NewEvent(outbox.AccountClosed, struct {
+		ID ID `json:"id"`
+	}{ID: id})
+	if err != nil {
+		return err
+	}
+	return s.accounts.MarkDeleted(id, event)
+}
+
+// Restore brings a deleted account back.
+func (s *service) Restore(ctx context.Context, id ID) error {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+	return s.accounts.MarkRestored(id)
+}
+
+// Freeze stops money from moving in or out of an account until it is unfrozen.
+func (s *service) Freeze(ctx context.Context, id ID) error {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+	return s.accounts.MarkFrozen(id, true)
+}
+
+// Unfreeze lets money move in and out of a frozen account again.
+func (s *service) Unfreeze(ctx context.Context, id ID) error {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+	return s.accounts.MarkFrozen(id, false)
+}
+
+// AddOwner makes the principal an owner of the account.
+func (s *service) AddOwner(ctx context.Context, id ID, principal string) error {
+	if _, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator); err != nil {
+		return err
+	}
+	if _, err := s.accounts.Find(id); err != nil {
+		return err
+	}
+	return s.accounts.StoreOwner(id, principal)
 }
 
 // NewService creates an account service with necessary dependencies.
-func NewService(accounts Repository) Service {
+func NewService(accounts Repository, customers customer.Repository) Service {
 	return &service{
-		accounts: accounts,
+		accounts:  accounts,
+		customers: customers,
+	}
+}
+
+// Authorize checks that the principal of the call either has any of the privileged roles,
+// or is a customer owning the account.
+func Authorize(ctx context.Context, accounts Repository, id ID, privileged ...auth.Role) error {
+	p, err := auth.Require(ctx, append(privileged, auth.RoleCustomer)...)
+	if err != nil {
+		return err
+	}
+	if p.HasRole(privileged...) || accounts.IsOwner(id, p.ID) {
+		return nil
 	}
+	return errs.ErrForbidden
 }
 
 // Repository interface for accounts storing and operations.
 type Repository interface {
-	// Store account in the repository
-	Store(account *Account) error
+	// Store account in the repository, together with its owners
+	Store(account *Account, owners ...string) error
 
 	// Find account in the repository with specified id
 	Find(id ID) (*Account, error)
@@ -98,6 +249,36 @@ type Repository interface {
 	// FindAll returns all accounts registered in the system
 	FindAll() []*Account
 
-	// MarkDeleted is mark as deleted specified account in the system
-	MarkDeleted(id ID) error
+	// FindByCustomer returns accounts held by the customer
+	FindByCustomer(customerID uuid.UUID) []*Account
+
+	// FindOwned returns accounts owned by the principal
+	FindOwned(principal string) []*Account
+
+	// MarkDeleted is mark as deleted specified account in the system,
+	// storing events in the same transaction
+	MarkDeleted(id ID, events ...*outbox.Event) error
+
+	// MarkRestored clears deleted mark of specified account
+	MarkRestored(id ID) error
+
+	// MarkFrozen sets or clears frozen mark of specified account
+	MarkFrozen(id ID, frozen bool) error
+
+	// StoreOwner links the principal to the account as its owner
+	StoreOwner(id ID, principal string) error
+
+	// IsOwner reports whether the principal owns the account
+	IsOwner(id ID, principal string) bool
+
+	// FindBalance returns the balance of the account at a moment
+	FindBalance(id ID, at time.Time) (decimal.Decimal, error)
+
+	// FindDailyBalances returns balances of the account at the end of every day from one
+	// midnight UTC to another, both days included
+	FindDailyBalances(id ID, from, to time.Time) ([]decimal.Decimal, error)
+
+	// StoreSnapshots takes balance snapshots of accounts as of a midnight UTC, of those with
+	// payments since their last snapshot or with none yet, and returns how many it took
+	StoreSnapshots(at time.Time) (int, error)
 }
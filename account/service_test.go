@@ -0,0 +1,90 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/customer"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+// accounts is a Repository keeping accounts and owners in memory.
+type accounts struct {
+	Repository
+	stored map[ID]*Account
+	owners map[ID][]string
+}
+
+func (r *accounts) Store(a *Account, owners ...string) error {
+	r.stored[a.ID] = a
+	r.owners[a.ID] = owners
+	return nil
+}
+
+// customers is a customer.Repository keeping customers in memory.
+type customers struct {
+	customer.Repository
+	found map[uuid.UUID]*customer.Customer
+}
+
+func (r customers) Find(id uuid.UUID) (*customer.Customer, error) {
+	c, ok := r.found[id]
+	if !ok {
+		return nil, errs.ErrUnknownCustomer
+	}
+	return c, nil
+}
+
+func as(id string, roles ...auth.Role) context.Context {
+	return auth.NewContext(context.Background(), auth.Principal{ID: id, Roles: roles})
+}
+
+func TestNew(t *testing.T) {
+	verified := &customer.Customer{ID: uuid.New(), Principal: "alice", Country: "US", KYCStatus: customer.KYCVerified}
+	pending := &customer.Customer{ID: uuid.New(), Principal: "bob", KYCStatus: customer.KYCPending}
+	custs := customers{found: map[uuid.UUID]*customer.Customer{verified.ID: verified, pending.ID: pending}}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		customer uuid.UUID
+		balance  string
+		want     error
+	}{
+		{"customer opening own empty account", as("alice", auth.RoleCustomer), verified.ID, "0", nil},
+		{"customer opening own account with balance", as("alice", auth.RoleCustomer), verified.ID, "1000000", errs.ErrForbidden},
+		{"customer opening account of another", as("bob", auth.RoleCustomer), verified.ID, "0", errs.ErrForbidden},
+		{"operator opening account with balance", as("op", auth.RoleOperator), verified.ID, "100", nil},
+		{"admin opening account with balance", as("root", auth.RoleAdmin), verified.ID, "100", nil},
+		{"auditor", as("aud", auth.RoleAuditor), verified.ID, "0", errs.ErrForbidden},
+		{"unverified customer", as("op", auth.RoleOperator), pending.ID, "0", errs.ErrCustomerNotVerified},
+		{"unknown customer", as("op", auth.RoleOperator), uuid.New(), "0", errs.ErrUnknownCustomer},
+		{"anonymous", context.Background(), verified.ID, "0", errs.ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		repo := &accounts{stored: map[ID]*Account{}, owners: map[ID][]string{}}
+		s := NewService(repo, custs)
+		err := s.New(tt.ctx, "acc", tt.customer, "", "Denver", "", decimal.RequireFromString(tt.balance))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		a, stored := repo.stored["acc"]
+		if stored != (tt.want == nil) {
+			t.Errorf("%s: stored %v", tt.name, stored)
+		}
+		if !stored {
+			continue
+		}
+		if !a.Balance.Equal(decimal.RequireFromString(tt.balance)) || a.Country != "US" || a.Currency != CurrencyUSD {
+			t.Errorf("%s: stored %+v", tt.name, a)
+		}
+		if owners := repo.owners["acc"]; len(owners) != 1 || owners[0] != "alice" {
+			t.Errorf("%s: owners %v", tt.name, owners)
+		}
+	}
+}
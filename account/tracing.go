@@ -0,0 +1,87 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/tracing"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type tracingService struct {
+	Service
+}
+
+// NewTracingService returns an instance of a tracing Service. Every call runs in a span
+// named after the method.
+func NewTracingService(s Service) Service {
+	return &tracingService{s}
+}
+
+func (s *tracingService) New(ctx context.Context, id ID, customerID uuid.UUID, country Country, city City, currency Currency, balance decimal.Decimal) (err error) {
+	ctx, span := tracing.Start(ctx, "account.New", attribute.String("account.id", string(id)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.New(ctx, id, customerID, country, city, currency, balance)
+}
+
+func (s *tracingService) Load(ctx context.Context, id ID) (a *Account, err error) {
+	ctx, span := tracing.Start(ctx, "account.Load", attribute.String("account.id", string(id)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Load(ctx, id)
+}
+
+func (s *tracingService) LoadAll(ctx context.Context) []*Account {
+	ctx, span := tracing.Start(ctx, "account.LoadAll")
+	defer span.End()
+	return s.Service.LoadAll(ctx)
+}
+
+func (s *tracingService) LoadByCustomer(ctx context.Context, customerID uuid.UUID) (accounts []*Account, err error) {
+	ctx, span := tracing.Start(ctx, "account.LoadByCustomer", attribute.String("customer.id", customerID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.LoadByCustomer(ctx, customerID)
+}
+
+func (s *tracingService) Delete(ctx context.Context, id ID) (err error) {
+	ctx, span := tracing.Start(ctx, "account.Delete", attribute.String("account.id", string(id)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Delete(ctx, id)
+}
+
+func (s *tracingService) Restore(ctx context.Context, id ID) (err error) {
+	ctx, span := tracing.Start(ctx, "account.Restore", attribute.String("account.id", string(id)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Restore(ctx, id)
+}
+
+func (s *tracingService) Freeze(ctx context.Context, id ID) (err error) {
+	ctx, span := tracing.Start(ctx, "account.Freeze", attribute.String("account.id", string(id)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Freeze(ctx, id)
+}
+
+func (s *tracingService) Unfreeze(ctx context.Context, id ID) (err error) {
+	ctx, span := tracing.Start(ctx, "account.Unfreeze", attribute.String("account.id", string(id)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Unfreeze(ctx, id)
+}
+
+func (s *tracingService) AddOwner(ctx context.Context, id ID, principal string) (err error) {
+	ctx, span := tracing.Start(ctx, "account.AddOwner", attribute.String("account.id", string(id)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.AddOwner(ctx, id, principal)
+}
+
+func (s *tracingService) Balance(ctx context.Context, id ID, at time.Time) (b Balance, err error) {
+	ctx, span := tracing.Start(ctx, "account.Balance", attribute.String("account.id", string(id)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Balance(ctx, id, at)
+}
+
+func (s *tracingService) Balances(ctx context.Context, id ID, from, to time.Time) (h BalanceHistory, err error) {
+	ctx, span := tracing.Start(ctx, "account.Balances", attribute.String("account.id", string(id)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Balances(ctx, id, from, to)
+}
@@ -2,11 +2,15 @@ package account
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
 	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/openapi"
+	"github.com/ilyareist/task1/tracing"
+	"github.com/ilyareist/task1/validate"
 	"github.com/shopspring/decimal"
 
 	"github.com/asaskevich/govalidator"
@@ -30,60 +34,137 @@ func init() {
 	})
 }
 
-// MakeHandler returns a handler for the account service.
-func MakeHandler(as Service, logger kitlog.Logger) http.Handler {
+// MakeHandler returns a handler for the account service endpoints.
+func MakeHandler(eps Endpoints, logger kitlog.Logger) http.Handler {
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
 		kithttp.ServerErrorEncoder(errs.EncodeError),
+		kithttp.ServerBefore(tracing.HTTPToContext, auth.HTTPToContext),
+		kithttp.ServerFinalizer(tracing.HTTPFinalizer),
 	}
 
 	newAccountHandler := kithttp.NewServer(
-		makeNewAccountEndpoint(as),
+		eps.NewAccountEndpoint,
 		decodeNewAccountRequest,
 		errs.EncodeResponse,
 		opts...,
 	)
 
 	loadAccountHandler := kithttp.NewServer(
-		makeLoadAccountEndpoint(as),
+		eps.LoadAccountEndpoint,
 		decodeLoadAccountRequest,
 		errs.EncodeResponse,
 		opts...,
 	)
 
 	loadAllAccountsHandler := kithttp.NewServer(
-		makeLoadAllAccountsEndpoint(as),
+		eps.LoadAllAccountsEndpoint,
 		decodeLoadAllAccountsRequest,
 		errs.EncodeResponse,
 		opts...,
 	)
 
+	loadByCustomerHandler := kithttp.NewServer(
+		eps.LoadByCustomerEndpoint,
+		decodeCustomerIDRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
 	deleteAccountHandler := kithttp.NewServer(
-		makeDeleteAccountEndpoint(as),
+		eps.DeleteAccountEndpoint,
 		decodeDeleteAccountRequest,
 		errs.EncodeResponse,
 		opts...,
 	)
 
+	restoreAccountHandler := kithttp.NewServer(
+		eps.RestoreAccountEndpoint,
+		decodeRestoreAccountRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	freezeAccountHandler := kithttp.NewServer(
+		eps.FreezeAccountEndpoint,
+		decodeFreezeAccountRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	unfreezeAccountHandler := kithttp.NewServer(
+		eps.UnfreezeAccountEndpoint,
+		decodeFreezeAccountRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	addOwnerHandler := kithttp.NewServer(
+		eps.AddOwnerEndpoint,
+		decodeAddOwnerRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	balanceHandler := kithttp.NewServer(
+		eps.BalanceEndpoint,
+		decodeBalanceRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	balancesHandler := kithttp.NewServer(
+		eps.BalancesEndpoint,
+		decodeBalancesRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
 	router := mux.NewRouter()
 
 	router.Handle("/api/accounts/v1/accounts", newAccountHandler).Methods("POST")
 	router.Handle("/api/accounts/v1/accounts", loadAllAccountsHandler).Methods("GET")
 	router.Handle("/api/accounts/v1/accounts/{id}", loadAccountHandler).Methods("GET")
 	router.Handle("/api/accounts/v1/accounts/{id}", deleteAccountHandler).Methods("DELETE")
+	router.Handle("/api/accounts/v1/accounts/{id}/restore", restoreAccountHandler).Methods("POST")
+	router.Handle("/api/accounts/v1/accounts/{id}/freeze", freezeAccountHandler).Methods("POST")
+	router.Handle("/api/accounts/v1/accounts/{id}/unfreeze", unfreezeAccountHandler).Methods("POST")
+	router.Handle("/api/accounts/v1/accounts/{id}/owners", addOwnerHandler).Methods("POST")
+	router.Handle("/api/accounts/v1/accounts/{id}/balance", balanceHandler).Methods("GET")
+	router.Handle("/api/accounts/v1/accounts/{id}/balances", balancesHandler).Methods("GET")
+	router.Handle("/api/accounts/v1/customers/{id}/accounts", loadByCustomerHandler).Methods("GET")
 
 	return router
 }
 
+// Operations describe the routes of MakeHandler for the OpenAPI specification.
+func Operations() []openapi.Operation {
+	const tag = "accounts"
+	at := openapi.Query("at", "Moment, RFC 3339, or YYYY-MM-DD for the end of the day; now by default")
+	days := []openapi.Parameter{
+		openapi.Query("from", "First day, YYYY-MM-DD; 30 days before the last by default"),
+		openapi.Query("to", "Last day, YYYY-MM-DD; today by default"),
+	}
+	return []openapi.Operation{
+		{Method: "POST", Path: "/api/accounts/v1/accounts", Tag: tag, Summary: "Create an account", Request: newAccountRequest{}},
+		{Method: "GET", Path: "/api/accounts/v1/accounts", Tag: tag, Summary: "List accounts", Response: []*Account{}},
+		{Method: "GET", Path: "/api/accounts/v1/accounts/{id}", Tag: tag, Summary: "Load an account", Response: loadAccountResponse{}},
+		{Method: "DELETE", Path: "/api/accounts/v1/accounts/{id}", Tag: tag, Summary: "Delete an account"},
+		{Method: "POST", Path: "/api/accounts/v1/accounts/{id}/restore", Tag: tag, Summary: "Restore a deleted account"},
+		{Method: "POST", Path: "/api/accounts/v1/accounts/{id}/freeze", Tag: tag, Summary: "Freeze an account"},
+		{Method: "POST", Path: "/api/accounts/v1/accounts/{id}/unfreeze", Tag: tag, Summary: "Unfreeze an account"},
+		{Method: "POST", Path: "/api/accounts/v1/accounts/{id}/owners", Tag: tag, Summary: "Add an owner", Request: addOwnerRequest{}},
+		{Method: "GET", Path: "/api/accounts/v1/accounts/{id}/balance", Tag: tag, Summary: "Get the balance of an account at a moment", Query: []openapi.Parameter{at}, Response: balanceResponse{}},
+		{Method: "GET", Path: "/api/accounts/v1/accounts/{id}/balances", Tag: tag, Summary: "Get end of day balances of an account", Query: days, Response: balancesResponse{}},
+		{Method: "GET", Path: "/api/accounts/v1/customers/{id}/accounts", Tag: tag, Summary: "List accounts of a customer", Response: []*Account{}},
+	}
+}
+
 func decodeNewAccountRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	var body newAccountRequest
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+	if err := validate.DecodeJSON(r, &body); err != nil {
 		return nil, err
 	}
-	if _, err := govalidator.ValidateStruct(body); err != nil {
-		return nil, errs.ValidationError{Err: err}
-	}
-	fmt.Println(body)
 	return body, nil
 }
 
@@ -100,6 +181,19 @@ func decodeLoadAllAccountsRequest(_ context.Context, _ *http.Request) (interface
 	return nil, nil
 }
 
+func decodeCustomerIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errs.ErrInvalidArgument
+	}
+	return customerIDRequest{Customer: uid}, nil
+}
+
 func decodeDeleteAccountRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
@@ -108,3 +202,83 @@ func decodeDeleteAccountRequest(_ context.Context, r *http.Request) (interface{}
 	}
 	return idField{ID: ID(id)}, nil
 }
+
+func decodeRestoreAccountRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	return idField{ID: ID(id)}, nil
+}
+
+// decodeFreezeAccountRequest decodes both freeze and unfreeze requests.
+func decodeFreezeAccountRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	return idField{ID: ID(id)}, nil
+}
+
+func decodeAddOwnerRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	var body addOwnerRequest
+	if err := validate.DecodeJSON(r, &body); err != nil {
+		return nil, err
+	}
+	body.ID = ID(id)
+	return body, nil
+}
+
+func decodeBalanceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	req := balanceRequest{ID: ID(id)}
+	at := r.URL.Query().Get("at")
+	if at == "" {
+		return req, nil
+	}
+	if t, err := time.Parse(time.RFC3339, at); err == nil {
+		req.At = t.UTC()
+		return req, nil
+	}
+	// A day stands for its end, so that it is told the balance the day closed with.
+	day, err := time.Parse(dateLayout, at)
+	if err != nil {
+		return nil, errs.ErrInvalidArgument
+	}
+	req.At = day.AddDate(0, 0, 1)
+	return req, nil
+}
+
+func decodeBalancesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	req := balancesRequest{ID: ID(id)}
+	q := r.URL.Query()
+	for _, p := range []struct {
+		name string
+		day  *time.Time
+	}{{"from", &req.From}, {"to", &req.To}} {
+		if v := q.Get(p.name); v != "" {
+			day, err := time.Parse(dateLayout, v)
+			if err != nil {
+				return nil, errs.ErrInvalidArgument
+			}
+			*p.day = day
+		}
+	}
+	return req, nil
+}
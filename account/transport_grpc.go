@@ -0,0 +1,221 @@
+package account
+
+//go:generate protoc --go_out=plugins=grpc:. pb/account.proto
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account/pb"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/validate"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+)
+
+type grpcServer struct {
+	newAccount kitgrpc.Handler
+	load       kitgrpc.Handler
+	loadAll    kitgrpc.Handler
+	byCustomer kitgrpc.Handler
+	delete     kitgrpc.Handler
+	restore    kitgrpc.Handler
+	addOwner   kitgrpc.Handler
+}
+
+// NewGRPCServer makes the account service endpoints available as a gRPC AccountServiceServer.
+func NewGRPCServer(eps Endpoints, logger kitlog.Logger) pb.AccountServiceServer {
+	opts := []kitgrpc.ServerOption{
+		kitgrpc.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kitgrpc.ServerBefore(auth.GRPCToContext),
+	}
+
+	return &grpcServer{
+		newAccount: kitgrpc.NewServer(
+			eps.NewAccountEndpoint,
+			decodeGRPCNewAccountRequest,
+			encodeGRPCErrorOnlyResponse,
+			opts...,
+		),
+		load: kitgrpc.NewServer(
+			eps.LoadAccountEndpoint,
+			decodeGRPCAccountIDRequest,
+			encodeGRPCLoadAccountResponse,
+			opts...,
+		),
+		loadAll: kitgrpc.NewServer(
+			eps.LoadAllAccountsEndpoint,
+			decodeGRPCEmptyRequest,
+			encodeGRPCLoadAllAccountsResponse,
+			opts...,
+		),
+		byCustomer: kitgrpc.NewServer(
+			eps.LoadByCustomerEndpoint,
+			decodeGRPCCustomerIDRequest,
+			encodeGRPCLoadAllAccountsResponse,
+			opts...,
+		),
+		delete: kitgrpc.NewServer(
+			eps.DeleteAccountEndpoint,
+			decodeGRPCAccountIDRequest,
+			encodeGRPCErrorOnlyResponse,
+			opts...,
+		),
+		restore: kitgrpc.NewServer(
+			eps.RestoreAccountEndpoint,
+			decodeGRPCAccountIDRequest,
+			encodeGRPCErrorOnlyResponse,
+			opts...,
+		),
+		addOwner: kitgrpc.NewServer(
+			eps.AddOwnerEndpoint,
+			decodeGRPCAddOwnerRequest,
+			encodeGRPCErrorOnlyResponse,
+			opts...,
+		),
+	}
+}
+
+func (s *grpcServer) New(ctx context.Context, req *pb.NewAccountRequest) (*pb.Empty, error) {
+	_, rep, err := s.newAccount.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.Empty), nil
+}
+
+func (s *grpcServer) Load(ctx context.Context, req *pb.AccountIDRequest) (*pb.Account, error) {
+	_, rep, err := s.load.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.Account), nil
+}
+
+func (s *grpcServer) LoadAll(ctx context.Context, req *pb.Empty) (*pb.AccountsReply, error) {
+	_, rep, err := s.loadAll.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.AccountsReply), nil
+}
+
+func (s *grpcServer) LoadByCustomer(ctx context.Context, req *pb.CustomerIDRequest) (*pb.AccountsReply, error) {
+	_, rep, err := s.byCustomer.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.AccountsReply), nil
+}
+
+func (s *grpcServer) Delete(ctx context.Context, req *pb.AccountIDRequest) (*pb.Empty, error) {
+	_, rep, err := s.delete.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.Empty), nil
+}
+
+func (s *grpcServer) Restore(ctx context.Context, req *pb.AccountIDRequest) (*pb.Empty, error) {
+	_, rep, err := s.restore.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.Empty), nil
+}
+
+func (s *grpcServer) AddOwner(ctx context.Context, req *pb.AddOwnerRequest) (*pb.Empty, error) {
+	_, rep, err := s.addOwner.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.Empty), nil
+}
+
+func decodeGRPCNewAccountRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.NewAccountRequest)
+	var fields validate.Fields
+	body := newAccountRequest{
+		ID:       ID(req.Id),
+		Customer: fields.UUID(req.Customer, "customer"),
+		Country:  Country(req.Country),
+		City:     City(req.City),
+		Currency: Currency(req.Currency),
+		Balance:  fields.Decimal(req.Balance, "balance"),
+	}
+	if err := fields.Struct(body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func decodeGRPCAccountIDRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AccountIDRequest)
+	if req.Id == "" {
+		return nil, errs.ErrInvalidArgument
+	}
+	return idField{ID: ID(req.Id)}, nil
+}
+
+func decodeGRPCCustomerIDRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CustomerIDRequest)
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, errs.ErrInvalidArgument
+	}
+	return customerIDRequest{Customer: id}, nil
+}
+
+func decodeGRPCAddOwnerRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AddOwnerRequest)
+	if req.Id == "" {
+		return nil, errs.ErrInvalidArgument
+	}
+	body := addOwnerRequest{ID: ID(req.Id), Principal: req.Principal}
+	if err := validate.Struct(body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func decodeGRPCEmptyRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func encodeGRPCErrorOnlyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	if err := response.(errs.ErrorOnlyResponse).Err; err != nil {
+		return nil, err
+	}
+	return &pb.Empty{}, nil
+}
+
+func encodeGRPCLoadAccountResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(loadAccountResponse)
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+	return toPBAccount(resp.Account), nil
+}
+
+func encodeGRPCLoadAllAccountsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	accounts := response.([]*Account)
+	reply := &pb.AccountsReply{Accounts: make([]*pb.Account, 0, len(accounts))}
+	for _, a := range accounts {
+		reply.Accounts = append(reply.Accounts, toPBAccount(a))
+	}
+	return reply, nil
+}
+
+func toPBAccount(a *Account) *pb.Account {
+	return &pb.Account{
+		Id:       string(a.ID),
+		Customer: a.Customer.String(),
+		Country:  string(a.Country),
+		City:     string(a.City),
+		Balance:  a.Balance.String(),
+		Currency: string(a.Currency),
+	}
+}
@@ -0,0 +1,123 @@
+package account
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account/pb"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// registry is a Service of the accounts found, recording accounts opened.
+type registry struct {
+	Service
+	found  map[ID]*Account
+	opened []*Account
+}
+
+func (s *registry) New(ctx context.Context, id ID, customerID uuid.UUID, country Country, city City, currency Currency, balance decimal.Decimal) error {
+	s.opened = append(s.opened, &Account{ID: id, Customer: customerID, Country: country, City: city, Currency: currency, Balance: balance})
+	return nil
+}
+
+func (s *registry) Load(ctx context.Context, id ID) (*Account, error) {
+	a, ok := s.found[id]
+	if !ok {
+		return nil, errs.ErrUnknownAccount
+	}
+	return a, nil
+}
+
+// dialGRPC serves s over an in-memory gRPC connection and returns a client of it.
+func dialGRPC(t *testing.T, s Service) (pb.AccountServiceClient, func()) {
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterAccountServiceServer(srv, NewGRPCServer(MakeEndpoints(s), log.NewNopLogger()))
+	go func() { _ = srv.Serve(ln) }()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return ln.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pb.NewAccountServiceClient(conn), func() {
+		conn.Close()
+		srv.Stop()
+	}
+}
+
+// reason returns the gRPC code of err and the errs code it carries.
+func reason(err error) (codes.Code, string) {
+	st := status.Convert(err)
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return st.Code(), info.Reason
+		}
+	}
+	return st.Code(), ""
+}
+
+func TestGRPCRoundTrip(t *testing.T) {
+	customerID := uuid.New()
+	s := &registry{found: map[ID]*Account{
+		"John": {ID: "John", Customer: customerID, Country: "US", City: "Austin", Currency: CurrencyUSD, Balance: decimal.RequireFromString("10.5")},
+	}}
+	client, done := dialGRPC(t, s)
+	defer done()
+	ctx := context.Background()
+
+	got, err := client.Load(ctx, &pb.AccountIDRequest{Id: "John"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &pb.Account{Id: "John", Customer: customerID.String(), Country: "US", City: "Austin", Balance: "10.5", Currency: "USD"}
+	if got.Id != want.Id || got.Customer != want.Customer || got.Country != want.Country || got.City != want.City ||
+		got.Balance != want.Balance || got.Currency != want.Currency {
+		t.Errorf("loaded %v, want %v", got, want)
+	}
+
+	_, err = client.New(ctx, &pb.NewAccountRequest{Id: "Jane", Customer: customerID.String(), Country: "US", City: "Austin", Currency: "USD", Balance: "100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.opened) != 1 || s.opened[0].Customer != customerID || !s.opened[0].Balance.Equal(decimal.New(100, 0)) {
+		t.Errorf("opened %+v", s.opened)
+	}
+}
+
+func TestGRPCErrors(t *testing.T) {
+	client, done := dialGRPC(t, &registry{})
+	defer done()
+	ctx := context.Background()
+
+	_, err := client.Load(ctx, &pb.AccountIDRequest{Id: "John"})
+	if code, r := reason(err); code != codes.NotFound || r != string(errs.CodeUnknownAccount) {
+		t.Errorf("unknown account: got %v, %q", code, r)
+	}
+
+	_, err = client.New(ctx, &pb.NewAccountRequest{Id: "Jane", Customer: uuid.New().String(), Currency: "USD", Balance: "ten"})
+	if code, r := reason(err); code != codes.InvalidArgument || r != string(errs.CodeValidation) {
+		t.Errorf("invalid balance: got %v, %q", code, r)
+	}
+	var fields []string
+	for _, d := range status.Convert(err).Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+		}
+	}
+	if len(fields) == 0 || fields[0] != "balance" {
+		t.Errorf("invalid balance: field violations %v", fields)
+	}
+}
@@ -0,0 +1,161 @@
+// Package activity provides real-time feed of account activity: new payments and balance changes.
+package activity
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/ilyareist/task1/account"
+)
+
+// Type of an activity event.
+type Type string
+
+const (
+	PaymentType Type = "payment"
+	BalanceType Type = "balance"
+)
+
+// Event is a single change of an account, as streamed to subscribers.
+// The broker publishing an event numbers it after the ones it published before, so that
+// subscribers resume its stream after reconnect. The ID is zero until then.
+type Event struct {
+	ID      int64           `json:"id"`
+	Account account.ID      `json:"account"`
+	Type    Type            `json:"type"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Publisher is the interface used by services to announce committed changes.
+type Publisher interface {
+	// Publish delivers the event to subscribers of its account.
+	Publish(e Event)
+}
+
+// Buffer is a Publisher holding events back, so that changes made in a transaction are
+// announced only once it commits.
+type Buffer struct {
+	events []Event
+}
+
+// Publish holds the event until Flush.
+func (b *Buffer) Publish(e Event) {
+	b.events = append(b.events, e)
+}
+
+// Flush publishes the events held to p in the order they came, and forgets them.
+func (b *Buffer) Flush(p Publisher) {
+	for _, e := range b.events {
+		p.Publish(e)
+	}
+	b.events = nil
+}
+
+// NewEvent creates an event for the account with data serialized to JSON.
+func NewEvent(accountID account.ID, typ Type, data interface{}) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Account: accountID, Type: typ, Data: raw}, nil
+}
+
+// Broker fans events out to local subscribers and keeps recent history for resuming.
+type Broker struct {
+	mu          sync.Mutex
+	seq         int64
+	history     []Event
+	next        int
+	size        int
+	subscribers map[account.ID]map[chan Event]struct{}
+}
+
+// NewBroker creates a broker keeping up to historySize latest events.
+func NewBroker(historySize int) *Broker {
+	return &Broker{
+		history:     make([]Event, 0, historySize),
+		size:        historySize,
+		subscribers: make(map[account.ID]map[chan Event]struct{}),
+	}
+}
+
+// Publish numbers the event and delivers it to subscribers of its account. Subscribers which
+// can not keep up are disconnected; they are expected to resume using the last event ID seen.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	e.ID = b.seq
+
+	if b.size > 0 {
+		if len(b.history) < b.size {
+			b.history = append(b.history, e)
+		} else {
+			b.history[b.next] = e
+			b.next = (b.next + 1) % b.size
+		}
+	}
+
+	for ch := range b.subscribers[e.Account] {
+		select {
+		case ch <- e:
+		default:
+			b.unsubscribe(e.Account, ch)
+		}
+	}
+}
+
+// Subscribe starts receiving events of the account. Events kept in history with ID greater
+// than lastID are returned for replay. Returned channel is closed after cancel is called or
+// when the subscriber falls behind or the broker is closed.
+func (b *Broker) Subscribe(accountID account.ID, lastID int64) (<-chan Event, []Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []Event
+	if lastID > 0 {
+		for i := 0; i < len(b.history); i++ {
+			e := b.history[(b.next+i)%len(b.history)]
+			if e.Account == accountID && e.ID > lastID {
+				missed = append(missed, e)
+			}
+		}
+	}
+
+	ch := make(chan Event, 64)
+	if b.subscribers[accountID] == nil {
+		b.subscribers[accountID] = make(map[chan Event]struct{})
+	}
+	b.subscribers[accountID][ch] = struct{}{}
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.unsubscribe(accountID, ch)
+	}
+	return ch, missed, cancel
+}
+
+// Close disconnects all subscribers, ending their streams.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for accountID, subs := range b.subscribers {
+		for ch := range subs {
+			b.unsubscribe(accountID, ch)
+		}
+	}
+}
+
+func (b *Broker) unsubscribe(accountID account.ID, ch chan Event) {
+	subs := b.subscribers[accountID]
+	if _, ok := subs[ch]; !ok {
+		return
+	}
+	delete(subs, ch)
+	close(ch)
+	if len(subs) == 0 {
+		delete(b.subscribers, accountID)
+	}
+}
@@ -0,0 +1,137 @@
+package activity
+
+import (
+	"testing"
+
+	"github.com/ilyareist/task1/account"
+)
+
+// publish publishes an event of each account given, in order.
+func publish(b *Broker, accounts ...account.ID) {
+	for _, id := range accounts {
+		e, _ := NewEvent(id, PaymentType, map[string]string{"account": string(id)})
+		b.Publish(e)
+	}
+}
+
+func ids(events []Event) []int64 {
+	var got []int64
+	for _, e := range events {
+		got = append(got, e.ID)
+	}
+	return got
+}
+
+func equal(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBrokerNumbersEvents(t *testing.T) {
+	b := NewBroker(0)
+	events, _, cancel := b.Subscribe("alice", 0)
+	defer cancel()
+
+	publish(b, "alice", "bob", "alice")
+	got := []Event{<-events, <-events}
+	if !equal(ids(got), []int64{1, 3}) || got[0].Account != "alice" || got[0].Type != PaymentType {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestBrokerReplays(t *testing.T) {
+	// The history of three events wraps: event 1 of alice is forgotten.
+	b := NewBroker(3)
+	publish(b, "alice", "alice", "bob", "alice")
+
+	tests := []struct {
+		name   string
+		lastID int64
+		want   []int64
+	}{
+		{"new subscriber", 0, nil},
+		{"forgotten events", 1, []int64{2, 4}},
+		{"missed events", 2, []int64{4}},
+		{"up to date", 4, nil},
+		{"ahead", 10, nil},
+	}
+	for _, tt := range tests {
+		_, missed, cancel := b.Subscribe("alice", tt.lastID)
+		cancel()
+		if !equal(ids(missed), tt.want) {
+			t.Errorf("%s: replayed %v, want %v", tt.name, ids(missed), tt.want)
+		}
+	}
+
+	// Brokers without history replay nothing.
+	b = NewBroker(0)
+	publish(b, "alice")
+	if _, missed, cancel := b.Subscribe("alice", 0); len(missed) != 0 {
+		t.Errorf("replayed %v without history", ids(missed))
+	} else {
+		cancel()
+	}
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	b := NewBroker(0)
+	slow, _, cancelSlow := b.Subscribe("alice", 0)
+	other, _, cancelOther := b.Subscribe("bob", 0)
+	defer cancelOther()
+
+	// The slow subscriber reads nothing until its buffer is overrun.
+	for i := 0; i <= cap(slow); i++ {
+		publish(b, "alice")
+	}
+	n := 0
+	for range slow {
+		n++
+	}
+	if n != cap(slow) {
+		t.Errorf("received %d events before being dropped, want %d", n, cap(slow))
+	}
+	cancelSlow()
+
+	publish(b, "bob")
+	if e, ok := <-other; !ok || e.Account != "bob" {
+		t.Errorf("other subscriber got %+v, %v", e, ok)
+	}
+}
+
+func TestBrokerClose(t *testing.T) {
+	b := NewBroker(0)
+	events, _, cancel := b.Subscribe("alice", 0)
+	b.Close()
+	if _, ok := <-events; ok {
+		t.Error("stream open after close")
+	}
+	cancel()
+	publish(b, "alice")
+}
+
+func TestBuffer(t *testing.T) {
+	b := NewBroker(0)
+	events, _, cancel := b.Subscribe("alice", 0)
+	defer cancel()
+
+	var buf Buffer
+	e, _ := NewEvent("alice", BalanceType, nil)
+	buf.Publish(e)
+	select {
+	case e := <-events:
+		t.Fatalf("published %+v before flush", e)
+	default:
+	}
+	buf.Flush(b)
+	buf.Flush(b)
+	if e := <-events; e.Type != BalanceType || len(events) != 0 {
+		t.Errorf("flushed %+v and %d more", e, len(events))
+	}
+}
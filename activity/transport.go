@@ -0,0 +1,128 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/openapi"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+// keepAliveInterval is how often a comment line is sent to idle streams to keep proxies from closing them.
+const keepAliveInterval = 15 * time.Second
+
+// AuthorizeFunc checks that the caller may read activity of the account.
+type AuthorizeFunc func(ctx context.Context, id account.ID) error
+
+// AuthorizeLoad lets callers stream activity of the accounts s lets them load: customers
+// their own ones, staff every one.
+func AuthorizeLoad(s account.Service) AuthorizeFunc {
+	return func(ctx context.Context, id account.ID) error {
+		_, err := s.Load(ctx, id)
+		return err
+	}
+}
+
+// MakeHandler returns a Server-Sent Events handler streaming activity of an account.
+// Every stream is checked by authorize before it is opened.
+func MakeHandler(b *Broker, authorize AuthorizeFunc, logger kitlog.Logger) http.Handler {
+	router := mux.NewRouter()
+	h := &streamHandler{broker: b, authorize: authorize, logger: logger}
+	router.Handle("/api/payments/v1/accounts/{id}/events", h).Methods("GET")
+	return router
+}
+
+// Operations describe the routes of MakeHandler for the OpenAPI specification.
+func Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: "GET", Path: "/api/payments/v1/accounts/{id}/events", Tag: "activity", Summary: "Stream account activity",
+			Query:  []openapi.Parameter{openapi.Query("last_event_id", "Resume after this event, like the Last-Event-ID header")},
+			Stream: true,
+		},
+	}
+}
+
+type streamHandler struct {
+	broker    *Broker
+	authorize AuthorizeFunc
+	logger    kitlog.Logger
+}
+
+func (h *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		errs.EncodeError(r.Context(), errs.ErrBadRoute, w)
+		return
+	}
+	if err := h.authorize(r.Context(), account.ID(id)); err != nil {
+		errs.EncodeError(r.Context(), err, w)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errs.EncodeError(r.Context(), fmt.Errorf("streaming is not supported"), w)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var last int64
+	if lastID != "" {
+		var err error
+		if last, err = strconv.ParseInt(lastID, 10, 64); err != nil {
+			errs.EncodeError(r.Context(), errs.ErrInvalidArgument, w)
+			return
+		}
+	}
+
+	events, missed, cancel := h.broker.Subscribe(account.ID(id), last)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range missed {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				_ = h.logger.Log("msg", "activity subscription ended", "account", id)
+				return
+			}
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+	return err
+}
@@ -0,0 +1,142 @@
+package activity
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+)
+
+// accounts is an account.Repository of accounts owned by alice.
+type accounts struct {
+	account.Repository
+}
+
+func (accounts) Find(id account.ID) (*account.Account, error) { return &account.Account{ID: id}, nil }
+
+func (accounts) IsOwner(id account.ID, principal string) bool { return principal == "alice" }
+
+func as(id string, roles ...auth.Role) context.Context {
+	return auth.NewContext(context.Background(), auth.Principal{ID: id, Roles: roles})
+}
+
+func TestAuthorizeLoad(t *testing.T) {
+	authorize := AuthorizeLoad(account.NewService(accounts{}, nil))
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+	}{
+		{"owner", as("alice", auth.RoleCustomer), nil},
+		{"other customer", as("bob", auth.RoleCustomer), errs.ErrForbidden},
+		{"auditor", as("carol", auth.RoleAuditor), nil},
+		{"anonymous", context.Background(), errs.ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		if err := authorize(tt.ctx, "acc"); !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+// stream opens the activity stream of alice on the server, resuming after lastID when it is
+// not empty, and returns the response.
+func stream(t *testing.T, url, lastID string) *http.Response {
+	req, err := http.NewRequest("GET", url+"/api/payments/v1/accounts/alice/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// next reads the next event of the stream as its id and event lines.
+func next(t *testing.T, r *bufio.Reader) string {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "id: ") || strings.HasPrefix(line, "event: ") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func TestHandlerStreams(t *testing.T) {
+	b := NewBroker(10)
+	authorize := func(ctx context.Context, id account.ID) error { return nil }
+	srv := httptest.NewServer(MakeHandler(b, authorize, log.NewNopLogger()))
+	defer srv.Close()
+
+	publish(b, "alice", "bob", "alice")
+	resp := stream(t, srv.URL, "1")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("got status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	r := bufio.NewReader(resp.Body)
+	if got := next(t, r); got != "id: 3 event: payment" {
+		t.Errorf("replayed %q, want event 3", got)
+	}
+
+	// Events published after the stream opened follow the missed ones.
+	e, _ := NewEvent("alice", BalanceType, map[string]string{"balance": "10"})
+	b.Publish(e)
+	if got := next(t, r); got != "id: 4 event: balance" {
+		t.Errorf("streamed %q, want event 4", got)
+	}
+
+	// The stream ends when the broker is closed.
+	b.Close()
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Error("stream open after the broker closed")
+	}
+}
+
+func TestHandlerRejects(t *testing.T) {
+	b := NewBroker(10)
+	authorize := func(ctx context.Context, id account.ID) error {
+		if id != "alice" {
+			return errs.ErrForbidden
+		}
+		return nil
+	}
+	srv := httptest.NewServer(MakeHandler(b, authorize, log.NewNopLogger()))
+	defer srv.Close()
+
+	resp := stream(t, srv.URL, "yesterday")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad Last-Event-ID: got status %d", resp.StatusCode)
+	}
+
+	resp, err := http.Get(srv.URL + "/api/payments/v1/accounts/bob/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("account of another: got status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,186 @@
+// Package audit provides an append-only log of state-changing calls. Every entry carries
+// the hash of the previous one, so that altering or removing entries breaks the chain.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/requestid"
+
+	kitlog "github.com/go-kit/kit/log"
+)
+
+// Genesis is the previous hash of the first entry.
+const Genesis = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Entry records one state-changing call: who did what to which resource, with the state
+// of the resource before and after the call. Failed calls are recorded with the error.
+type Entry struct {
+	TableName struct{}        `json:"-" sql:"audit_log"`
+	Seq       int64           `json:"seq" sql:"seq,pk,type:bigint"`
+	Time      time.Time       `json:"time" sql:"time,notnull"`
+	Actor     string          `json:"actor" sql:"actor,type:varchar(255)"`
+	Action    string          `json:"action" sql:"action,notnull,type:varchar(64)"`
+	Resource  string          `json:"resource,omitempty" sql:"resource,type:varchar(255)"`
+	Before    json.RawMessage `json:"before,omitempty" sql:"before,type:text"`
+	After     json.RawMessage `json:"after,omitempty" sql:"after,type:text"`
+	Error     string          `json:"error,omitempty" sql:"error,type:text"`
+	RequestID string          `json:"request_id,omitempty" sql:"request_id,type:varchar(255)"`
+	PrevHash  string          `json:"prev_hash" sql:"prev_hash,notnull,type:varchar(64)"`
+	Hash      string          `json:"hash" sql:"hash,notnull,type:varchar(64)"`
+}
+
+// Seal chains the entry after prev, the last entry of the log, or after Genesis when prev is nil.
+func (e *Entry) Seal(prev *Entry) {
+	e.Seq, e.PrevHash = 1, Genesis
+	if prev != nil {
+		e.Seq, e.PrevHash = prev.Seq+1, prev.Hash
+	}
+	e.Hash = e.Digest()
+}
+
+// Digest returns the hex SHA-256 of the entry content and the previous hash.
+// Fields are length-prefixed, so that no two different entries share the input.
+func (e *Entry) Digest() string {
+	h := sha256.New()
+	for _, f := range []string{
+		strconv.FormatInt(e.Seq, 10),
+		e.Time.UTC().Format(time.RFC3339Nano),
+		e.Actor,
+		e.Action,
+		e.Resource,
+		string(e.Before),
+		string(e.After),
+		e.Error,
+		e.RequestID,
+		e.PrevHash,
+	} {
+		_, _ = io.WriteString(h, strconv.Itoa(len(f))+":"+f)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Filter selects entries. Zero fields match everything.
+type Filter struct {
+	Actor    string
+	Action   string
+	Resource string
+	Since    time.Time
+	Until    time.Time
+	// After selects entries following the given sequence number, for paging.
+	After int64
+	Limit int
+}
+
+// Repository interface for the log. Entries are never updated nor deleted.
+type Repository interface {
+	// Append seals the entry after the last one and stores it, serialized with other appends.
+	Append(e *Entry) error
+
+	// Find returns entries matching the filter in sequence order.
+	Find(f Filter) ([]*Entry, error)
+}
+
+// Recorder records state-changing calls to the log.
+type Recorder interface {
+	// Record appends an entry for the action of the principal in the context on the resource.
+	// Before and after are states of the resource, nil when there is none; err is the outcome.
+	Record(ctx context.Context, action, resource string, before, after interface{}, err error)
+}
+
+type recorder struct {
+	entries Repository
+	logger  kitlog.Logger
+}
+
+// NewRecorder returns a Recorder appending to the repository. It records calls outside of their
+// transaction, which have taken effect or failed already, so failures to record are logged rather than returned.
+func NewRecorder(entries Repository, logger kitlog.Logger) Recorder {
+	return &recorder{entries: entries, logger: logger}
+}
+
+// Record appends an entry for the action of the principal in the context on the resource.
+func (r *recorder) Record(ctx context.Context, action, resource string, before, after interface{}, err error) {
+	if err := r.entries.Append(NewEntry(ctx, action, resource, before, after, err)); err != nil {
+		_ = r.logger.Log("msg", "audit", "action", action, "resource", resource, "error", err)
+	}
+}
+
+// NewEntry returns an unsealed entry for the action of the principal in the context on the resource.
+// Before and after are states of the resource, nil when there is none; err is the outcome.
+func NewEntry(ctx context.Context, action, resource string, before, after interface{}, err error) *Entry {
+	p, _ := auth.FromContext(ctx)
+	e := &Entry{
+		// PostgreSQL keeps microseconds, the hash must survive the round trip.
+		Time:      time.Now().UTC().Truncate(time.Microsecond),
+		Actor:     p.ID,
+		Action:    action,
+		Resource:  resource,
+		RequestID: requestid.FromContext(ctx),
+		Before:    marshal(before),
+		After:     marshal(after),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+// marshal returns JSON of a state, nil for no state.
+func marshal(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil
+	}
+	return b
+}
+
+// ChainError tells the first entry which does not match its hash or does not follow the previous one.
+type ChainError struct {
+	Seq    int64
+	Reason string
+}
+
+func (e ChainError) Error() string {
+	return fmt.Sprintf("audit entry %d: %s", e.Seq, e.Reason)
+}
+
+// verifyPage is the number of entries read at once by Verify.
+const verifyPage = 1000
+
+// Verify walks the whole log and checks every hash and link of the chain.
+// It returns the number of verified entries, and a ChainError when the log was tampered with.
+func Verify(entries Repository) (int64, error) {
+	prev := &Entry{Seq: 0, Hash: Genesis}
+	for {
+		page, err := entries.Find(Filter{After: prev.Seq, Limit: verifyPage})
+		if err != nil {
+			return prev.Seq, err
+		}
+		for _, e := range page {
+			switch {
+			case e.Seq != prev.Seq+1:
+				return prev.Seq, ChainError{Seq: prev.Seq + 1, Reason: "entry is missing"}
+			case e.PrevHash != prev.Hash:
+				return prev.Seq, ChainError{Seq: e.Seq, Reason: "previous hash does not match"}
+			case e.Digest() != e.Hash:
+				return prev.Seq, ChainError{Seq: e.Seq, Reason: "hash does not match content"}
+			}
+			prev = e
+		}
+		if len(page) < verifyPage {
+			return prev.Seq, nil
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/requestid"
+)
+
+// log is a Repository keeping entries in memory.
+type log struct {
+	entries []*Entry
+}
+
+func (l *log) Append(e *Entry) error {
+	var last *Entry
+	if len(l.entries) > 0 {
+		last = l.entries[len(l.entries)-1]
+	}
+	e.Seal(last)
+	l.entries = append(l.entries, e)
+	return nil
+}
+
+func (l *log) Find(f Filter) ([]*Entry, error) {
+	var found []*Entry
+	for _, e := range l.entries {
+		if e.Seq > f.After && (f.Limit == 0 || len(found) < f.Limit) {
+			c := *e
+			found = append(found, &c)
+		}
+	}
+	return found, nil
+}
+
+func newLog(t *testing.T, n int) *log {
+	l := &log{}
+	ctx := auth.NewContext(requestid.NewContext(context.Background(), "req-1"), auth.Principal{ID: "alice"})
+	for i := 0; i < n; i++ {
+		if err := l.Append(NewEntry(ctx, "account.freeze", "acc", map[string]bool{"frozen": false}, map[string]bool{"frozen": true}, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return l
+}
+
+func TestNewEntry(t *testing.T) {
+	ctx := auth.NewContext(requestid.NewContext(context.Background(), "req-1"), auth.Principal{ID: "alice"})
+	var none *struct{}
+	e := NewEntry(ctx, "account.delete", "acc", map[string]string{"id": "acc"}, none, errors.New("account is frozen"))
+	if e.Actor != "alice" || e.RequestID != "req-1" || e.Error != "account is frozen" {
+		t.Errorf("got %+v", e)
+	}
+	if string(e.Before) != `{"id":"acc"}` || e.After != nil {
+		t.Errorf("got before %s, after %s", e.Before, e.After)
+	}
+	if e.Time.Nanosecond()%1000 != 0 {
+		t.Errorf("time %v keeps nanoseconds", e.Time)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	n := int64(verifyPage + 5)
+	if got, err := Verify(newLog(t, int(n))); err != nil || got != n {
+		t.Fatalf("got %d, %v", got, err)
+	}
+	if got, err := Verify(&log{}); err != nil || got != 0 {
+		t.Errorf("empty log: got %d, %v", got, err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(l *log)
+		want   ChainError
+	}{
+		{"content changed", func(l *log) { l.entries[2].After = json.RawMessage(`{"frozen":false}`) }, ChainError{Seq: 3, Reason: "hash does not match content"}},
+		{"content changed and rehashed", func(l *log) { l.entries[2].Actor = "mallory"; l.entries[2].Hash = l.entries[2].Digest() }, ChainError{Seq: 4, Reason: "previous hash does not match"}},
+		{"entry removed", func(l *log) { l.entries = append(l.entries[:2], l.entries[3:]...) }, ChainError{Seq: 3, Reason: "entry is missing"}},
+		{"first entry removed", func(l *log) { l.entries = l.entries[1:] }, ChainError{Seq: 1, Reason: "entry is missing"}},
+	}
+	for _, tt := range tests {
+		l := newLog(t, 5)
+		tt.tamper(l)
+		_, err := Verify(l)
+		var chainErr ChainError
+		if !errors.As(err, &chainErr) || chainErr != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints collects all of the endpoints that compose an audit log service.
+type Endpoints struct {
+	LoadEntriesEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints wired to the provided service, each wrapped
+// by middlewares in the given order, the first one being the outermost.
+func MakeEndpoints(s Service, mws ...endpoint.Middleware) Endpoints {
+	wrap := func(e endpoint.Endpoint) endpoint.Endpoint {
+		for i := len(mws) - 1; i >= 0; i-- {
+			e = mws[i](e)
+		}
+		return e
+	}
+	return Endpoints{
+		LoadEntriesEndpoint: wrap(makeLoadEntriesEndpoint(s)),
+	}
+}
+
+func makeLoadEntriesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return s.Entries(ctx, request.(Filter))
+	}
+}
@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/ilyareist/task1/auth"
+)
+
+// maxEntries caps the number of entries returned at once.
+const maxEntries = 1000
+
+// Service is the interface that provides access to the audit log.
+type Service interface {
+	// Entries returns entries matching the filter in sequence order.
+	Entries(ctx context.Context, f Filter) ([]*Entry, error)
+}
+
+type service struct {
+	entries Repository
+}
+
+// Entries returns entries matching the filter in sequence order. The log is read by admins and auditors.
+func (s *service) Entries(ctx context.Context, f Filter) ([]*Entry, error) {
+	if _, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleAuditor); err != nil {
+		return nil, err
+	}
+	if f.Limit <= 0 || f.Limit > maxEntries {
+		f.Limit = maxEntries
+	}
+	return s.entries.Find(f)
+}
+
+// NewService creates an audit log service with necessary dependencies.
+func NewService(entries Repository) Service {
+	return &service{
+		entries: entries,
+	}
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/openapi"
+	"github.com/ilyareist/task1/tracing"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a handler for the audit log endpoints.
+func MakeHandler(eps Endpoints, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(errs.EncodeError),
+		kithttp.ServerBefore(tracing.HTTPToContext, auth.HTTPToContext),
+		kithttp.ServerFinalizer(tracing.HTTPFinalizer),
+	}
+
+	loadEntriesHandler := kithttp.NewServer(
+		eps.LoadEntriesEndpoint,
+		decodeLoadEntriesRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	router := mux.NewRouter()
+
+	router.Handle("/api/audit/v1/entries", loadEntriesHandler).Methods("GET")
+
+	return router
+}
+
+// Operations describe the routes of MakeHandler for the OpenAPI specification.
+func Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: "GET", Path: "/api/audit/v1/entries", Tag: "audit", Summary: "List audit log entries",
+			Query: []openapi.Parameter{
+				openapi.Query("actor", "Principal who made the call"),
+				openapi.Query("action", "Action, e.g. payment.create"),
+				openapi.Query("resource", "Resource the action touched"),
+				openapi.Query("since", "RFC 3339 time of the earliest entry"),
+				openapi.Query("until", "RFC 3339 time of the latest entry"),
+				openapi.Query("after", "Sequence number entries follow"),
+				openapi.Query("limit", "Maximum number of entries"),
+			},
+			Response: []*Entry{},
+		},
+	}
+}
+
+func decodeLoadEntriesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	f := Filter{
+		Actor:    q.Get("actor"),
+		Action:   q.Get("action"),
+		Resource: q.Get("resource"),
+	}
+	for name, t := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		if v := q.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, errs.ErrInvalidArgument
+			}
+			*t = parsed
+		}
+	}
+	if v := q.Get("after"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return nil, errs.ErrInvalidArgument
+		}
+		f.After = n
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, errs.ErrInvalidArgument
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
@@ -0,0 +1,154 @@
+// Package auth provides authentication of API callers by API keys, JWT bearer tokens and TLS
+// client certificates.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/ilyareist/task1/errs"
+)
+
+// Method is the way a principal was authenticated.
+type Method string
+
+const (
+	MethodAPIKey Method = "api_key"
+	MethodJWT    Method = "jwt"
+	// MethodCLI is the method of principals running admin commands of the binary.
+	MethodCLI Method = "cli"
+	// MethodCertificate is the method of services calling with TLS client certificates.
+	MethodCertificate Method = "certificate"
+)
+
+// Role grants a set of permissions to principals.
+type Role string
+
+const (
+	// RoleAdmin may do anything, including deleting and restoring accounts.
+	RoleAdmin Role = "admin"
+	// RoleOperator may read everything and move money between any accounts.
+	RoleOperator Role = "operator"
+	// RoleCustomer may read and debit only accounts they own.
+	RoleCustomer Role = "customer"
+	// RoleAuditor may read everything and change nothing.
+	RoleAuditor Role = "auditor"
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	ID     string `json:"id"`
+	Method Method `json:"method"`
+	Roles  []Role `json:"roles"`
+}
+
+// HasRole reports whether the principal has any of the roles.
+func (p Principal) HasRole(roles ...Role) bool {
+	for _, have := range p.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Require returns the principal of the call if it has any of the roles,
+// or just any principal when no roles are given. It fails with errs.ErrUnauthenticated when the call is anonymous
+// and with errs.ErrForbidden when roles do not match.
+func Require(ctx context.Context, roles ...Role) (Principal, error) {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return Principal{}, errs.ErrUnauthenticated
+	}
+	if len(roles) > 0 && !p.HasRole(roles...) {
+		return p, errs.ErrForbidden
+	}
+	return p, nil
+}
+
+type principalKey struct{}
+
+// NewContext returns a context carrying the principal.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal authenticated for the call, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// APIKey is a stored API key. Only a hash of the key secret is kept.
+// The key itself is presented by callers as "<id>.<secret>".
+type APIKey struct {
+	TableName struct{}  `json:"-" sql:"api_keys"`
+	ID        string    `json:"id" sql:"id,pk,type:varchar(32)"`
+	Hash      string    `json:"-" sql:"hash,notnull,type:varchar(64)"`
+	Principal string    `json:"principal" sql:"principal,notnull,type:varchar(255)"`
+	Roles     []Role    `json:"roles" sql:"roles,array"`
+	CreatedAt time.Time `json:"created_at" sql:"created_at,notnull"`
+	Revoked   bool      `json:"revoked" sql:"revoked,notnull"`
+}
+
+// NewAPIKey generates a key for the principal with given roles. The returned key string
+// is the only place its secret appears, it must be handed over to the caller right away.
+func NewAPIKey(principal string, roles ...Role) (string, *APIKey, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", nil, err
+	}
+	return id + "." + secret, &APIKey{
+		ID:        id,
+		Hash:      hashSecret(secret),
+		Principal: principal,
+		Roles:     roles,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Matches reports whether the secret belongs to the key.
+func (k *APIKey) Matches(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hashSecret(secret))) == 1
+}
+
+// KeyRepository interface for API keys storing.
+type KeyRepository interface {
+	// StoreKey stores a new API key.
+	StoreKey(key *APIKey) error
+
+	// FindKey returns an API key with specified id.
+	FindKey(id string) (*APIKey, error)
+}
+
+func splitAPIKey(key string) (id, secret string, ok bool) {
+	i := strings.IndexByte(key, '.')
+	if i <= 0 || i == len(key)-1 {
+		return "", "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
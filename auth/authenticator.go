@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/ilyareist/task1/errs"
+)
+
+// Authenticator checks credentials presented by callers.
+type Authenticator struct {
+	keys     KeyRepository
+	secret   []byte
+	rsaKeys  map[string]*rsa.PublicKey
+	issuer   string
+	audience string
+
+	certs       Certificates
+	acceptCerts bool
+}
+
+// Option configures an Authenticator.
+type Option func(*Authenticator)
+
+// WithHMACSecret enables HS256 signed tokens verified with the secret.
+func WithHMACSecret(secret []byte) Option {
+	return func(a *Authenticator) { a.secret = secret }
+}
+
+// WithRSAKeys enables RS256 signed tokens verified with the keys, indexed by key id.
+func WithRSAKeys(keys map[string]*rsa.PublicKey) Option {
+	return func(a *Authenticator) { a.rsaKeys = keys }
+}
+
+// WithIssuer requires tokens to be issued by iss.
+func WithIssuer(iss string) Option {
+	return func(a *Authenticator) { a.issuer = iss }
+}
+
+// WithAudience requires tokens to be intended for aud.
+func WithAudience(aud string) Option {
+	return func(a *Authenticator) { a.audience = aud }
+}
+
+// NewAuthenticator creates an authenticator accepting API keys from the repository
+// and JWT bearer tokens signed with keys given by options.
+func NewAuthenticator(keys KeyRepository, opts ...Option) *Authenticator {
+	a := &Authenticator{keys: keys}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// AuthenticateAPIKey returns the principal owning a valid, not revoked API key.
+func (a *Authenticator) AuthenticateAPIKey(key string) (Principal, error) {
+	id, secret, ok := splitAPIKey(key)
+	if !ok {
+		return Principal{}, errs.ErrUnauthenticated
+	}
+	k, err := a.keys.FindKey(id)
+	if err != nil || k.Revoked || !k.Matches(secret) {
+		return Principal{}, errs.ErrUnauthenticated
+	}
+	return Principal{ID: k.Principal, Method: MethodAPIKey, Roles: k.Roles}, nil
+}
+
+// claims of JWT bearer tokens: registered ones and roles of the subject.
+type claims struct {
+	jwt.StandardClaims
+	Roles []Role `json:"roles"`
+}
+
+// AuthenticateToken returns the principal named by subject of a valid JWT, with roles from "roles" claim.
+// Tokens must expire: those without "exp" claim are refused.
+func (a *Authenticator) AuthenticateToken(token string) (Principal, error) {
+	claims := &claims{}
+	_, err := jwt.ParseWithClaims(token, claims, a.keyFunc)
+	if err != nil || claims.Subject == "" || claims.ExpiresAt == 0 {
+		return Principal{}, errs.ErrUnauthenticated
+	}
+	if a.issuer != "" && !claims.VerifyIssuer(a.issuer, true) {
+		return Principal{}, errs.ErrUnauthenticated
+	}
+	if a.audience != "" && !claims.VerifyAudience(a.audience, true) {
+		return Principal{}, errs.ErrUnauthenticated
+	}
+	return Principal{ID: claims.Subject, Method: MethodJWT, Roles: claims.Roles}, nil
+}
+
+func (a *Authenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method {
+	case jwt.SigningMethodHS256:
+		if len(a.secret) == 0 {
+			return nil, fmt.Errorf("HS256 tokens are not accepted")
+		}
+		return a.secret, nil
+	case jwt.SigningMethodRS256:
+		kid, _ := token.Header["kid"].(string)
+		key, ok := a.rsaKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+}
+
+// LoadJWKS reads RSA public keys from a JSON Web Key Set file.
+func LoadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", k.Kid, err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/ilyareist/task1/errs"
+)
+
+var secret = []byte("test secret")
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, c jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, c)
+	token.Header["kid"] = "k1"
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	a := NewAuthenticator(nil, WithHMACSecret(secret), WithIssuer("bank"), WithAudience("api"))
+	valid := func() *claims {
+		return &claims{
+			StandardClaims: jwt.StandardClaims{
+				Subject:   "alice",
+				Issuer:    "bank",
+				Audience:  "api",
+				ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			},
+			Roles: []Role{RoleOperator},
+		}
+	}
+
+	p, err := a.AuthenticateToken(sign(t, jwt.SigningMethodHS256, secret, valid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "alice" || p.Method != MethodJWT || !p.HasRole(RoleOperator) {
+		t.Errorf("got principal %+v", p)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*claims)
+		key    []byte
+	}{
+		{"no exp", func(c *claims) { c.ExpiresAt = 0 }, secret},
+		{"expired", func(c *claims) { c.ExpiresAt = time.Now().Add(-time.Minute).Unix() }, secret},
+		{"not yet valid", func(c *claims) { c.NotBefore = time.Now().Add(time.Hour).Unix() }, secret},
+		{"no subject", func(c *claims) { c.Subject = "" }, secret},
+		{"wrong issuer", func(c *claims) { c.Issuer = "other" }, secret},
+		{"wrong audience", func(c *claims) { c.Audience = "other" }, secret},
+		{"wrong secret", func(c *claims) {}, []byte("other secret")},
+	}
+	for _, tt := range tests {
+		c := valid()
+		tt.modify(c)
+		if _, err := a.AuthenticateToken(sign(t, jwt.SigningMethodHS256, tt.key, c)); !errors.Is(err, errs.ErrUnauthenticated) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, errs.ErrUnauthenticated)
+		}
+	}
+}
+
+func TestAuthenticateTokenRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &claims{StandardClaims: jwt.StandardClaims{Subject: "bob", ExpiresAt: time.Now().Add(time.Hour).Unix()}}
+	token := sign(t, jwt.SigningMethodRS256, key, c)
+
+	a := NewAuthenticator(nil, WithRSAKeys(map[string]*rsa.PublicKey{"k1": &key.PublicKey}))
+	if p, err := a.AuthenticateToken(token); err != nil || p.ID != "bob" {
+		t.Errorf("got %+v, %v", p, err)
+	}
+	// HS256 is not accepted without a secret, even when signed with the public key.
+	if _, err := a.AuthenticateToken(sign(t, jwt.SigningMethodHS256, []byte{}, c)); err == nil {
+		t.Error("HS256 token accepted without a secret")
+	}
+	other := NewAuthenticator(nil, WithRSAKeys(map[string]*rsa.PublicKey{"k2": &key.PublicKey}))
+	if _, err := other.AuthenticateToken(token); err == nil {
+		t.Error("token with unknown key id accepted")
+	}
+}
+
+type keys map[string]*APIKey
+
+func (k keys) StoreKey(key *APIKey) error { k[key.ID] = key; return nil }
+
+func (k keys) FindKey(id string) (*APIKey, error) {
+	key, ok := k[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return key, nil
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	repo := keys{}
+	s, key, err := NewAPIKey("carol", RoleAuditor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = repo.StoreKey(key)
+	a := NewAuthenticator(repo)
+
+	p, err := a.AuthenticateAPIKey(s)
+	if err != nil || p.ID != "carol" || p.Method != MethodAPIKey || !p.HasRole(RoleAuditor) {
+		t.Errorf("got %+v, %v", p, err)
+	}
+	for _, bad := range []string{"", ".", key.ID, key.ID + ".", key.ID + ".wrong", "unknown." + s[len(key.ID)+1:]} {
+		if _, err := a.AuthenticateAPIKey(bad); !errors.Is(err, errs.ErrUnauthenticated) {
+			t.Errorf("key %q: got %v", bad, err)
+		}
+	}
+	key.Revoked = true
+	if _, err := a.AuthenticateAPIKey(s); !errors.Is(err, errs.ErrUnauthenticated) {
+		t.Errorf("revoked key: got %v", err)
+	}
+}
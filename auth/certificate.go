@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/x509"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ilyareist/task1/errs"
+)
+
+// Certificates maps subjects of client certificates to the principals they authenticate.
+// Subjects are distinguished names as x509 prints them, e.g. CN=billing,O=Acme, or common names.
+type Certificates map[string]Principal
+
+// Principal returns the principal of the certificate, looked up by its distinguished name,
+// then by its common name.
+func (c Certificates) Principal(cert *x509.Certificate) (Principal, bool) {
+	if p, ok := c[cert.Subject.String()]; ok {
+		return p, true
+	}
+	p, ok := c[cert.Subject.CommonName]
+	return p, ok && cert.Subject.CommonName != ""
+}
+
+// LoadCertificates reads the principals of client certificates from a CSV file of records of
+// subject, principal and space separated roles.
+func LoadCertificates(path string) (Certificates, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 3
+	r.TrimLeadingSpace = true
+	certs := make(Certificates)
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return certs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		subject, principal := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if subject == "" || principal == "" {
+			return nil, fmt.Errorf("line %d: subject and principal are required", line)
+		}
+		if _, ok := certs[subject]; ok {
+			return nil, fmt.Errorf("line %d: subject %q is listed twice", line, subject)
+		}
+		p := Principal{ID: principal, Method: MethodCertificate}
+		for _, role := range strings.Fields(record[2]) {
+			p.Roles = append(p.Roles, Role(role))
+		}
+		certs[subject] = p
+	}
+}
+
+// WithCertificates accepts client certificates verified by the TLS server, authenticating
+// those listed in certs as their principals. With no list, a certificate authenticates the
+// principal named by its common name, with no roles.
+func WithCertificates(certs Certificates) Option {
+	return func(a *Authenticator) {
+		a.certs = certs
+		a.acceptCerts = true
+	}
+}
+
+// AuthenticateCertificate returns the principal of a client certificate verified by the TLS server.
+func (a *Authenticator) AuthenticateCertificate(cert *x509.Certificate) (Principal, error) {
+	if !a.acceptCerts {
+		return Principal{}, errs.ErrUnauthenticated
+	}
+	if a.certs == nil {
+		if cert.Subject.CommonName == "" {
+			return Principal{}, errs.ErrUnauthenticated
+		}
+		return Principal{ID: cert.Subject.CommonName, Method: MethodCertificate}, nil
+	}
+	p, ok := a.certs.Principal(cert)
+	if !ok {
+		return Principal{}, errs.ErrUnauthenticated
+	}
+	return p, nil
+}
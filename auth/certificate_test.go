@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ca issues client certificates for tests.
+type ca struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newCA(t *testing.T) *ca {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ca{cert: cert, key: key}
+}
+
+// issue returns a client certificate with the subject.
+func (c *ca) issue(t *testing.T, subject pkix.Name) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, c.cert, &key.PublicKey, c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func loadCertificates(t *testing.T, csv string) (Certificates, error) {
+	dir, err := ioutil.TempDir("", "certificates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "principals.csv")
+	if err := ioutil.WriteFile(path, []byte(csv), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadCertificates(path)
+}
+
+const principals = `"CN=billing,O=Acme", billing-svc, operator auditor
+reports, reports-svc, auditor
+"CN=reports,O=Other", other-reports,
+`
+
+func TestLoadCertificates(t *testing.T) {
+	certs, err := loadCertificates(t, principals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := certs["CN=billing,O=Acme"]; p.ID != "billing-svc" || p.Method != MethodCertificate || !p.HasRole(RoleOperator) || !p.HasRole(RoleAuditor) {
+		t.Errorf("got %+v", p)
+	}
+	if p := certs["CN=reports,O=Other"]; p.ID != "other-reports" || len(p.Roles) != 0 {
+		t.Errorf("got %+v", p)
+	}
+
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"subject listed twice", "reports, a, auditor\nreports, b, auditor\n"},
+		{"no principal", "reports, , auditor\n"},
+		{"no subject", ", reports, auditor\n"},
+		{"no roles column", "reports, reports-svc\n"},
+	}
+	for _, tt := range tests {
+		if _, err := loadCertificates(t, tt.csv); err == nil {
+			t.Errorf("%s: loaded", tt.name)
+		}
+	}
+}
+
+func TestAuthenticateCertificate(t *testing.T) {
+	issuer := newCA(t)
+	billing := issuer.issue(t, pkix.Name{CommonName: "billing", Organization: []string{"Acme"}})
+	reports := issuer.issue(t, pkix.Name{CommonName: "reports", Organization: []string{"Acme"}})
+	otherReports := issuer.issue(t, pkix.Name{CommonName: "reports", Organization: []string{"Other"}})
+	mallory := issuer.issue(t, pkix.Name{CommonName: "mallory"})
+	nameless := issuer.issue(t, pkix.Name{Organization: []string{"Acme"}})
+	if s := billing.Leaf.Subject.String(); s != "CN=billing,O=Acme" {
+		t.Fatalf("subject %q is not the one listed", s)
+	}
+
+	certs, err := loadCertificates(t, principals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := NewAuthenticator(nil, WithCertificates(certs))
+	unlisted := NewAuthenticator(nil, WithCertificates(nil))
+	refusing := NewAuthenticator(nil)
+
+	tests := []struct {
+		name string
+		a    *Authenticator
+		cert *tls.Certificate
+		want string
+	}{
+		{"listed by name", listed, &billing, "billing-svc operator,auditor"},
+		{"listed by common name", listed, &reports, "reports-svc auditor"},
+		{"name before common name", listed, &otherReports, "other-reports "},
+		{"not listed", listed, &mallory, ""},
+		{"no certificate", listed, nil, ""},
+		{"common name without list", unlisted, &mallory, "mallory "},
+		{"no common name without list", unlisted, &nameless, ""},
+		{"certificates not accepted", refusing, &billing, ""},
+	}
+	for _, tt := range tests {
+		got, status := call(t, issuer, tt.a, tt.cert)
+		if tt.want == "" {
+			if status != http.StatusUnauthorized {
+				t.Errorf("%s: got status %d, %q", tt.name, status, got)
+			}
+			continue
+		}
+		if status != http.StatusOK || got != tt.want {
+			t.Errorf("%s: got status %d, %q, want %q", tt.name, status, got, tt.want)
+		}
+	}
+}
+
+// call calls a server verifying client certificates with the CA and authenticating them with a,
+// which answers with the principal, and returns the answer and its status.
+func call(t *testing.T, issuer *ca, a *Authenticator, cert *tls.Certificate) (string, int) {
+	s := httptest.NewUnstartedServer(Handler(a, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := FromContext(r.Context())
+		roles := make([]string, len(p.Roles))
+		for i, role := range p.Roles {
+			roles[i] = string(role)
+		}
+		fmt.Fprintf(w, "%s %s", p.ID, strings.Join(roles, ","))
+	})))
+	pool := x509.NewCertPool()
+	pool.AddCert(issuer.cert)
+	s.TLS = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: pool}
+	s.StartTLS()
+	defer s.Close()
+
+	client := s.Client()
+	if cert != nil {
+		client.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{*cert}
+	}
+	resp, err := client.Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body), resp.StatusCode
+}
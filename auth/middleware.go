@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"strings"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/ilyareist/task1/errs"
+	"google.golang.org/grpc/metadata"
+)
+
+type credentials struct {
+	apiKey string
+	token  string
+	cert   *x509.Certificate
+}
+
+type credentialsKey struct{}
+
+// NewMiddleware returns an endpoint middleware which authenticates credentials
+// put into context by the transport, and rejects calls without valid ones.
+func NewMiddleware(a *Authenticator) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			p, err := a.authenticate(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return next(NewContext(ctx, p), request)
+		}
+	}
+}
+
+func (a *Authenticator) authenticate(ctx context.Context) (Principal, error) {
+	c, _ := ctx.Value(credentialsKey{}).(credentials)
+	switch {
+	case c.apiKey != "":
+		return a.AuthenticateAPIKey(c.apiKey)
+	case c.token != "":
+		return a.AuthenticateToken(c.token)
+	case c.cert != nil:
+		return a.AuthenticateCertificate(c.cert)
+	}
+	return Principal{}, errs.ErrUnauthenticated
+}
+
+// HTTPToContext moves credentials from "X-API-Key" or "Authorization: Bearer" request
+// headers into context, for use as kithttp.ServerBefore, along with the client certificate
+// verified by the TLS server, which authenticates calls without other credentials.
+func HTTPToContext(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, credentials{
+		apiKey: r.Header.Get("X-API-Key"),
+		token:  bearer(r.Header.Get("Authorization")),
+		cert:   clientCertificate(r),
+	})
+}
+
+// GRPCToContext moves credentials from "x-api-key" or "authorization: Bearer" metadata
+// into context, for use as kitgrpc.ServerBefore.
+func GRPCToContext(ctx context.Context, md metadata.MD) context.Context {
+	first := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	return context.WithValue(ctx, credentialsKey{}, credentials{
+		apiKey: first("x-api-key"),
+		token:  bearer(first("authorization")),
+	})
+}
+
+// Handler authenticates requests to a plain HTTP handler, answering 401 to calls without
+// valid credentials. It is meant for handlers which are not built of endpoints.
+func Handler(a *Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := HTTPToContext(r.Context(), r)
+		p, err := a.authenticate(ctx)
+		if err != nil {
+			errs.EncodeError(ctx, err, w)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(ctx, p)))
+	})
+}
+
+// Client identifies the caller of r as the package-level Client does, once its credentials
+// are authenticated. It is empty when they are missing or invalid.
+func (a *Authenticator) Client(r *http.Request) string {
+	if _, err := a.authenticate(HTTPToContext(r.Context(), r)); err != nil {
+		return ""
+	}
+	return Client(r)
+}
+
+// maxClientLength bounds key ids Client returns as they are.
+const maxClientLength = 64
+
+// Client identifies the caller of r by the credentials it presents, without checking them:
+// by the id of its API key or the digest of its bearer token. It is empty without credentials.
+func Client(r *http.Request) string {
+	if id, _, ok := splitAPIKey(r.Header.Get("X-API-Key")); ok {
+		if len(id) > maxClientLength {
+			id = hashSecret(id)[:32]
+		}
+		return "key:" + id
+	}
+	if token := bearer(r.Header.Get("Authorization")); token != "" {
+		return "token:" + hashSecret(token)[:32]
+	}
+	if cert := clientCertificate(r); cert != nil {
+		return "cert:" + hashSecret(cert.Subject.String())[:32]
+	}
+	return ""
+}
+
+// clientCertificate returns the certificate the client presented, if the TLS server verified it.
+func clientCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+func bearer(header string) string {
+	const prefix = "bearer "
+	if len(header) > len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+		return strings.TrimSpace(header[len(prefix):])
+	}
+	return ""
+}
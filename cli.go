@@ -0,0 +1,525 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/activity"
+	"github.com/ilyareist/task1/audit"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/db"
+	"github.com/ilyareist/task1/payment"
+	"github.com/shopspring/decimal"
+)
+
+// command is an admin command run against the database, args are those after its name.
+type command func(w *wiring, args []string, logger log.Logger) int
+
+// commands are the admin commands by group and name. They act through the same services as
+// the server, on behalf of an admin principal, so that their changes are audited and announced.
+var commands = map[string]map[string]command{
+	"account": {
+		"create":   createAccount,
+		"list":     listAccounts,
+		"freeze":   freezeAccount,
+		"unfreeze": freezeAccount,
+	},
+	"payment": {
+		"list":      listPayments,
+		"reverse":   reversePayment,
+		"statement": paymentStatement,
+	},
+	"rates": {
+		"import": importRates,
+	},
+	"ledger": {
+		"verify": verifyLedger,
+	},
+	"audit": {
+		"verify": verifyAudit,
+	},
+	"keys": {
+		"create": createKey,
+	},
+	"export": {
+		"accounts": exportAccounts,
+		"payments": exportPayments,
+	},
+}
+
+// runCommand runs the command named by args, serving the API when there is none, and returns
+// the exit status of the command.
+func runCommand(args []string, logger log.Logger) int {
+	if len(args) == 0 || args[0] == "serve" {
+		return serve(logger)
+	}
+	if args[0] == "migrate" {
+		return migrate(logger)
+	}
+	group, ok := commands[args[0]]
+	if !ok {
+		_ = logger.Log("msg", "unknown command", "command", args[0])
+		return 2
+	}
+	if len(args) < 2 || group[args[1]] == nil {
+		var name string
+		if len(args) > 1 {
+			name = args[1]
+		}
+		_ = logger.Log("msg", "unknown "+args[0]+" command", "command", name)
+		return 2
+	}
+	logger = log.With(logger, "command", args[0]+" "+args[1])
+
+	conn := connectDB(logger)
+	defer closeDB(conn, logger)
+	// Listings read nothing rather than fail, the database is checked up front.
+	if err := db.Ping(context.Background(), conn); err != nil {
+		_ = logger.Log("msg", "connect to database", "address", cfg.DB.Address(), "error", err)
+		return 1
+	}
+	// Payments made here are announced to servers listening for activity, if they do.
+	broker := activity.NewBroker(0)
+	var publisher activity.Publisher = broker
+	if cfg.Activity.Notify {
+		publisher = db.NewActivityBridge(conn, broker, logger)
+	}
+	return group[args[1]](wire(conn, publisher, logger), args[1:], logger)
+}
+
+// migrate creates the schema and brings it up to date, and returns the exit status of the command.
+func migrate(logger log.Logger) int {
+	conn := connectDB(logger)
+	defer closeDB(conn, logger)
+	if err := db.CreateSchema(conn); err != nil {
+		_ = logger.Log("msg", "migrate schema", "error", err)
+		return 1
+	}
+	_ = logger.Log("msg", "schema is up to date")
+	return 0
+}
+
+// adminContext returns the context commands act in, on behalf of an admin principal named
+// after the user running them.
+func adminContext() context.Context {
+	id := "cli"
+	if u, err := user.Current(); err == nil {
+		id += ":" + u.Username
+	}
+	return auth.NewContext(context.Background(), auth.Principal{
+		ID:     id,
+		Method: auth.MethodCLI,
+		Roles:  []auth.Role{auth.RoleAdmin},
+	})
+}
+
+// parseFlags parses flags of the command named by args[0], which must leave want positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string, want int, logger log.Logger) bool {
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args[1:]); err != nil {
+		return false
+	}
+	if fs.NArg() != want {
+		_ = logger.Log("msg", "unexpected arguments", "want", want, "got", fs.NArg())
+		return false
+	}
+	return true
+}
+
+func createAccount(w *wiring, args []string, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	var (
+		id       = fs.String("id", "", "account id")
+		customer = fs.String("customer", "", "id of the verified customer holding the account")
+		country  = fs.String("country", "", "country, residence of the customer by default")
+		city     = fs.String("city", "", "city")
+		currency = fs.String("currency", string(account.CurrencyUSD), "currency")
+		balance  = fs.String("balance", "0", "opening balance")
+	)
+	if !parseFlags(fs, args, 0, logger) {
+		return 2
+	}
+	customerID, err := uuid.Parse(*customer)
+	if err != nil {
+		_ = logger.Log("msg", "invalid -customer", "error", err)
+		return 2
+	}
+	amount, err := decimal.NewFromString(*balance)
+	if err != nil {
+		_ = logger.Log("msg", "invalid -balance", "error", err)
+		return 2
+	}
+	err = w.accountService.New(adminContext(), account.ID(*id), customerID, account.Country(*country),
+		account.City(*city), account.Currency(*currency), amount)
+	if err != nil {
+		_ = logger.Log("account_id", *id, "error", err)
+		return 1
+	}
+	_ = logger.Log("msg", "account created", "account_id", *id)
+	return 0
+}
+
+func listAccounts(w *wiring, args []string, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	if !parseFlags(fs, args, 0, logger) {
+		return 2
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCUSTOMER\tCOUNTRY\tCITY\tCURRENCY\tBALANCE\tFROZEN")
+	for _, a := range w.accountService.LoadAll(adminContext()) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%t\n", a.ID, a.Customer, a.Country, a.City, a.Currency, a.Balance.StringFixed(4), a.Frozen)
+	}
+	return flush(tw, logger)
+}
+
+// freezeAccount freezes or, run as unfreeze, unfreezes the account.
+func freezeAccount(w *wiring, args []string, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	if !parseFlags(fs, args, 1, logger) {
+		return 2
+	}
+	id := account.ID(fs.Arg(0))
+	change := w.accountService.Freeze
+	if args[0] == "unfreeze" {
+		change = w.accountService.Unfreeze
+	}
+	if err := change(adminContext(), id); err != nil {
+		_ = logger.Log("account_id", id, "error", err)
+		return 1
+	}
+	_ = logger.Log("msg", "account "+args[0]+"d", "account_id", id)
+	return 0
+}
+
+func listPayments(w *wiring, args []string, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	accountID := fs.String("account", "", "list payments of the account only")
+	if !parseFlags(fs, args, 0, logger) {
+		return 2
+	}
+	ctx := adminContext()
+	var payments []*payment.Payment
+	if *accountID != "" {
+		var err error
+		if payments, err = w.paymentService.Load(ctx, account.ID(*accountID)); err != nil {
+			_ = logger.Log("account_id", *accountID, "error", err)
+			return 1
+		}
+	} else {
+		payments = w.paymentService.LoadAll(ctx)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "GROUP\tACCOUNT\tDIRECTION\tAMOUNT\tFROM\tTO\tREVERSAL OF\tCREATED AT")
+	for _, p := range payments {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", p.Group, p.Account, p.Direction, p.Amount.StringFixed(4),
+			p.FromAccount, p.ToAccount, reversalOf(p), p.CreatedAt.Format(time.RFC3339))
+	}
+	return flush(tw, logger)
+}
+
+func paymentStatement(w *wiring, args []string, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	var (
+		from   = fs.String("from", "", "start of the period, RFC 3339 or YYYY-MM-DD, the account opening by default")
+		to     = fs.String("to", "", "end of the period, exclusive, RFC 3339 or YYYY-MM-DD for the whole day, now by default")
+		format = fs.String("format", "json", "output format, json, csv or pdf")
+		output = fs.String("o", "", "output file, standard output by default")
+	)
+	if !parseFlags(fs, args, 1, logger) {
+		return 2
+	}
+	start, err := payment.ParseBound(*from, false)
+	if err != nil {
+		_ = logger.Log("msg", "invalid start of the period", "from", *from)
+		return 2
+	}
+	end, err := payment.ParseBound(*to, true)
+	if err != nil {
+		_ = logger.Log("msg", "invalid end of the period", "to", *to)
+		return 2
+	}
+	write := map[string]func(*payment.Statement, io.Writer) error{
+		"json": func(st *payment.Statement, out io.Writer) error {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(st)
+		},
+		"csv": (*payment.Statement).WriteCSV,
+		"pdf": (*payment.Statement).WritePDF,
+	}[*format]
+	if write == nil {
+		_ = logger.Log("msg", "unknown format", "format", *format)
+		return 2
+	}
+	id := account.ID(fs.Arg(0))
+	st, err := w.paymentService.Statement(adminContext(), id, start, end)
+	if err != nil {
+		_ = logger.Log("account_id", id, "error", err)
+		return 1
+	}
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			_ = logger.Log("error", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := write(st, out); err != nil {
+		_ = logger.Log("msg", "write statement", "error", err)
+		return 1
+	}
+	return 0
+}
+
+func reversePayment(w *wiring, args []string, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	if !parseFlags(fs, args, 1, logger) {
+		return 2
+	}
+	id, err := uuid.Parse(fs.Arg(0))
+	if err != nil {
+		_ = logger.Log("msg", "invalid payment id", "error", err)
+		return 2
+	}
+	rcpt, err := w.paymentService.Reverse(adminContext(), id)
+	if err != nil {
+		_ = logger.Log("payment_id", id, "error", err)
+		return 1
+	}
+	_ = logger.Log("msg", "payment reversed", "payment_id", id, "reversal_id", rcpt.ID)
+	return writeJSON(os.Stdout, rcpt, logger)
+}
+
+func importRates(w *wiring, args []string, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	if !parseFlags(fs, args, 1, logger) {
+		return 2
+	}
+	var in io.Reader = os.Stdin
+	if name := fs.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			_ = logger.Log("error", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+	rates, err := payment.ReadRates(in)
+	if err != nil {
+		_ = logger.Log("msg", "read rates", "error", err)
+		return 1
+	}
+	ctx := adminContext()
+	err = w.transact(func(r *db.Repositories) error {
+		if err := r.Rates.StoreRates(rates...); err != nil {
+			return err
+		}
+		return r.Entries.Append(audit.NewEntry(ctx, "rates.import", "", nil, rates, nil))
+	})
+	if err != nil {
+		_ = logger.Log("msg", "store rates", "error", err)
+		return 1
+	}
+	_ = logger.Log("msg", "rates imported", "rates", len(rates))
+	return 0
+}
+
+// roles is a flag naming a role each time it is given.
+type roles []auth.Role
+
+func (r *roles) String() string {
+	return fmt.Sprint([]auth.Role(*r))
+}
+
+func (r *roles) Set(s string) error {
+	switch role := auth.Role(s); role {
+	case auth.RoleAdmin, auth.RoleOperator, auth.RoleCustomer, auth.RoleAuditor:
+		*r = append(*r, role)
+		return nil
+	}
+	return fmt.Errorf("unknown role %q", s)
+}
+
+// createKey issues an API key and prints it. Only the hash of its secret is stored, so this is
+// the only time the key is seen.
+func createKey(w *wiring, args []string, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	principal := fs.String("principal", "", "principal the key authenticates")
+	var granted roles
+	fs.Var(&granted, "role", "role granted to the principal, once per role")
+	if !parseFlags(fs, args, 0, logger) {
+		return 2
+	}
+	if *principal == "" {
+		_ = logger.Log("msg", "no -principal")
+		return 2
+	}
+	secret, key, err := auth.NewAPIKey(*principal, granted...)
+	if err != nil {
+		_ = logger.Log("msg", "generate key", "error", err)
+		return 1
+	}
+	ctx := adminContext()
+	err = w.transact(func(r *db.Repositories) error {
+		if err := r.Keys.StoreKey(key); err != nil {
+			return err
+		}
+		return r.Entries.Append(audit.NewEntry(ctx, "key.create", key.ID, nil, key, nil))
+	})
+	if err != nil {
+		_ = logger.Log("msg", "store key", "error", err)
+		return 1
+	}
+	_ = logger.Log("msg", "key created", "key_id", key.ID, "principal", key.Principal)
+	fmt.Println(secret)
+	return 0
+}
+
+// verifyLedger checks payments and balances, printing every discrepancy found, and returns
+// the exit status of the command.
+func verifyLedger(w *wiring, args []string, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	if !parseFlags(fs, args, 0, logger) {
+		return 2
+	}
+	payments := w.payments.FindAll()
+	found := payment.VerifyLedger(payments, w.accounts.FindAll())
+	for _, d := range found {
+		fmt.Println(d)
+	}
+	if len(found) > 0 {
+		_ = logger.Log("msg", "ledger verification failed", "payments", len(payments), "discrepancies", len(found))
+		return 1
+	}
+	_ = logger.Log("msg", "ledger verified", "payments", len(payments))
+	return 0
+}
+
+// verifyAudit checks the hash chain of the audit log and returns the exit status of the command.
+func verifyAudit(w *wiring, args []string, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	if !parseFlags(fs, args, 0, logger) {
+		return 2
+	}
+	n, err := audit.Verify(w.entries)
+	if err != nil {
+		_ = logger.Log("msg", "audit log verification failed", "verified", n, "error", err)
+		return 1
+	}
+	_ = logger.Log("msg", "audit log verified", "entries", n)
+	return 0
+}
+
+var (
+	accountColumns = []string{"id", "customer", "country", "city", "currency", "balance", "frozen"}
+	paymentColumns = []string{"id", "group", "account", "direction", "amount", "from_account", "to_account", "reversal_of", "created_at"}
+)
+
+func exportAccounts(w *wiring, args []string, logger log.Logger) int {
+	accounts := w.accountService.LoadAll(adminContext())
+	return export(args, accounts, accountColumns, func(i int) []string {
+		a := accounts[i]
+		return []string{string(a.ID), a.Customer.String(), string(a.Country), string(a.City), string(a.Currency),
+			a.Balance.StringFixed(4), strconv.FormatBool(a.Frozen)}
+	}, len(accounts), logger)
+}
+
+func exportPayments(w *wiring, args []string, logger log.Logger) int {
+	payments := w.paymentService.LoadAll(adminContext())
+	// Payment ids are not part of their JSON form, yet they identify rows of exports.
+	type exported struct {
+		ID uuid.UUID `json:"id"`
+		*payment.Payment
+	}
+	rows := make([]exported, len(payments))
+	for i, p := range payments {
+		rows[i] = exported{ID: p.ID, Payment: p}
+	}
+	return export(args, rows, paymentColumns, func(i int) []string {
+		p := payments[i]
+		return []string{p.ID.String(), p.Group.String(), string(p.Account), string(p.Direction), p.Amount.StringFixed(4),
+			string(p.FromAccount), string(p.ToAccount), reversalOf(p), p.CreatedAt.Format(time.RFC3339Nano)}
+	}, len(payments), logger)
+}
+
+// export writes rows as a JSON array or, with -format csv, as CSV records of the columns,
+// to standard output or the file given by -o.
+func export(args []string, rows interface{}, columns []string, record func(i int) []string, n int, logger log.Logger) int {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	format := fs.String("format", "json", "output format, json or csv")
+	output := fs.String("o", "", "output file, standard output by default")
+	if !parseFlags(fs, args, 0, logger) {
+		return 2
+	}
+	if *format != "json" && *format != "csv" {
+		_ = logger.Log("msg", "unknown format", "format", *format)
+		return 2
+	}
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			_ = logger.Log("error", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+	if *format == "json" {
+		return writeJSON(out, rows, logger)
+	}
+	cw := csv.NewWriter(out)
+	_ = cw.Write(columns)
+	for i := 0; i < n; i++ {
+		_ = cw.Write(record(i))
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		_ = logger.Log("msg", "write export", "error", err)
+		return 1
+	}
+	_ = logger.Log("msg", "exported", "rows", n)
+	return 0
+}
+
+func writeJSON(out io.Writer, v interface{}, logger log.Logger) int {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		_ = logger.Log("msg", "write output", "error", err)
+		return 1
+	}
+	return 0
+}
+
+func flush(tw *tabwriter.Writer, logger log.Logger) int {
+	if err := tw.Flush(); err != nil {
+		_ = logger.Log("msg", "write output", "error", err)
+		return 1
+	}
+	return 0
+}
+
+// reversalOf returns the group the payment reverses, empty when it is no reversal.
+func reversalOf(p *payment.Payment) string {
+	if p.ReversalOf == nil {
+		return ""
+	}
+	return p.ReversalOf.String()
+}
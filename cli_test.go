@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/audit"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/db"
+	"github.com/ilyareist/task1/payment"
+	"github.com/shopspring/decimal"
+)
+
+// keys is an auth.KeyRepository keeping keys in memory.
+type keys map[string]*auth.APIKey
+
+func (k keys) StoreKey(key *auth.APIKey) error { k[key.ID] = key; return nil }
+
+func (k keys) FindKey(id string) (*auth.APIKey, error) { return k[id], nil }
+
+// entries is an audit log failing appends with err.
+type entries struct {
+	appended []*audit.Entry
+	err      error
+}
+
+func (l *entries) Append(e *audit.Entry) error {
+	if l.err != nil {
+		return l.err
+	}
+	var prev *audit.Entry
+	if len(l.appended) > 0 {
+		prev = l.appended[len(l.appended)-1]
+	}
+	e.Seal(prev)
+	l.appended = append(l.appended, e)
+	return nil
+}
+
+func (l *entries) Find(f audit.Filter) ([]*audit.Entry, error) {
+	var found []*audit.Entry
+	for _, e := range l.appended {
+		if e.Seq > f.After && (f.Limit == 0 || len(found) < f.Limit) {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+// rates is a payment.RateStore keeping rates in memory.
+type rates struct {
+	payment.RateStore
+	stored []payment.Rate
+}
+
+func (s *rates) StoreRates(r ...payment.Rate) error {
+	s.stored = append(s.stored, r...)
+	return nil
+}
+
+// accounts are an account.Repository and an account.Service of the accounts.
+type accounts struct {
+	account.Repository
+	account.Service
+	all []*account.Account
+}
+
+func (a *accounts) FindAll() []*account.Account { return a.all }
+
+func (a *accounts) LoadAll(ctx context.Context) []*account.Account { return a.all }
+
+// payments are a payment.Repository and a payment.Service of no payments.
+type payments struct {
+	payment.Repository
+	payment.Service
+}
+
+func (p *payments) FindAll() []*payment.Payment { return nil }
+
+func (p *payments) LoadAll(ctx context.Context) []*payment.Payment { return nil }
+
+// transact returns the transactor of wiring running on the repositories, which keep what was
+// stored only when the transaction commits.
+func transact(r *db.Repositories, committed *bool) func(fn func(r *db.Repositories) error) error {
+	return func(fn func(r *db.Repositories) error) error {
+		err := fn(r)
+		*committed = err == nil
+		return err
+	}
+}
+
+// capture returns what run writes to standard output, and its result.
+func capture(t *testing.T, run func() int) (string, int) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	code := run()
+	os.Stdout = stdout
+	w.Close()
+	var out bytes.Buffer
+	if _, err := io.Copy(&out, r); err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), code
+}
+
+func TestCreateKey(t *testing.T) {
+	storeErr := errors.New("connection reset")
+	tests := []struct {
+		name      string
+		args      []string
+		appendErr error
+		code      int
+		roles     []auth.Role
+	}{
+		{"roles", []string{"create", "-principal", "billing", "-role", "operator", "-role", "auditor"}, nil, 0, []auth.Role{auth.RoleOperator, auth.RoleAuditor}},
+		{"no roles", []string{"create", "-principal", "billing"}, nil, 0, nil},
+		{"no principal", []string{"create", "-role", "operator"}, nil, 2, nil},
+		{"unknown role", []string{"create", "-principal", "billing", "-role", "root"}, nil, 2, nil},
+		{"extra argument", []string{"create", "-principal", "billing", "now"}, nil, 2, nil},
+		{"failed append", []string{"create", "-principal", "billing"}, storeErr, 1, nil},
+	}
+	for _, tt := range tests {
+		stored := keys{}
+		audited := &entries{err: tt.appendErr}
+		var committed bool
+		w := &wiring{transact: transact(&db.Repositories{Keys: stored, Entries: audited}, &committed)}
+
+		out, code := capture(t, func() int { return createKey(w, tt.args, log.NewNopLogger()) })
+		if code != tt.code {
+			t.Errorf("%s: exit status %d, want %d", tt.name, code, tt.code)
+			continue
+		}
+		if code != 0 {
+			if out != "" || committed {
+				t.Errorf("%s: printed %q, committed %v", tt.name, out, committed)
+			}
+			continue
+		}
+		id := strings.SplitN(strings.TrimSpace(out), ".", 2)
+		key := stored[id[0]]
+		if len(id) != 2 || key == nil || !key.Matches(id[1]) {
+			t.Errorf("%s: printed %q, stored %+v", tt.name, out, stored)
+			continue
+		}
+		if key.Principal != "billing" || len(key.Roles) != len(tt.roles) || strings.Contains(key.Hash, id[1]) {
+			t.Errorf("%s: stored %+v", tt.name, key)
+		}
+		if len(audited.appended) != 1 || audited.appended[0].Action != "key.create" || audited.appended[0].Resource != key.ID {
+			t.Errorf("%s: audited %+v", tt.name, audited.appended)
+		}
+	}
+}
+
+func TestRunCommandUnknown(t *testing.T) {
+	for _, args := range [][]string{{"nope"}, {"account"}, {"account", "nope"}} {
+		if code := runCommand(args, log.NewNopLogger()); code != 2 {
+			t.Errorf("%q: exit status %d, want 2", args, code)
+		}
+	}
+}
+
+func TestCommandArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		run  command
+		args []string
+		code int
+	}{
+		{"account create without customer", createAccount, []string{"create", "-id", "John"}, 2},
+		{"account create with a bad balance", createAccount, []string{"create", "-customer", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "-balance", "ten"}, 2},
+		{"account create with an argument", createAccount, []string{"create", "John"}, 2},
+		{"account list with an argument", listAccounts, []string{"list", "John"}, 2},
+		{"account list", listAccounts, []string{"list"}, 0},
+		{"account freeze without id", freezeAccount, []string{"freeze"}, 2},
+		{"account unfreeze with two ids", freezeAccount, []string{"unfreeze", "John", "Jane"}, 2},
+		{"payment list with an argument", listPayments, []string{"list", "John"}, 2},
+		{"payment list", listPayments, []string{"list"}, 0},
+		{"payment reverse without id", reversePayment, []string{"reverse"}, 2},
+		{"payment reverse with a bad id", reversePayment, []string{"reverse", "42"}, 2},
+		{"payment statement without account", paymentStatement, []string{"statement"}, 2},
+		{"payment statement with a bad start", paymentStatement, []string{"statement", "-from", "yesterday", "John"}, 2},
+		{"payment statement with a bad end", paymentStatement, []string{"statement", "-to", "tomorrow", "John"}, 2},
+		{"payment statement in a bad format", paymentStatement, []string{"statement", "-format", "xml", "John"}, 2},
+		{"rates import without file", importRates, []string{"import"}, 2},
+		{"rates import of a missing file", importRates, []string{"import", "missing.csv"}, 1},
+		{"ledger verify with an argument", verifyLedger, []string{"verify", "now"}, 2},
+		{"ledger verify", verifyLedger, []string{"verify"}, 0},
+		{"audit verify with an argument", verifyAudit, []string{"verify", "now"}, 2},
+		{"audit verify", verifyAudit, []string{"verify"}, 0},
+		{"keys create with an unknown flag", createKey, []string{"create", "-secret", "abc"}, 2},
+		{"export accounts in a bad format", exportAccounts, []string{"accounts", "-format", "xml"}, 2},
+		{"export accounts with an argument", exportAccounts, []string{"accounts", "all"}, 2},
+		{"export accounts", exportAccounts, []string{"accounts", "-format", "csv"}, 0},
+		{"export payments", exportPayments, []string{"payments"}, 0},
+	}
+	for _, tt := range tests {
+		accts := &accounts{all: []*account.Account{{ID: "John", Balance: decimal.New(10, 0)}}}
+		w := &wiring{
+			accounts:       accts,
+			payments:       &payments{},
+			entries:        &entries{},
+			accountService: accts,
+			paymentService: &payments{},
+		}
+		_, code := capture(t, func() int { return tt.run(w, tt.args, log.NewNopLogger()) })
+		if code != tt.code {
+			t.Errorf("%s: exit status %d, want %d", tt.name, code, tt.code)
+		}
+	}
+}
+
+func TestImportRates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	good := write("good.csv", "currency,date,rate\nEUR,2019-10-01,0.91\nGBP,2019-10-01,0.81\n")
+	bad := write("bad.csv", "EUR,2019-10-01,zero\n")
+
+	storeErr := errors.New("connection reset")
+	tests := []struct {
+		name      string
+		file      string
+		appendErr error
+		code      int
+	}{
+		{"imported", good, nil, 0},
+		{"malformed", bad, nil, 1},
+		{"failed append", good, storeErr, 1},
+	}
+	for _, tt := range tests {
+		stored := &rates{}
+		audited := &entries{err: tt.appendErr}
+		var committed bool
+		w := &wiring{transact: transact(&db.Repositories{Rates: stored, Entries: audited}, &committed)}
+
+		code := importRates(w, []string{"import", tt.file}, log.NewNopLogger())
+		if code != tt.code {
+			t.Errorf("%s: exit status %d, want %d", tt.name, code, tt.code)
+			continue
+		}
+		if code != 0 {
+			if committed {
+				t.Errorf("%s: committed", tt.name)
+			}
+			continue
+		}
+		if len(stored.stored) != 2 || stored.stored[1] != (payment.Rate{Currency: "GBP", Date: "2019-10-01", Rate: 0.81}) {
+			t.Errorf("%s: stored %+v", tt.name, stored.stored)
+		}
+		if len(audited.appended) != 1 || audited.appended[0].Action != "rates.import" || audited.appended[0].Actor == "" {
+			t.Errorf("%s: audited %+v", tt.name, audited.appended)
+		}
+	}
+}
+
+func TestVerifyAudit(t *testing.T) {
+	chain := &entries{}
+	for _, action := range []string{"account.create", "payment.create", "payment.reverse"} {
+		_ = chain.Append(audit.NewEntry(adminContext(), action, "John", nil, nil, nil))
+	}
+	w := &wiring{entries: chain}
+	if code := verifyAudit(w, []string{"verify"}, log.NewNopLogger()); code != 0 {
+		t.Fatalf("intact log: exit status %d", code)
+	}
+	chain.appended[1].Resource = "Jane"
+	if code := verifyAudit(w, []string{"verify"}, log.NewNopLogger()); code != 1 {
+		t.Errorf("tampered log: exit status %d, want 1", code)
+	}
+}
+
+func TestVerifyLedger(t *testing.T) {
+	w := &wiring{
+		accounts: &accounts{all: []*account.Account{{ID: "John", Balance: decimal.New(-10, 0)}}},
+		payments: &payments{},
+	}
+	out, code := capture(t, func() int { return verifyLedger(w, []string{"verify"}, log.NewNopLogger()) })
+	if code != 1 || !strings.Contains(out, "John") {
+		t.Errorf("negative balance: exit status %d, printed %q", code, out)
+	}
+}
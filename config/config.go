@@ -0,0 +1,371 @@
+// Package config holds settings of the service, loaded from a YAML or TOML file, environment
+// variables and command-line flags, in that order of precedence from lowest to highest.
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/headers"
+	"github.com/ilyareist/task1/ratelimit"
+	"github.com/ilyareist/task1/tlsreload"
+	"github.com/ilyareist/task1/tracing"
+	"github.com/shopspring/decimal"
+)
+
+// Config is the configuration of the service. Every setting is addressed by its key, section
+// and name separated by a dot, e.g. db.host, in files; by the env variable, PAYMENTS_DB_HOST
+// unless the env tag names another, in the environment; and by the flag on the command line.
+type Config struct {
+	HTTP      HTTP      `key:"http"`
+	GRPC      GRPC      `key:"grpc"`
+	DB        DB        `key:"db"`
+	Auth      Auth      `key:"auth"`
+	Activity  Activity  `key:"activity"`
+	Webhooks  Webhooks  `key:"webhooks"`
+	Rates     Rates     `key:"rates"`
+	Approvals Approvals `key:"approvals"`
+	Balances  Balances  `key:"balances"`
+	Screening Screening `key:"screening"`
+	Health    Health    `key:"health"`
+	Tracing   Tracing   `key:"tracing"`
+	RateLimit RateLimit `key:"rate_limit"`
+	CORS      CORS      `key:"cors"`
+	Security  Security  `key:"security"`
+}
+
+// HTTP configures the HTTP server.
+type HTTP struct {
+	Host          string        `key:"host" flag:"http_host" usage:"Host the HTTP server listens on"`
+	Port          int           `key:"port" env:"HTTP_PORT" flag:"http_port" usage:"Port the HTTP server listens on"`
+	MaxBodySize   int64         `key:"max_body_size" flag:"max_body_size" usage:"Maximum size in bytes of request bodies and gRPC messages"`
+	TLSCert       string        `key:"tls_cert" flag:"tls_cert" usage:"PEM certificate file, the server serves HTTPS when it is given with the key"`
+	TLSKey        string        `key:"tls_key" flag:"tls_key" usage:"PEM private key file of the certificate"`
+	TLSClientCA   string        `key:"tls_client_ca" flag:"tls_client_ca" usage:"PEM file of CAs client certificates are verified with, enables mutual TLS"`
+	TLSClientAuth string        `key:"tls_client_auth" flag:"tls_client_auth" usage:"Client certificates under mutual TLS: optional or require"`
+	TLSPrincipals string        `key:"tls_principals" flag:"tls_principals" usage:"CSV file mapping client certificate subjects to principals and roles, common names are principals with no roles when empty"`
+	TLSReload     time.Duration `key:"tls_reload" flag:"tls_reload" usage:"Interval between checks of certificate files for changes"`
+}
+
+// Address is the address the HTTP server listens on.
+func (h HTTP) Address() string { return net.JoinHostPort(h.Host, strconv.Itoa(h.Port)) }
+
+// GRPC configures the gRPC server.
+type GRPC struct {
+	Host string `key:"host" flag:"grpc_host" usage:"Host the gRPC server listens on"`
+	Port int    `key:"port" env:"GRPC_PORT" flag:"grpc_port" usage:"Port the gRPC server listens on"`
+}
+
+// Address is the address the gRPC server listens on.
+func (g GRPC) Address() string { return net.JoinHostPort(g.Host, strconv.Itoa(g.Port)) }
+
+// DB configures the PostgreSQL connection.
+type DB struct {
+	Host     string `key:"host" env:"DB_HOST" flag:"db_host" usage:"PostgreSQL server host"`
+	Port     int    `key:"port" env:"DB_PORT" flag:"db_port" usage:"PostgreSQL server port"`
+	User     string `key:"user" env:"DB_USER" flag:"db_user" usage:"PostgreSQL connection user"`
+	Password string `key:"password" env:"DB_PASSWORD" flag:"db_password" usage:"PostgreSQL connection password" secret:"true"`
+	Name     string `key:"name" env:"DB_NAME" flag:"database" usage:"PostgreSQL database name"`
+	AppName  string `key:"app_name" flag:"app_name" usage:"PostgreSQL application name (for logging), also the service name of traces"`
+	PoolSize int    `key:"pool_size" flag:"pool_size" usage:"PostgreSQL connection pool size"`
+	Log      bool   `key:"log" flag:"db_log" usage:"Switch for statements logging"`
+}
+
+// Address is the address of the PostgreSQL server.
+func (d DB) Address() string { return net.JoinHostPort(d.Host, strconv.Itoa(d.Port)) }
+
+// Auth configures authentication of callers.
+type Auth struct {
+	JWTSecret   string `key:"jwt_secret" flag:"jwt_secret" usage:"Secret for HS256 signed JWT bearer tokens, HS256 is disabled when empty" secret:"true"`
+	JWKSFile    string `key:"jwks_file" flag:"jwks_file" usage:"JSON Web Key Set file with public keys for RS256 signed JWT bearer tokens"`
+	JWTIssuer   string `key:"jwt_issuer" flag:"jwt_issuer" usage:"Required issuer (iss) of JWT bearer tokens"`
+	JWTAudience string `key:"jwt_audience" flag:"jwt_audience" usage:"Required audience (aud) of JWT bearer tokens"`
+}
+
+// Activity configures the activity stream.
+type Activity struct {
+	History int  `key:"history" flag:"activity_history" usage:"Number of recent activity events kept for stream resuming"`
+	Notify  bool `key:"notify" flag:"activity_notify" usage:"Fan activity out to other instances via PostgreSQL LISTEN/NOTIFY"`
+}
+
+// Webhooks configures webhook delivery.
+type Webhooks struct {
+	Interval    time.Duration `key:"interval" flag:"webhook_interval" usage:"Interval between webhook dispatching rounds"`
+	MaxAttempts int           `key:"max_attempts" flag:"webhook_max_attempts" usage:"Webhook delivery attempts before dead-lettering"`
+	Timeout     time.Duration `key:"timeout" flag:"webhook_timeout" usage:"Webhook delivery request timeout"`
+}
+
+// Rates configures the exchange rate provider.
+type Rates struct {
+	URL      string        `key:"url" flag:"rates_url" usage:"Base URL of the exchangeratesapi.io compatible rate provider"`
+	Timeout  time.Duration `key:"timeout" flag:"rates_timeout" usage:"Exchange rate request timeout"`
+	CacheTTL time.Duration `key:"cache_ttl" flag:"rates_cache_ttl" usage:"Time latest exchange rates are cached for"`
+}
+
+// Approvals configures approval of large payments.
+type Approvals struct {
+	Thresholds     Thresholds    `key:"thresholds" flag:"approval_thresholds" usage:"Payments debiting more than this need approval, per source currency, e.g. USD=10000,RUB=700000"`
+	TTL            time.Duration `key:"ttl" flag:"approval_ttl" usage:"Time a payment may wait for approval before it expires"`
+	ExpireInterval time.Duration `key:"expire_interval" flag:"approval_expire_interval" usage:"Interval between expiring overdue payment approvals"`
+}
+
+// Balances configures balance snapshots, which balances at past moments are worked out from.
+type Balances struct {
+	SnapshotInterval time.Duration `key:"snapshot_interval" flag:"balance_snapshot_interval" usage:"Interval between checks for balance snapshots to take"`
+	SnapshotDelay    time.Duration `key:"snapshot_delay" flag:"balance_snapshot_delay" usage:"Time after midnight UTC balances are snapshot as of it, for late payments to be stored"`
+}
+
+// Screening configures rules payments are screened by.
+type Screening struct {
+	VelocityWindow        time.Duration   `key:"velocity_window" flag:"screening_velocity_window" usage:"Window of the velocity screening rule"`
+	VelocityCount         int             `key:"velocity_count" flag:"screening_velocity_count" usage:"Payments from an account allowed within the velocity window before review, unlimited when 0"`
+	VelocityAmount        decimal.Decimal `key:"velocity_amount" flag:"screening_velocity_amount" usage:"Amount in account currency allowed to be debited within the velocity window before review, unlimited when 0"`
+	UnusualFactor         decimal.Decimal `key:"unusual_factor" flag:"screening_unusual_factor" usage:"Payments this many times larger than the recent average of the account are reviewed"`
+	UnusualSamples        int             `key:"unusual_samples" flag:"screening_unusual_samples" usage:"Recent payments averaged by the unusual amount rule, the rule is off when 0"`
+	NewCounterpartyAmount decimal.Decimal `key:"new_counterparty_amount" flag:"screening_new_counterparty_amount" usage:"First payments to a counterparty from this amount in account currency are reviewed, off when 0"`
+	BlockedCountries      string          `key:"blocked_countries" flag:"screening_blocked_countries" usage:"Comma separated countries payments from and to are blocked"`
+	SanctionsFile         string          `key:"sanctions_file" flag:"screening_sanctions_file" usage:"Sanctions list file with one account id or person name per line"`
+}
+
+// Countries lists the blocked countries.
+func (s Screening) Countries() []account.Country {
+	var countries []account.Country
+	for _, c := range list(s.BlockedCountries) {
+		countries = append(countries, account.Country(c))
+	}
+	return countries
+}
+
+// Health configures readiness checks and shutdown.
+type Health struct {
+	Timeout      time.Duration `key:"timeout" flag:"health_timeout" usage:"Timeout of every readiness check"`
+	DrainDelay   time.Duration `key:"drain_delay" flag:"drain_delay" usage:"Time readiness is reported failing on shutdown before connections are drained, for load balancers to notice"`
+	DrainTimeout time.Duration `key:"drain_timeout" flag:"drain_timeout" usage:"Time requests in flight are given to complete on shutdown"`
+}
+
+// Tracing configures export of spans.
+type Tracing struct {
+	Exporter     string  `key:"exporter" flag:"trace_exporter" usage:"Span exporter: none, stdout or otlp"`
+	SampleRatio  float64 `key:"sample_ratio" flag:"trace_sample_ratio" usage:"Fraction of new traces sampled, traces of callers follow their sampling decision"`
+	OTLPEndpoint string  `key:"otlp_endpoint" flag:"otlp_endpoint" usage:"Address of the OTLP gRPC collector spans are exported to"`
+	OTLPInsecure bool    `key:"otlp_insecure" flag:"otlp_insecure" usage:"Export spans to the OTLP collector without TLS"`
+}
+
+// RateLimit configures throttling of HTTP API clients. Limits are written as <tokens>/<period>[:<burst>],
+// e.g. 10/s or 600/1m:50, and turned off by "off".
+type RateLimit struct {
+	KeyRead    ratelimit.Rule `key:"key_read" flag:"rate_limit_key_read" usage:"Limit of reads per API key or token"`
+	KeyMoney   ratelimit.Rule `key:"key_money" flag:"rate_limit_key_money" usage:"Limit of money-moving requests per API key or token"`
+	IPRead     ratelimit.Rule `key:"ip_read" flag:"rate_limit_ip_read" usage:"Limit of reads per client IP"`
+	IPMoney    ratelimit.Rule `key:"ip_money" flag:"rate_limit_ip_money" usage:"Limit of money-moving requests per client IP"`
+	Shared     bool           `key:"shared" flag:"rate_limit_shared" usage:"Keep rate limits in PostgreSQL, so that all instances enforce one budget"`
+	TrustProxy bool           `key:"trust_proxy" flag:"trust_proxy" usage:"Take client IPs from X-Forwarded-For set by a proxy in front of the service"`
+}
+
+// CORS configures calls of the API by scripts of other origins. Lists are comma separated.
+type CORS struct {
+	Origins     string        `key:"origins" flag:"cors_origins" usage:"Origins browsers may call the API from, * for any, e.g. https://app.example.com,https://*.example.com"`
+	Methods     string        `key:"methods" flag:"cors_methods" usage:"Methods allowed in cross-origin calls"`
+	Headers     string        `key:"headers" flag:"cors_headers" usage:"Request headers allowed in cross-origin calls, * for any"`
+	Expose      string        `key:"expose" flag:"cors_expose" usage:"Response headers scripts of other origins may read"`
+	Credentials bool          `key:"credentials" flag:"cors_credentials" usage:"Allow credentials in cross-origin calls, origins must be listed by name"`
+	MaxAge      time.Duration `key:"max_age" flag:"cors_max_age" usage:"Time browsers may cache preflight responses"`
+}
+
+// Policy is the CORS policy of the settings.
+func (c CORS) Policy() headers.CORS {
+	return headers.CORS{
+		Origins:     list(c.Origins),
+		Methods:     list(c.Methods),
+		Headers:     list(c.Headers),
+		Expose:      list(c.Expose),
+		Credentials: c.Credentials,
+		MaxAge:      c.MaxAge,
+	}
+}
+
+// Security configures headers hardening responses.
+type Security struct {
+	HSTS                  time.Duration `key:"hsts" flag:"hsts" usage:"max-age of Strict-Transport-Security, not sent when 0"`
+	FrameOptions          string        `key:"frame_options" flag:"frame_options" usage:"X-Frame-Options of responses"`
+	ReferrerPolicy        string        `key:"referrer_policy" flag:"referrer_policy" usage:"Referrer-Policy of responses"`
+	ContentSecurityPolicy string        `key:"content_security_policy" flag:"content_security_policy" usage:"Content-Security-Policy of API responses"`
+}
+
+// list splits a comma separated list, dropping empty items.
+func list(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Thresholds are amounts per currency, written as comma separated CURRENCY=AMOUNT pairs.
+type Thresholds map[account.Currency]decimal.Decimal
+
+// UnmarshalText parses comma separated CURRENCY=AMOUNT pairs.
+func (t *Thresholds) UnmarshalText(text []byte) error {
+	thresholds := make(Thresholds)
+	for _, pair := range strings.Split(string(text), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("threshold %q is not CURRENCY=AMOUNT", pair)
+		}
+		amount, err := decimal.NewFromString(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return fmt.Errorf("threshold %q: %v", pair, err)
+		}
+		thresholds[account.Currency(strings.ToUpper(strings.TrimSpace(kv[0])))] = amount
+	}
+	*t = thresholds
+	return nil
+}
+
+// String formats the thresholds as UnmarshalText parses them, sorted by currency.
+func (t Thresholds) String() string {
+	pairs := make([]string, 0, len(t))
+	for currency, amount := range t {
+		pairs = append(pairs, string(currency)+"="+amount.String())
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Default returns the configuration used for settings given neither by file, environment
+// nor flags.
+func Default() *Config {
+	return &Config{
+		HTTP: HTTP{
+			Host: "0.0.0.0", Port: 8080, MaxBodySize: 1 << 20,
+			TLSClientAuth: tlsreload.ClientOptional, TLSReload: 10 * time.Second,
+		},
+		GRPC:     GRPC{Host: "0.0.0.0", Port: 8081},
+		DB:       DB{Host: "postgres", Port: 5432, User: "postgres", Password: "password", Name: "payments", AppName: "payments", PoolSize: 10},
+		Activity: Activity{History: 1024, Notify: true},
+		Webhooks: Webhooks{Interval: time.Second, MaxAttempts: 8, Timeout: 10 * time.Second},
+		Rates:    Rates{URL: "https://api.exchangeratesapi.io/", Timeout: 10 * time.Second, CacheTTL: 10 * time.Minute},
+		Approvals: Approvals{
+			Thresholds:     Thresholds{},
+			TTL:            24 * time.Hour,
+			ExpireInterval: time.Minute,
+		},
+		Balances:  Balances{SnapshotInterval: time.Hour, SnapshotDelay: time.Hour},
+		Screening: Screening{VelocityWindow: time.Hour, UnusualFactor: decimal.New(5, 0)},
+		Health:    Health{Timeout: 2 * time.Second, DrainTimeout: 30 * time.Second},
+		Tracing:   Tracing{Exporter: tracing.ExporterNone, SampleRatio: 1, OTLPEndpoint: "localhost:4317"},
+		RateLimit: RateLimit{
+			KeyRead:  ratelimit.Rule{Rate: 20, Burst: 40},
+			KeyMoney: ratelimit.Rule{Rate: 5, Burst: 10},
+			IPRead:   ratelimit.Rule{Rate: 50, Burst: 100},
+			IPMoney:  ratelimit.Rule{Rate: 10, Burst: 20},
+		},
+		CORS: CORS{
+			Origins: "*",
+			Methods: "GET,POST,PUT,PATCH,DELETE",
+			Headers: "Content-Type,Authorization,X-API-Key,X-Request-ID",
+			Expose:  "X-Request-ID,Retry-After",
+			MaxAge:  10 * time.Minute,
+		},
+		Security: Security{
+			FrameOptions:          "DENY",
+			ReferrerPolicy:        "no-referrer",
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		},
+	}
+}
+
+// Validate checks the settings, reporting every invalid one.
+func (c *Config) Validate() error {
+	var invalid []string
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			invalid = append(invalid, key+" "+fmt.Sprintf(format, args...))
+		}
+	}
+	port := func(key string, p int) { check(p > 0 && p < 1<<16, key, "must be a port number, got %d", p) }
+	positive := func(key string, d time.Duration) { check(d > 0, key, "must be positive, got %s", d) }
+
+	port("http.port", c.HTTP.Port)
+	check(c.HTTP.MaxBodySize > 0, "http.max_body_size", "must be positive")
+	check((c.HTTP.TLSCert == "") == (c.HTTP.TLSKey == ""), "http.tls_key", "must be given together with http.tls_cert")
+	check(c.HTTP.TLSClientCA == "" || c.HTTP.TLSCert != "", "http.tls_client_ca", "needs TLS, with http.tls_cert and http.tls_key")
+	check(c.HTTP.TLSPrincipals == "" || c.HTTP.TLSClientCA != "", "http.tls_principals", "needs mutual TLS, with http.tls_client_ca")
+	check(c.HTTP.TLSClientAuth == tlsreload.ClientOptional || c.HTTP.TLSClientAuth == tlsreload.ClientRequire, "http.tls_client_auth", "must be optional or require, got %q", c.HTTP.TLSClientAuth)
+	positive("http.tls_reload", c.HTTP.TLSReload)
+	port("grpc.port", c.GRPC.Port)
+	check(c.HTTP.Address() != c.GRPC.Address(), "grpc.port", "must differ from http.port on the same host")
+
+	check(c.DB.Host != "", "db.host", "is required")
+	port("db.port", c.DB.Port)
+	check(c.DB.User != "", "db.user", "is required")
+	check(c.DB.Name != "", "db.name", "is required")
+	check(c.DB.PoolSize > 0, "db.pool_size", "must be positive, got %d", c.DB.PoolSize)
+
+	check(c.Activity.History >= 0, "activity.history", "must not be negative")
+
+	positive("webhooks.interval", c.Webhooks.Interval)
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts", "must be positive, got %d", c.Webhooks.MaxAttempts)
+	positive("webhooks.timeout", c.Webhooks.Timeout)
+
+	u, err := url.Parse(c.Rates.URL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "rates.url", "must be an http(s) URL, got %q", c.Rates.URL)
+	positive("rates.timeout", c.Rates.Timeout)
+	check(c.Rates.CacheTTL >= 0, "rates.cache_ttl", "must not be negative")
+
+	for currency, amount := range c.Approvals.Thresholds {
+		check(amount.Sign() > 0, "approvals.thresholds", "of %s must be positive", currency)
+	}
+	positive("approvals.ttl", c.Approvals.TTL)
+	positive("approvals.expire_interval", c.Approvals.ExpireInterval)
+
+	positive("balances.snapshot_interval", c.Balances.SnapshotInterval)
+	check(c.Balances.SnapshotDelay >= 0, "balances.snapshot_delay", "must not be negative")
+
+	positive("screening.velocity_window", c.Screening.VelocityWindow)
+	check(c.Screening.VelocityCount >= 0, "screening.velocity_count", "must not be negative")
+	check(c.Screening.VelocityAmount.Sign() >= 0, "screening.velocity_amount", "must not be negative")
+	check(c.Screening.UnusualFactor.Sign() > 0, "screening.unusual_factor", "must be positive")
+	check(c.Screening.UnusualSamples >= 0, "screening.unusual_samples", "must not be negative")
+	check(c.Screening.NewCounterpartyAmount.Sign() >= 0, "screening.new_counterparty_amount", "must not be negative")
+
+	positive("health.timeout", c.Health.Timeout)
+	check(c.Health.DrainDelay >= 0, "health.drain_delay", "must not be negative")
+	positive("health.drain_timeout", c.Health.DrainTimeout)
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		check(false, "tracing.exporter", "must be one of none, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be within [0, 1], got %v", c.Tracing.SampleRatio)
+	check(c.Tracing.Exporter != tracing.ExporterOTLP || c.Tracing.OTLPEndpoint != "", "tracing.otlp_endpoint", "is required by the otlp exporter")
+
+	for _, origin := range list(c.CORS.Origins) {
+		if origin == headers.Any {
+			check(!c.CORS.Credentials, "cors.origins", "must list origins by name when credentials are allowed")
+			continue
+		}
+		u, err := url.Parse(strings.Replace(origin, "://*.", "://", 1))
+		check(err == nil && u.Scheme != "" && u.Host != "" && u.Path == "", "cors.origins", "must be * or scheme://host[:port], got %q", origin)
+	}
+	check(len(list(c.CORS.Methods)) > 0, "cors.methods", "is required")
+	check(c.CORS.MaxAge >= 0, "cors.max_age", "must not be negative")
+	check(c.Security.HSTS >= 0, "security.hsts", "must not be negative")
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(invalid, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/shopspring/decimal"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// EnvPrefix prefixes environment variables of settings without an env tag.
+const EnvPrefix = "PAYMENTS_"
+
+// EnvFile names the configuration file when the -config flag does not.
+const EnvFile = EnvPrefix + "CONFIG"
+
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	decimalType     = reflect.TypeOf(decimal.Decimal{})
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// setting is a field of the configuration.
+type setting struct {
+	key    string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// settings lists the settings of c in the order of declaration.
+func (c *Config) settings() []setting {
+	var list []setting
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Type().Field(i)
+		fields := sections.Field(i)
+		for j := 0; j < fields.NumField(); j++ {
+			f := fields.Type().Field(j)
+			key := section.Tag.Get("key") + "." + f.Tag.Get("key")
+			s := setting{
+				key:    key,
+				env:    f.Tag.Get("env"),
+				flag:   f.Tag.Get("flag"),
+				usage:  f.Tag.Get("usage"),
+				secret: f.Tag.Get("secret") == "true",
+				value:  fields.Field(j),
+			}
+			if s.env == "" {
+				s.env = EnvPrefix + strings.ToUpper(strings.Replace(key, ".", "_", -1))
+			}
+			if s.flag == "" {
+				s.flag = strings.Replace(key, ".", "_", -1)
+			}
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// assignment is a flag given on the command line.
+type assignment struct {
+	setting setting
+	value   string
+}
+
+// flagValue records a flag, it is applied after the file and the environment.
+type flagValue struct {
+	setting  setting
+	defaults string
+	given    *[]assignment
+}
+
+func (f *flagValue) String() string { return f.defaults }
+
+func (f *flagValue) Set(s string) error {
+	// Parsed into a scratch value for errors to be reported along with the flag.
+	if err := parse(reflect.New(f.setting.value.Type()).Elem(), s); err != nil {
+		return err
+	}
+	*f.given = append(*f.given, assignment{setting: f.setting, value: s})
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.setting.value.Kind() == reflect.Bool }
+
+// Load registers flags of the settings on fs and parses args with it, then loads the
+// configuration: defaults are overridden by the file named by the -config flag or the
+// PAYMENTS_CONFIG variable, which is overridden by environment variables, which are overridden
+// by flags. The configuration is validated. Arguments after flags are left in fs.Args().
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	c := Default()
+	var given []assignment
+	for _, s := range c.settings() {
+		fs.Var(&flagValue{setting: s, defaults: format(s.value), given: &given}, s.flag, s.usage)
+	}
+	file := fs.String("config", "", "YAML or TOML configuration file, by extension (env "+EnvFile+")")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *file == "" {
+		*file = os.Getenv(EnvFile)
+	}
+	if *file != "" {
+		if err := c.loadFile(*file); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.loadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	for _, a := range given {
+		if err := parse(a.setting.value, a.value); err != nil {
+			return nil, fmt.Errorf("flag -%s: %v", a.setting.flag, err)
+		}
+	}
+	return c, c.Validate()
+}
+
+// loadEnv sets settings given by environment variables.
+func (c *Config) loadEnv(lookup func(string) (string, bool)) error {
+	for _, s := range c.settings() {
+		if v, ok := lookup(s.env); ok {
+			if err := parse(s.value, v); err != nil {
+				return fmt.Errorf("environment variable %s: %v", s.env, err)
+			}
+		}
+	}
+	return nil
+}
+
+// loadFile sets settings given by the YAML or TOML file. Keys which are not settings are errors.
+func (c *Config) loadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %v", err)
+	}
+	var doc map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return fmt.Errorf("config file %s: unknown format, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %v", path, err)
+	}
+
+	settings := make(map[string]setting)
+	for _, s := range c.settings() {
+		settings[s.key] = s
+	}
+	values := make(map[string]interface{})
+	flatten("", doc, values)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var unknown []string
+	for _, k := range keys {
+		s, ok := settings[k]
+		if !ok {
+			unknown = append(unknown, k)
+			continue
+		}
+		v, err := scalar(values[k])
+		if err == nil {
+			err = parse(s.value, v)
+		}
+		if err != nil {
+			return fmt.Errorf("config file %s: %s: %v", path, k, err)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("config file %s: unknown settings %s", path, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// flatten collects values of nested tables by their dotted keys. Tables of values, like
+// approval thresholds, are kept for scalar to format.
+func flatten(prefix string, table map[string]interface{}, values map[string]interface{}) {
+	for k, v := range table {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := stringMap(v); ok && prefix == "" {
+			flatten(key, nested, values)
+			continue
+		}
+		values[key] = v
+	}
+}
+
+// stringMap converts tables decoded by YAML, keyed by interface{}, and by TOML alike.
+func stringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			converted[fmt.Sprint(k)] = v
+		}
+		return converted, true
+	}
+	return nil, false
+}
+
+// scalar formats a value of the file the way it would be given by a flag.
+func scalar(v interface{}) (string, error) {
+	if m, ok := stringMap(v); ok {
+		pairs := make([]string, 0, len(m))
+		for k, v := range m {
+			pairs = append(pairs, k+"="+fmt.Sprint(v))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, uint64:
+		return fmt.Sprint(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// parse sets the setting v from its text form.
+func parse(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case v.Type() == decimalType:
+		// Empty decimals are zero, which turns off rules they limit.
+		d := decimal.Zero
+		if s = strings.TrimSpace(s); s != "" {
+			var err error
+			if d, err = decimal.NewFromString(s); err != nil {
+				return err
+			}
+		}
+		v.Set(reflect.ValueOf(d))
+		return nil
+	case v.Addr().Type().Implements(textUnmarshaler):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// format returns the text form of the setting v, as parse reads it.
+func format(v reflect.Value) string {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	if v.Kind() == reflect.Float64 {
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	return fmt.Sprint(v.Interface())
+}
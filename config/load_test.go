@@ -0,0 +1,167 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ilyareist/task1/account"
+	"github.com/shopspring/decimal"
+)
+
+// setenv replaces the environment variables of every setting with env, returning a function
+// restoring them.
+func setenv(env map[string]string) func() {
+	saved := make(map[string]string)
+	for _, s := range Default().settings() {
+		saved[s.env] = ""
+	}
+	saved[EnvFile] = ""
+	for name := range saved {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			delete(saved, name)
+			continue
+		}
+		saved[name] = v
+		os.Unsetenv(name)
+	}
+	for name, v := range env {
+		os.Setenv(name, v)
+	}
+	return func() {
+		for name := range env {
+			os.Unsetenv(name)
+		}
+		for name, v := range saved {
+			os.Setenv(name, v)
+		}
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	yamlFile := writeFile(t, dir, "payments.yaml", `
+db:
+  host: file-host
+  user: file-user
+  name: file-name
+approvals:
+  thresholds:
+    USD: 100
+`)
+	tomlFile := writeFile(t, dir, "payments.toml", `
+[db]
+host = "file-host"
+user = "file-user"
+name = "file-name"
+
+[approvals.thresholds]
+USD = 100
+`)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{"yaml file by flag", map[string]string{"DB_USER": "env-user", "DB_NAME": "env-name", "PAYMENTS_DB_POOL_SIZE": "30"},
+			[]string{"-config", yamlFile, "-database", "flag-name", "-pool_size", "40", "serve"}},
+		{"toml file by environment", map[string]string{EnvFile: tomlFile, "DB_USER": "env-user", "DB_NAME": "env-name", "PAYMENTS_DB_POOL_SIZE": "30"},
+			[]string{"-database=flag-name", "-pool_size=40", "serve"}},
+	}
+	for _, tt := range tests {
+		restore := setenv(tt.env)
+		fs := flag.NewFlagSet("payments", flag.ContinueOnError)
+		c, err := Load(fs, tt.args)
+		restore()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		got := map[string]interface{}{
+			"default":                  c.DB.AppName,
+			"file":                     c.DB.Host,
+			"file and env":             c.DB.User,
+			"file, env and flag":       c.DB.Name,
+			"env and flag":             c.DB.PoolSize,
+			"default of another layer": c.DB.Port,
+		}
+		want := map[string]interface{}{
+			"default":                  "payments",
+			"file":                     "file-host",
+			"file and env":             "env-user",
+			"file, env and flag":       "flag-name",
+			"env and flag":             40,
+			"default of another layer": 5432,
+		}
+		for k := range want {
+			if got[k] != want[k] {
+				t.Errorf("%s: %s: got %v, want %v", tt.name, k, got[k], want[k])
+			}
+		}
+		if usd := c.Approvals.Thresholds[account.CurrencyUSD]; !usd.Equal(decimal.New(100, 0)) {
+			t.Errorf("%s: thresholds %v", tt.name, c.Approvals.Thresholds)
+		}
+		if args := fs.Args(); len(args) != 1 || args[0] != "serve" {
+			t.Errorf("%s: args %v", tt.name, args)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	unknown := writeFile(t, dir, "unknown.yaml", "db:\n  hostname: db\n")
+	invalid := writeFile(t, dir, "invalid.yaml", "db:\n  port: many\n")
+	other := writeFile(t, dir, "payments.json", "{}")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{"unknown file setting", nil, []string{"-config", unknown}, "unknown settings db.hostname"},
+		{"invalid file value", nil, []string{"-config", invalid}, "db.port"},
+		{"unknown file format", nil, []string{"-config", other}, "unknown format"},
+		{"missing file", map[string]string{EnvFile: filepath.Join(dir, "missing.yaml")}, nil, "config file"},
+		{"invalid environment value", map[string]string{"DB_PORT": "many"}, nil, "environment variable DB_PORT"},
+		{"invalid flag value", nil, []string{"-db_port", "many"}, "db_port"},
+		{"invalid setting", map[string]string{"HTTP_PORT": "0"}, nil, "http.port must be a port number"},
+		{"flag overriding an invalid setting", map[string]string{"HTTP_PORT": "0"}, []string{"-http_port", "8090"}, ""},
+	}
+	for _, tt := range tests {
+		restore := setenv(tt.env)
+		fs := flag.NewFlagSet("payments", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		_, err := Load(fs, tt.args)
+		restore()
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
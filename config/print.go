@@ -0,0 +1,48 @@
+package config
+
+import (
+	"io"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Redacted replaces values of secret settings when the configuration is printed.
+const Redacted = "REDACTED"
+
+// Print writes the configuration as a YAML file Load would read it from, with values of secret
+// settings redacted.
+func (c *Config) Print(w io.Writer) error {
+	var doc yaml.MapSlice
+	for _, s := range c.settings() {
+		parts := strings.SplitN(s.key, ".", 2)
+		if len(doc) == 0 || doc[len(doc)-1].Key != parts[0] {
+			doc = append(doc, yaml.MapItem{Key: parts[0], Value: yaml.MapSlice{}})
+		}
+		section := &doc[len(doc)-1]
+		section.Value = append(section.Value.(yaml.MapSlice), yaml.MapItem{Key: parts[1], Value: printable(s)})
+	}
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// printable returns the value of the setting as YAML should show it.
+func printable(s setting) interface{} {
+	if s.secret && s.value.String() != "" {
+		return Redacted
+	}
+	switch s.value.Kind() {
+	case reflect.Bool, reflect.Float64:
+		return s.value.Interface()
+	case reflect.Int, reflect.Int64:
+		if s.value.Type() != durationType {
+			return s.value.Int()
+		}
+	}
+	return format(s.value)
+}
@@ -0,0 +1,84 @@
+package customer
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/audit"
+)
+
+// Transactor runs fn in one transaction, handing it the service, the customers and the audit log
+// bound to the transaction. The transaction is committed when fn returns nil.
+type Transactor func(fn func(s Service, customers Repository, entries audit.Repository) error) error
+
+type auditingService struct {
+	recorder audit.Recorder
+	transact Transactor
+	Service
+}
+
+// NewAuditingService returns a Service recording its state-changing calls to the audit log.
+// Calls run on the service bound to a transaction of the transactor, their entries are appended
+// in the same transaction, together with states of customers read from it around the calls.
+func NewAuditingService(recorder audit.Recorder, transact Transactor, s Service) Service {
+	return &auditingService{recorder: recorder, transact: transact, Service: s}
+}
+
+func (s *auditingService) New(ctx context.Context, c Customer) (*Customer, error) {
+	var created *Customer
+	err := s.record(ctx, "customer.create", func(svc Service, customers Repository) (resource string, before, after interface{}, err error) {
+		created, err = svc.New(ctx, c)
+		if created != nil {
+			resource = created.ID.String()
+		}
+		return resource, nil, created, err
+	})
+	return created, err
+}
+
+func (s *auditingService) Update(ctx context.Context, c Customer) error {
+	return s.record(ctx, "customer.update", func(svc Service, customers Repository) (resource string, before, after interface{}, err error) {
+		before = find(customers, c.ID)
+		if err = svc.Update(ctx, c); err == nil {
+			after = find(customers, c.ID)
+		}
+		return c.ID.String(), before, after, err
+	})
+}
+
+func (s *auditingService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.record(ctx, "customer.delete", func(svc Service, customers Repository) (resource string, before, after interface{}, err error) {
+		before = find(customers, id)
+		return id.String(), before, nil, svc.Delete(ctx, id)
+	})
+}
+
+// record runs the call and appends its entry in one transaction, so that no change is stored
+// without its entry. Failed calls are recorded too. When the transaction fails, the change is
+// rolled back and the call is recorded as failed outside of it.
+func (s *auditingService) record(ctx context.Context, action string, call func(Service, Repository) (resource string, before, after interface{}, err error)) error {
+	var resource string
+	var before interface{}
+	var callErr error
+	err := s.transact(func(svc Service, customers Repository, entries audit.Repository) error {
+		var after interface{}
+		resource, before, after, callErr = call(svc, customers)
+		return entries.Append(audit.NewEntry(ctx, action, resource, before, after, callErr))
+	})
+	if err != nil {
+		if callErr == nil {
+			callErr = err
+		}
+		s.recorder.Record(ctx, action, resource, before, nil, callErr)
+	}
+	return callErr
+}
+
+// find returns the customer for the record, nil when there is none.
+func find(customers Repository, id uuid.UUID) *Customer {
+	c, err := customers.Find(id)
+	if err != nil {
+		return nil
+	}
+	return c
+}
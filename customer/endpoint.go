@@ -0,0 +1,113 @@
+package customer
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/errs"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints collects all of the endpoints that compose a customer service.
+type Endpoints struct {
+	NewCustomerEndpoint      endpoint.Endpoint
+	LoadCustomerEndpoint     endpoint.Endpoint
+	LoadAllCustomersEndpoint endpoint.Endpoint
+	UpdateCustomerEndpoint   endpoint.Endpoint
+	DeleteCustomerEndpoint   endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints wired to the provided service, each wrapped
+// by middlewares in the given order, the first one being the outermost.
+func MakeEndpoints(s Service, mws ...endpoint.Middleware) Endpoints {
+	wrap := func(e endpoint.Endpoint) endpoint.Endpoint {
+		for i := len(mws) - 1; i >= 0; i-- {
+			e = mws[i](e)
+		}
+		return e
+	}
+	return Endpoints{
+		NewCustomerEndpoint:      wrap(makeNewCustomerEndpoint(s)),
+		LoadCustomerEndpoint:     wrap(makeLoadCustomerEndpoint(s)),
+		LoadAllCustomersEndpoint: wrap(makeLoadAllCustomersEndpoint(s)),
+		UpdateCustomerEndpoint:   wrap(makeUpdateCustomerEndpoint(s)),
+		DeleteCustomerEndpoint:   wrap(makeDeleteCustomerEndpoint(s)),
+	}
+}
+
+// customerRequest carries customer details of create and update calls.
+type customerRequest struct {
+	ID          uuid.UUID `json:"-"`
+	Principal   string    `json:"principal" valid:"stringlength(1|255)"`
+	Name        string    `json:"name" valid:"required,stringlength(1|255)"`
+	Email       string    `json:"email" valid:"email,required"`
+	Phone       string    `json:"phone" valid:"numeric,stringlength(5|32)"`
+	Address     string    `json:"address" valid:"stringlength(1|255)"`
+	Country     string    `json:"country" valid:"ISO3166Alpha2,required"`
+	DateOfBirth string    `json:"date_of_birth" valid:"required"`
+	KYCStatus   KYCStatus `json:"kyc_status" valid:"in(pending|verified|rejected)"`
+}
+
+func (r customerRequest) customer() Customer {
+	return Customer{
+		ID:          r.ID,
+		Principal:   r.Principal,
+		Name:        r.Name,
+		Email:       r.Email,
+		Phone:       r.Phone,
+		Address:     r.Address,
+		Country:     r.Country,
+		DateOfBirth: r.DateOfBirth,
+		KYCStatus:   r.KYCStatus,
+	}
+}
+
+type customerResponse struct {
+	Customer *Customer `json:"customer,omitempty"`
+	Err      error     `json:"error,omitempty"`
+}
+
+func (r customerResponse) ErrError() error { return r.Err }
+
+func makeNewCustomerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(customerRequest)
+		c, err := s.New(ctx, req.customer())
+		return customerResponse{Customer: c, Err: err}, nil
+	}
+}
+
+type idRequest struct {
+	ID uuid.UUID
+}
+
+func makeLoadCustomerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		c, err := s.Load(ctx, req.ID)
+		return customerResponse{Customer: c, Err: err}, nil
+	}
+}
+
+func makeLoadAllCustomersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return s.LoadAll(ctx), nil
+	}
+}
+
+func makeUpdateCustomerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(customerRequest)
+		err := s.Update(ctx, req.customer())
+		return errs.ErrorOnlyResponse{Err: err}, nil
+	}
+}
+
+func makeDeleteCustomerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		err := s.Delete(ctx, req.ID)
+		return errs.ErrorOnlyResponse{Err: err}, nil
+	}
+}
@@ -0,0 +1,66 @@
+package customer
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/requestid"
+)
+
+type loggingService struct {
+	logger log.Logger
+	Service
+}
+
+// NewLoggingService returns a new instance of a logging Service. Every call is logged
+// with the request id and the principal found in the context. Personal data is never logged.
+func NewLoggingService(logger log.Logger, s Service) Service {
+	return &loggingService{logger, s}
+}
+
+func (s *loggingService) log(ctx context.Context, keyvals ...interface{}) {
+	p, _ := auth.FromContext(ctx)
+	_ = log.With(s.logger, "request_id", requestid.FromContext(ctx), "principal", p.ID).Log(keyvals...)
+}
+
+func (s *loggingService) New(ctx context.Context, c Customer) (created *Customer, err error) {
+	defer func(begin time.Time) {
+		var id uuid.UUID
+		if created != nil {
+			id = created.ID
+		}
+		s.log(ctx, "method", "new", "customer_id", id, "country", c.Country, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.New(ctx, c)
+}
+
+func (s *loggingService) Load(ctx context.Context, id uuid.UUID) (c *Customer, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "load", "customer_id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Load(ctx, id)
+}
+
+func (s *loggingService) LoadAll(ctx context.Context) (customers []*Customer) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "load_all", "count", len(customers), "took", time.Since(begin))
+	}(time.Now())
+	return s.Service.LoadAll(ctx)
+}
+
+func (s *loggingService) Update(ctx context.Context, c Customer) (err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "update", "customer_id", c.ID, "kyc_status", c.KYCStatus, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Update(ctx, c)
+}
+
+func (s *loggingService) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "delete", "customer_id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Delete(ctx, id)
+}
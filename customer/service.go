@@ -0,0 +1,213 @@
+// Package customer provides handlers for work with customers owning accounts in the system.
+package customer
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+)
+
+// KYCStatus is a state of know-your-customer verification.
+type KYCStatus string
+
+const (
+	KYCPending  KYCStatus = "pending"
+	KYCVerified KYCStatus = "verified"
+	KYCRejected KYCStatus = "rejected"
+)
+
+// dateLayout is the format of dates of birth.
+const dateLayout = "2006-01-02"
+
+// Customer is a person holding accounts in the system.
+// Principal, when set, is the login acting on behalf of the customer.
+type Customer struct {
+	ID          uuid.UUID `json:"id" sql:"id,pk,type:varchar(36)"`
+	Principal   string    `json:"principal,omitempty" sql:"principal,type:varchar(255)"`
+	Name        string    `json:"name" sql:"name,notnull,type:varchar(255)"`
+	Email       string    `json:"email" sql:"email,notnull,type:varchar(255)"`
+	Phone       string    `json:"phone,omitempty" sql:"phone,type:varchar(32)"`
+	Address     string    `json:"address,omitempty" sql:"address,type:varchar(255)"`
+	Country     string    `json:"country" sql:"country,notnull,type:varchar(2)"`
+	DateOfBirth string    `json:"date_of_birth" sql:"date_of_birth,notnull,type:varchar(10)"`
+	KYCStatus   KYCStatus `json:"kyc_status" sql:"kyc_status,notnull,type:varchar(16)"`
+	CreatedAt   time.Time `json:"created_at" sql:"created_at,notnull"`
+	UpdatedAt   time.Time `json:"updated_at" sql:"updated_at,notnull"`
+	Deleted     bool      `json:"-" sql:"deleted,notnull"`
+}
+
+// Verified reports whether the customer passed KYC and may hold accounts.
+func (c *Customer) Verified() bool {
+	return c.KYCStatus == KYCVerified
+}
+
+// Service is the interface that provides customer methods.
+// Every method acts on behalf of the principal authenticated in the context.
+type Service interface {
+	// New registers a new customer pending KYC verification.
+	// Customers registering themselves become their principal.
+	New(ctx context.Context, c Customer) (*Customer, error)
+
+	// Load returns a customer with specified id.
+	Load(ctx context.Context, id uuid.UUID) (*Customer, error)
+
+	// LoadAll returns all customers, which the caller may read.
+	LoadAll(ctx context.Context) []*Customer
+
+	// Update replaces customer details. KYC status is changed only by admins and operators, and goes
+	// back to pending whenever the identity of the customer changes. Principal is kept unless given.
+	Update(ctx context.Context, c Customer) error
+
+	// Delete marks customer as deleted.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type service struct {
+	customers Repository
+}
+
+// New registers a new customer pending KYC verification.
+func (s *service) New(ctx context.Context, c Customer) (*Customer, error) {
+	p, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator, auth.RoleCustomer)
+	if err != nil {
+		return nil, err
+	}
+	if !p.HasRole(auth.RoleAdmin, auth.RoleOperator) {
+		c.Principal = p.ID
+	}
+	if err := validateDetails(&c); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	c.ID = uuid.New()
+	c.KYCStatus = KYCPending
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	if err := s.customers.Store(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Load returns a customer with specified id.
+func (s *service) Load(ctx context.Context, id uuid.UUID) (*Customer, error) {
+	p, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor, auth.RoleCustomer)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.customers.Find(id)
+	if err != nil {
+		return nil, err
+	}
+	if !p.HasRole(auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor) && c.Principal != p.ID {
+		return nil, errs.ErrForbidden
+	}
+	return c, nil
+}
+
+// LoadAll returns all customers, which the caller may read.
+func (s *service) LoadAll(ctx context.Context) []*Customer {
+	p, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor, auth.RoleCustomer)
+	if err != nil {
+		return nil
+	}
+	if p.HasRole(auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor) {
+		return s.customers.FindAll()
+	}
+	return s.customers.FindByPrincipal(p.ID)
+}
+
+// Update replaces customer details.
+func (s *service) Update(ctx context.Context, c Customer) error {
+	p, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator, auth.RoleCustomer)
+	if err != nil {
+		return err
+	}
+	current, err := s.customers.Find(c.ID)
+	if err != nil {
+		return err
+	}
+	if !p.HasRole(auth.RoleAdmin, auth.RoleOperator) {
+		if current.Principal != p.ID || (c.KYCStatus != "" && c.KYCStatus != current.KYCStatus) {
+			return errs.ErrForbidden
+		}
+		c.Principal = current.Principal
+	}
+	if c.Principal == "" {
+		c.Principal = current.Principal
+	}
+	if c.KYCStatus == "" {
+		c.KYCStatus = current.KYCStatus
+	}
+	// Verification holds for the identity it was done for only.
+	if identityChanged(current, &c) {
+		c.KYCStatus = KYCPending
+	}
+	if err := validateDetails(&c); err != nil {
+		return err
+	}
+	c.CreatedAt = current.CreatedAt
+	c.UpdatedAt = time.Now().UTC()
+	return s.customers.Update(&c)
+}
+
+// Delete marks customer as deleted.
+func (s *service) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+	return s.customers.MarkDeleted(id)
+}
+
+// identityChanged reports whether details the customer is known and screened by differ.
+func identityChanged(current, c *Customer) bool {
+	return c.Name != current.Name ||
+		c.DateOfBirth != current.DateOfBirth ||
+		c.Country != current.Country ||
+		c.Address != current.Address
+}
+
+// validateDetails checks customer details which can not be expressed by field tags.
+func validateDetails(c *Customer) error {
+	switch c.KYCStatus {
+	case "", KYCPending, KYCVerified, KYCRejected:
+	default:
+		return errs.ErrInvalidArgument
+	}
+	born, err := time.Parse(dateLayout, c.DateOfBirth)
+	if err != nil || !born.Before(time.Now()) {
+		return errs.ErrInvalidArgument
+	}
+	return nil
+}
+
+// NewService creates a customer service with necessary dependencies.
+func NewService(customers Repository) Service {
+	return &service{
+		customers: customers,
+	}
+}
+
+// Repository interface for customers storing and operations.
+type Repository interface {
+	// Store a new customer in the repository
+	Store(c *Customer) error
+
+	// Find customer in the repository with specified id
+	Find(id uuid.UUID) (*Customer, error)
+
+	// FindAll returns all customers registered in the system
+	FindAll() []*Customer
+
+	// FindByPrincipal returns customers the principal acts on behalf of
+	FindByPrincipal(principal string) []*Customer
+
+	// Update stores changed customer details
+	Update(c *Customer) error
+
+	// MarkDeleted is mark as deleted specified customer in the system
+	MarkDeleted(id uuid.UUID) error
+}
@@ -0,0 +1,118 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+)
+
+// customers is a Repository keeping customers in memory.
+type customers map[uuid.UUID]*Customer
+
+func (r customers) Store(c *Customer) error { r[c.ID] = c; return nil }
+
+func (r customers) Find(id uuid.UUID) (*Customer, error) {
+	c, ok := r[id]
+	if !ok {
+		return nil, errs.ErrUnknownCustomer
+	}
+	found := *c
+	return &found, nil
+}
+
+func (r customers) FindAll() []*Customer { return nil }
+
+func (r customers) FindByPrincipal(principal string) []*Customer { return nil }
+
+func (r customers) Update(c *Customer) error { r[c.ID] = c; return nil }
+
+func (r customers) MarkDeleted(id uuid.UUID) error { return nil }
+
+func as(id string, roles ...auth.Role) context.Context {
+	return auth.NewContext(context.Background(), auth.Principal{ID: id, Roles: roles})
+}
+
+func verified() *Customer {
+	return &Customer{
+		ID:          uuid.New(),
+		Principal:   "alice",
+		Name:        "Alice Smith",
+		Email:       "alice@example.com",
+		Address:     "1 Main St",
+		Country:     "US",
+		DateOfBirth: "1980-04-01",
+		KYCStatus:   KYCVerified,
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		modify        func(*Customer)
+		want          error
+		wantKYC       KYCStatus
+		wantPrincipal string
+	}{
+		{"customer changing email", as("alice", auth.RoleCustomer), func(c *Customer) { c.Email = "new@example.com" }, nil, KYCVerified, "alice"},
+		{"customer changing name", as("alice", auth.RoleCustomer), func(c *Customer) { c.Name = "Mallory" }, nil, KYCPending, "alice"},
+		{"customer changing date of birth", as("alice", auth.RoleCustomer), func(c *Customer) { c.DateOfBirth = "1990-01-01" }, nil, KYCPending, "alice"},
+		{"customer changing country", as("alice", auth.RoleCustomer), func(c *Customer) { c.Country = "IR" }, nil, KYCPending, "alice"},
+		{"customer changing address", as("alice", auth.RoleCustomer), func(c *Customer) { c.Address = "2 Main St" }, nil, KYCPending, "alice"},
+		{"customer changing name keeping status", as("alice", auth.RoleCustomer), func(c *Customer) { c.Name = "Mallory"; c.KYCStatus = KYCVerified }, nil, KYCPending, "alice"},
+		{"customer verifying", as("alice", auth.RoleCustomer), func(c *Customer) { c.KYCStatus = KYCRejected }, errs.ErrForbidden, "", ""},
+		{"customer taking principal", as("alice", auth.RoleCustomer), func(c *Customer) { c.Principal = "bob" }, nil, KYCVerified, "alice"},
+		{"another customer", as("bob", auth.RoleCustomer), func(c *Customer) {}, errs.ErrForbidden, "", ""},
+		{"operator leaving principal out", as("op", auth.RoleOperator), func(c *Customer) { c.Principal = "" }, nil, KYCVerified, "alice"},
+		{"operator linking principal", as("op", auth.RoleOperator), func(c *Customer) { c.Principal = "bob" }, nil, KYCVerified, "bob"},
+		{"operator rejecting", as("op", auth.RoleOperator), func(c *Customer) { c.KYCStatus = KYCRejected }, nil, KYCRejected, "alice"},
+		{"operator changing name", as("op", auth.RoleOperator), func(c *Customer) { c.Name = "Alice Jones" }, nil, KYCPending, "alice"},
+		{"auditor", as("aud", auth.RoleAuditor), func(c *Customer) {}, errs.ErrForbidden, "", ""},
+	}
+	for _, tt := range tests {
+		current := verified()
+		repo := customers{current.ID: current}
+		s := NewService(repo)
+
+		c := *current
+		c.KYCStatus = ""
+		tt.modify(&c)
+		err := s.Update(tt.ctx, c)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		got := repo[current.ID]
+		if got.KYCStatus != tt.wantKYC {
+			t.Errorf("%s: kyc status %q, want %q", tt.name, got.KYCStatus, tt.wantKYC)
+		}
+		if got.Principal != tt.wantPrincipal {
+			t.Errorf("%s: principal %q, want %q", tt.name, got.Principal, tt.wantPrincipal)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	repo := customers{}
+	s := NewService(repo)
+	c := *verified()
+	c.Principal = "bob"
+	created, err := s.New(as("alice", auth.RoleCustomer), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Principal != "alice" || created.KYCStatus != KYCPending || created.ID == c.ID {
+		t.Errorf("got %+v", created)
+	}
+	c.DateOfBirth = "2999-01-01"
+	if _, err := s.New(as("op", auth.RoleOperator), c); !errors.Is(err, errs.ErrInvalidArgument) {
+		t.Errorf("born in the future: got %v", err)
+	}
+}
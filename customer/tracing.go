@@ -0,0 +1,49 @@
+package customer
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type tracingService struct {
+	Service
+}
+
+// NewTracingService returns an instance of a tracing Service. Every call runs in a span
+// named after the method.
+func NewTracingService(s Service) Service {
+	return &tracingService{s}
+}
+
+func (s *tracingService) New(ctx context.Context, c Customer) (created *Customer, err error) {
+	ctx, span := tracing.Start(ctx, "customer.New")
+	defer func() { tracing.End(span, err) }()
+	return s.Service.New(ctx, c)
+}
+
+func (s *tracingService) Load(ctx context.Context, id uuid.UUID) (c *Customer, err error) {
+	ctx, span := tracing.Start(ctx, "customer.Load", attribute.String("customer.id", id.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Load(ctx, id)
+}
+
+func (s *tracingService) LoadAll(ctx context.Context) []*Customer {
+	ctx, span := tracing.Start(ctx, "customer.LoadAll")
+	defer span.End()
+	return s.Service.LoadAll(ctx)
+}
+
+func (s *tracingService) Update(ctx context.Context, c Customer) (err error) {
+	ctx, span := tracing.Start(ctx, "customer.Update", attribute.String("customer.id", c.ID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Update(ctx, c)
+}
+
+func (s *tracingService) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "customer.Delete", attribute.String("customer.id", id.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Delete(ctx, id)
+}
@@ -0,0 +1,124 @@
+package customer
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/openapi"
+	"github.com/ilyareist/task1/tracing"
+	"github.com/ilyareist/task1/validate"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a handler for the customer service endpoints.
+func MakeHandler(eps Endpoints, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(errs.EncodeError),
+		kithttp.ServerBefore(tracing.HTTPToContext, auth.HTTPToContext),
+		kithttp.ServerFinalizer(tracing.HTTPFinalizer),
+	}
+
+	newCustomerHandler := kithttp.NewServer(
+		eps.NewCustomerEndpoint,
+		decodeNewCustomerRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	loadCustomerHandler := kithttp.NewServer(
+		eps.LoadCustomerEndpoint,
+		decodeIDRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	loadAllCustomersHandler := kithttp.NewServer(
+		eps.LoadAllCustomersEndpoint,
+		decodeEmptyRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	updateCustomerHandler := kithttp.NewServer(
+		eps.UpdateCustomerEndpoint,
+		decodeUpdateCustomerRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	deleteCustomerHandler := kithttp.NewServer(
+		eps.DeleteCustomerEndpoint,
+		decodeIDRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	router := mux.NewRouter()
+
+	router.Handle("/api/customers/v1/customers", newCustomerHandler).Methods("POST")
+	router.Handle("/api/customers/v1/customers", loadAllCustomersHandler).Methods("GET")
+	router.Handle("/api/customers/v1/customers/{id}", loadCustomerHandler).Methods("GET")
+	router.Handle("/api/customers/v1/customers/{id}", updateCustomerHandler).Methods("PUT")
+	router.Handle("/api/customers/v1/customers/{id}", deleteCustomerHandler).Methods("DELETE")
+
+	return router
+}
+
+// Operations describe the routes of MakeHandler for the OpenAPI specification.
+func Operations() []openapi.Operation {
+	const tag = "customers"
+	return []openapi.Operation{
+		{Method: "POST", Path: "/api/customers/v1/customers", Tag: tag, Summary: "Register a customer", Request: customerRequest{}, Response: customerResponse{}},
+		{Method: "GET", Path: "/api/customers/v1/customers", Tag: tag, Summary: "List customers", Response: []*Customer{}},
+		{Method: "GET", Path: "/api/customers/v1/customers/{id}", Tag: tag, Summary: "Load a customer", Response: customerResponse{}},
+		{Method: "PUT", Path: "/api/customers/v1/customers/{id}", Tag: tag, Summary: "Update a customer", Request: customerRequest{}},
+		{Method: "DELETE", Path: "/api/customers/v1/customers/{id}", Tag: tag, Summary: "Delete a customer"},
+	}
+}
+
+func decodeNewCustomerRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var body customerRequest
+	if err := validate.DecodeJSON(r, &body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func decodeUpdateCustomerRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeIDRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	body, err := decodeNewCustomerRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	c := body.(customerRequest)
+	c.ID = req.(idRequest).ID
+	return c, nil
+}
+
+func decodeEmptyRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errs.ErrInvalidArgument
+	}
+	return idRequest{ID: uid}, nil
+}
@@ -4,11 +4,13 @@
 
 CREATE TABLE public.accounts (
     id character varying(255) NOT NULL,
+    customer_id character varying(36),
     country character varying(50) NOT NULL,
     city character varying(50) NOT NULL,
     balance numeric(16,4) NOT NULL,
     currency character varying(3) NOT NULL,
-    deleted boolean NOT NULL
+    deleted boolean NOT NULL,
+    frozen boolean NOT NULL DEFAULT false
 );
 
 
@@ -27,12 +29,15 @@ ALTER TABLE ONLY public.accounts
 --
 CREATE TABLE public.payments (
     id character varying(36) NOT NULL,
+    group_id character varying(36),
     account character varying(255),
     amount numeric(16,4) NOT NULL,
     to_account character varying(255),
     from_account character varying(255),
     direction character varying(16) NOT NULL,
-    deleted boolean NOT NULL
+    deleted boolean NOT NULL,
+    created_at timestamp with time zone,
+    reversal_of character varying(36)
 );
 
 
@@ -66,5 +71,117 @@ SELECT A.id,
        A.country,
        A.city,
        A.currency,
-       A.deleted
+       A.deleted,
+       A.customer_id,
+       A.frozen
 FROM accounts AS A;
+
+
+CREATE TABLE public.account_owners (
+    account_id character varying(255) NOT NULL,
+    principal character varying(255) NOT NULL,
+    PRIMARY KEY (account_id, principal)
+);
+
+
+CREATE TABLE public.customers (
+    id character varying(36) NOT NULL PRIMARY KEY,
+    principal character varying(255),
+    name character varying(255) NOT NULL,
+    email character varying(255) NOT NULL,
+    phone character varying(32),
+    address character varying(255),
+    country character varying(2) NOT NULL,
+    date_of_birth character varying(10) NOT NULL,
+    kyc_status character varying(16) NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    deleted boolean NOT NULL
+);
+
+
+CREATE TABLE public.payment_approvals (
+    id character varying(36) NOT NULL PRIMARY KEY,
+    kind character varying(16) NOT NULL,
+    from_account character varying(255) NOT NULL,
+    to_account character varying(255),
+    legs jsonb,
+    amount numeric(16,4) NOT NULL,
+    debit numeric(16,4) NOT NULL,
+    currency character varying(3) NOT NULL,
+    status character varying(16) NOT NULL,
+    maker character varying(255) NOT NULL,
+    checker character varying(255),
+    reason text,
+    findings jsonb,
+    created_at timestamp with time zone NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    decided_at timestamp with time zone
+);
+
+
+CREATE TABLE public.screenings (
+    id character varying(36) NOT NULL PRIMARY KEY,
+    reference character varying(36) NOT NULL,
+    kind character varying(16) NOT NULL,
+    account character varying(255) NOT NULL,
+    counterparties text[],
+    amount numeric(16,4) NOT NULL,
+    currency character varying(3) NOT NULL,
+    decision character varying(16) NOT NULL,
+    findings jsonb,
+    principal character varying(255),
+    created_at timestamp with time zone NOT NULL
+);
+
+
+CREATE TABLE public.rates (
+    currency character varying(255) NOT NULL,
+    date character varying(255) NOT NULL,
+    rate double precision NOT NULL,
+    PRIMARY KEY (currency, date)
+);
+
+
+CREATE TABLE public.balance_snapshots (
+    account_id character varying(255) NOT NULL,
+    at timestamp with time zone NOT NULL,
+    balance numeric(16,4) NOT NULL,
+    PRIMARY KEY (account_id, at)
+);
+
+CREATE INDEX payments_account_created_at ON public.payments (account, created_at);
+
+
+CREATE TABLE public.rate_limits (
+    key character varying(255) NOT NULL PRIMARY KEY,
+    tokens double precision NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+
+CREATE TABLE public.audit_log (
+    seq bigint NOT NULL PRIMARY KEY,
+    "time" timestamp with time zone NOT NULL,
+    actor character varying(255),
+    action character varying(64) NOT NULL,
+    resource character varying(255),
+    before text,
+    after text,
+    error text,
+    request_id character varying(255),
+    prev_hash character varying(64) NOT NULL,
+    hash character varying(64) NOT NULL
+);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON public.audit_log
+    FOR EACH ROW EXECUTE PROCEDURE audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON public.audit_log
+    FOR EACH STATEMENT EXECUTE PROCEDURE audit_log_append_only();
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-pg/pg"
+	"github.com/ilyareist/task1/activity"
+)
+
+// activityChannel is the PostgreSQL notification channel carrying account activity between instances.
+const activityChannel = "account_activity"
+
+// ActivityBridge publishes activity events through PostgreSQL NOTIFY and feeds events
+// received by LISTEN into a local broker, so that every instance streams every change.
+// Brokers number events as they receive them, so ids of one stream are not those of another
+// instance.
+type ActivityBridge struct {
+	conn   *pg.DB
+	broker *activity.Broker
+	logger kitlog.Logger
+}
+
+// NewActivityBridge returns a bridge between PostgreSQL notifications and the local broker.
+func NewActivityBridge(conn *pg.DB, broker *activity.Broker, logger kitlog.Logger) *ActivityBridge {
+	return &ActivityBridge{
+		conn:   conn,
+		broker: broker,
+		logger: logger,
+	}
+}
+
+// Publish sends the event to all instances, this one included. If notification fails the
+// event is delivered to local subscribers only.
+func (b *ActivityBridge) Publish(e activity.Event) {
+	payload, err := json.Marshal(e)
+	if err == nil {
+		_, err = b.conn.Exec("SELECT pg_notify(?, ?)", activityChannel, string(payload))
+	}
+	if err != nil {
+		_ = b.logger.Log("msg", "notify activity", "error", err)
+		b.broker.Publish(e)
+	}
+}
+
+// Run listens for notifications until the context is cancelled.
+func (b *ActivityBridge) Run(ctx context.Context) {
+	ln := b.conn.Listen(activityChannel)
+	defer func() { _ = ln.Close() }()
+
+	ch := ln.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-ch:
+			if !ok {
+				return
+			}
+			var e activity.Event
+			if err := json.Unmarshal([]byte(n.Payload), &e); err != nil {
+				_ = b.logger.Log("msg", "decode activity", "error", err)
+				continue
+			}
+			b.broker.Publish(e)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/ilyareist/task1/activity"
+)
+
+func TestActivityBridge(t *testing.T) {
+	conn, done := testDB(t)
+	defer done()
+
+	// Two instances, each with a broker of its own, share one database.
+	local, remote := activity.NewBroker(10), activity.NewBroker(10)
+	publisher := NewActivityBridge(conn, local, log.NewNopLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go publisher.Run(ctx)
+	go NewActivityBridge(conn, remote, log.NewNopLogger()).Run(ctx)
+
+	events, _, unsubscribe := remote.Subscribe("alice", 0)
+	defer unsubscribe()
+
+	// Listening starts in the background: events are published until one arrives.
+	e, err := activity.NewEvent("alice", activity.PaymentType, map[string]string{"amount": "10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tick := time.NewTicker(50 * time.Millisecond)
+	defer tick.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		publisher.Publish(e)
+		select {
+		case got := <-events:
+			if got.Account != "alice" || got.Type != activity.PaymentType || got.ID == 0 || string(got.Data) != `{"amount":"10"}` {
+				t.Errorf("got %+v", got)
+			}
+			return
+		case <-timeout:
+			t.Fatal("no event arrived")
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/ilyareist/task1/audit"
+)
+
+// auditTriggers make the audit log append-only for every client, not only for this application.
+const auditTriggers = `
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE PROCEDURE audit_log_append_only();
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE PROCEDURE audit_log_append_only();
+`
+
+type auditRepository struct {
+	conn querier
+}
+
+// Append seals the entry after the last one and stores it. The table is locked against
+// concurrent appends until the transaction ends, so that the chain never forks.
+func (r *auditRepository) Append(e *audit.Entry) error {
+	return r.conn.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("LOCK TABLE audit_log IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+		last := new(audit.Entry)
+		err := tx.Model(last).Order("seq DESC").Limit(1).Select()
+		if err == pg.ErrNoRows {
+			last = nil
+		} else if err != nil {
+			return err
+		}
+		e.Seal(last)
+		return tx.Insert(e)
+	})
+}
+
+// Find returns entries matching the filter in sequence order.
+func (r *auditRepository) Find(f audit.Filter) ([]*audit.Entry, error) {
+	var entries []*audit.Entry
+	q := r.conn.Model(&entries).Order("seq")
+	if f.Actor != "" {
+		q = q.Where("actor = ?", f.Actor)
+	}
+	if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
+	}
+	if f.Resource != "" {
+		q = q.Where("resource = ?", f.Resource)
+	}
+	if !f.Since.IsZero() {
+		q = q.Where("time >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		q = q.Where("time < ?", f.Until)
+	}
+	if f.After > 0 {
+		q = q.Where("seq > ?", f.After)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	if err := q.Select(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// NewAuditRepository returns a new instance of a PostgreSQL audit log.
+func NewAuditRepository(conn *pg.DB) audit.Repository {
+	return &auditRepository{
+		conn: conn,
+	}
+}
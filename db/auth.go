@@ -0,0 +1,36 @@
+package db
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+)
+
+type keyRepository struct {
+	conn querier
+}
+
+// StoreKey stores a new API key.
+func (r *keyRepository) StoreKey(key *auth.APIKey) error {
+	return r.conn.Insert(key)
+}
+
+// FindKey returns an API key with specified id.
+func (r *keyRepository) FindKey(id string) (*auth.APIKey, error) {
+	k := &auth.APIKey{ID: id}
+	err := r.conn.Select(k)
+	if err == pg.ErrNoRows {
+		return nil, errs.ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// NewKeyRepository returns a new instance of a PostgreSQL API key repository.
+func NewKeyRepository(conn *pg.DB) auth.KeyRepository {
+	return &keyRepository{
+		conn: conn,
+	}
+}
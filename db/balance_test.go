@@ -0,0 +1,212 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/payment"
+	"github.com/shopspring/decimal"
+)
+
+// testDB connects to the PostgreSQL database of TEST_DATABASE_URL, in a schema of its own made
+// for the test, and returns the connection and a function dropping the schema. Tests are
+// skipped when TEST_DATABASE_URL is not set.
+func testDB(t *testing.T) (*pg.DB, func()) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	opts, err := pg.ParseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	admin := pg.Connect(opts)
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		t.Fatal(err)
+	}
+	drop := func() {
+		_, _ = admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	}
+
+	opts.OnConnect = func(conn *pg.Conn) error {
+		_, err := conn.Exec("SET search_path = " + schema)
+		return err
+	}
+	conn := pg.Connect(opts)
+	if err := CreateSchema(conn); err != nil {
+		conn.Close()
+		drop()
+		t.Fatal(err)
+	}
+	return conn, func() {
+		conn.Close()
+		drop()
+	}
+}
+
+func at(day, hour int) time.Time { return time.Date(2019, 10, day, hour, 0, 0, 0, time.UTC) }
+
+func amount(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+// ledger stores an account opened on October 1st with 100, credited 50 on the 2nd, debited 30
+// on the 3rd and credited 5 on the 5th, at 10:00 each.
+func ledger(t *testing.T, conn *pg.DB) account.ID {
+	id := account.ID("acc")
+	if err := conn.Insert(&account.Account{ID: id, Country: "US", City: "Austin", Currency: account.CurrencyUSD, Balance: amount("100"), CreatedAt: at(1, 12)}); err != nil {
+		t.Fatal(err)
+	}
+	legs := []struct {
+		direction payment.Direction
+		amount    string
+		day       int
+	}{
+		{payment.Incoming, "50", 2},
+		{payment.Outgoing, "30", 3},
+		{payment.Incoming, "5", 5},
+	}
+	for _, l := range legs {
+		p := &payment.Payment{ID: uuid.New(), Group: uuid.New(), Account: id, Direction: l.direction, Amount: amount(l.amount), CreatedAt: at(l.day, 10)}
+		if err := conn.Insert(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return id
+}
+
+func TestFindBalance(t *testing.T) {
+	conn, done := testDB(t)
+	defer done()
+	id := ledger(t, conn)
+	r := &accountRepository{conn: conn}
+
+	check := func(name string, moment time.Time, want string) {
+		got, err := r.FindBalance(id, moment)
+		if err != nil || !got.Equal(amount(want)) {
+			t.Errorf("%s: balance at %v is %v, %v, want %s", name, moment, got, err, want)
+		}
+	}
+	check("initial", at(2, 9), "100")
+	check("payment at the moment", at(2, 10), "100")
+	check("after payments", at(3, 11), "120")
+	check("now", at(6, 0), "125")
+	if _, err := r.FindBalance("missing", at(6, 0)); err == nil {
+		t.Error("balance of a missing account")
+	}
+
+	// Balances after a snapshot are worked out of it: made up snapshots show in them.
+	if n, err := r.StoreSnapshots(at(3, 0)); n != 1 || err != nil {
+		t.Fatalf("took %d snapshots, %v", n, err)
+	}
+	if _, err := conn.Exec("UPDATE balance_snapshots SET balance = 1000"); err != nil {
+		t.Fatal(err)
+	}
+	check("after the snapshot", at(4, 0), "970")
+	check("at the snapshot", at(3, 0), "1000")
+	check("before the snapshot", at(2, 23), "150")
+}
+
+func TestStoreSnapshots(t *testing.T) {
+	conn, done := testDB(t)
+	defer done()
+	id := ledger(t, conn)
+	r := &accountRepository{conn: conn}
+
+	steps := []struct {
+		name   string
+		moment time.Time
+		want   int
+	}{
+		{"first", at(3, 0), 1},
+		{"taken already", at(3, 0), 0},
+		{"payments since", at(4, 0), 1},
+		{"no payments since", at(5, 0), 0},
+		{"earlier than the last", at(2, 0), 1},
+	}
+	for _, s := range steps {
+		if n, err := r.StoreSnapshots(s.moment); n != s.want || err != nil {
+			t.Errorf("%s: took %d snapshots, %v, want %d", s.name, n, err, s.want)
+		}
+	}
+
+	// Instances taking the same snapshots at once take each once.
+	var wg sync.WaitGroup
+	taken := make([]int, 4)
+	errs := make([]error, len(taken))
+	for i := range taken {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			taken[i], errs[i] = r.StoreSnapshots(at(6, 0))
+		}(i)
+	}
+	wg.Wait()
+	total := 0
+	for i, n := range taken {
+		if errs[i] != nil {
+			t.Errorf("concurrent snapshots: %v", errs[i])
+		}
+		total += n
+	}
+	if total != 1 {
+		t.Errorf("concurrent snapshots: took %d", total)
+	}
+
+	var snaps []account.Snapshot
+	if err := conn.Model(&snaps).Where("account_id = ?", id).Order("at").Select(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[time.Time]string{at(2, 0): "100", at(3, 0): "150", at(4, 0): "120", at(6, 0): "125"}
+	if len(snaps) != len(want) {
+		t.Fatalf("got %d snapshots, want %d", len(snaps), len(want))
+	}
+	for _, s := range snaps {
+		if b, ok := want[s.At.UTC()]; !ok || !s.Balance.Equal(amount(b)) {
+			t.Errorf("snapshot as of %v is %v, want %s", s.At, s.Balance, b)
+		}
+	}
+}
+
+func TestFindDailyBalances(t *testing.T) {
+	conn, done := testDB(t)
+	defer done()
+	id := ledger(t, conn)
+	r := &accountRepository{conn: conn}
+	if _, err := r.StoreSnapshots(at(3, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to time.Time
+		want     []string
+	}{
+		{at(1, 0), at(6, 0), []string{"100", "150", "120", "120", "125", "125"}},
+		{at(3, 0), at(3, 0), []string{"120"}},
+		{at(4, 0), at(5, 0), []string{"120", "125"}},
+	}
+	for _, tt := range tests {
+		got, err := r.FindDailyBalances(id, tt.from, tt.to)
+		if err != nil {
+			t.Errorf("%v to %v: %v", tt.from, tt.to, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%v to %v: got %v, want %v", tt.from, tt.to, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].Equal(amount(tt.want[i])) {
+				t.Errorf("%v to %v: got %v, want %v", tt.from, tt.to, got, tt.want)
+				break
+			}
+		}
+	}
+}
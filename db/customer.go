@@ -0,0 +1,92 @@
+package db
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/customer"
+	"github.com/ilyareist/task1/errs"
+)
+
+type customerRepository struct {
+	conn querier
+}
+
+// Store a new customer in the repository
+func (r *customerRepository) Store(c *customer.Customer) error {
+	return r.conn.Insert(c)
+}
+
+// Find customer in the repository with specified id
+func (r *customerRepository) Find(id uuid.UUID) (*customer.Customer, error) {
+	c := &customer.Customer{ID: id}
+	err := r.conn.Select(c)
+	if err == pg.ErrNoRows {
+		return nil, errs.ErrUnknownCustomer
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Deleted {
+		return nil, errs.ErrUnknownCustomer
+	}
+	return c, nil
+}
+
+// FindAll returns all customers registered in the system
+func (r *customerRepository) FindAll() []*customer.Customer {
+	var customers []*customer.Customer
+	err := r.conn.Model(&customers).Where("deleted = ?", false).Order("created_at").Select()
+	if err != nil {
+		return nil
+	}
+	return customers
+}
+
+// FindByPrincipal returns customers the principal acts on behalf of
+func (r *customerRepository) FindByPrincipal(principal string) []*customer.Customer {
+	var customers []*customer.Customer
+	err := r.conn.Model(&customers).
+		Where("deleted = ?", false).
+		Where("principal = ?", principal).
+		Order("created_at").
+		Select()
+	if err != nil {
+		return nil
+	}
+	return customers
+}
+
+// Update stores changed customer details
+func (r *customerRepository) Update(c *customer.Customer) error {
+	res, err := r.conn.Model(c).WherePK().Where("deleted = ?", false).Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return errs.ErrUnknownCustomer
+	}
+	return nil
+}
+
+// MarkDeleted is mark as deleted specified customer in the system
+func (r *customerRepository) MarkDeleted(id uuid.UUID) error {
+	res, err := r.conn.Model((*customer.Customer)(nil)).
+		Set("deleted = ?", true).
+		Where("id = ?", id).
+		Where("deleted = ?", false).
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return errs.ErrUnknownCustomer
+	}
+	return nil
+}
+
+// NewCustomerRepository returns a new instance of a PostgreSQL customer repository.
+func NewCustomerRepository(conn *pg.DB) customer.Repository {
+	return &customerRepository{
+		conn: conn,
+	}
+}
@@ -2,17 +2,46 @@
 package db
 
 import (
+	"sort"
+	"time"
+
 	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/google/uuid"
 	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/audit"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/customer"
 	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/outbox"
 	"github.com/ilyareist/task1/payment"
+	"github.com/ilyareist/task1/ratelimit"
+	"github.com/ilyareist/task1/screening"
+	"github.com/shopspring/decimal"
 )
 
-// CreateSchema creating schema if its not exist. Without any migrations mechanic, just schema only.
+// models are stored in tables created by CreateSchema.
+var models = []interface{}{
+	(*account.Account)(nil),
+	(*account.Owner)(nil),
+	(*account.Snapshot)(nil),
+	(*customer.Customer)(nil),
+	(*payment.Payment)(nil),
+	(*payment.Approval)(nil),
+	(*screening.Screening)(nil),
+	(*outbox.Event)(nil),
+	(*outbox.Webhook)(nil),
+	(*outbox.Delivery)(nil),
+	(*auth.APIKey)(nil),
+	(*audit.Entry)(nil),
+	(*payment.Rate)(nil),
+	(*ratelimit.Bucket)(nil),
+}
+
+// CreateSchema creating schema if its not exist, then brings tables created by older versions
+// up to date by migrations, which may be run any number of times.
 func CreateSchema(conn *pg.DB) error {
-	for _, model := range []interface{}{(*account.Account)(nil), (*payment.Payment)(nil)} {
+	for _, model := range models {
 		err := conn.CreateTable(model, &orm.CreateTableOptions{
 			IfNotExists: true,
 		})
@@ -20,19 +49,30 @@ func CreateSchema(conn *pg.DB) error {
 			return err
 		}
 	}
-	return nil
+	if _, err := conn.Exec(migrations); err != nil {
+		return err
+	}
+	_, err := conn.Exec(auditTriggers)
+	return err
 }
 
 type accountRepository struct {
-	conn *pg.DB
+	conn querier
 }
 
-// Store account in the repository
-func (r *accountRepository) Store(account *account.Account) error {
-	if err := r.conn.Insert(account); err != nil {
-		return err
-	}
-	return nil
+// Store account in the repository, together with its owners
+func (r *accountRepository) Store(a *account.Account, owners ...string) error {
+	return r.conn.RunInTransaction(func(tx *pg.Tx) error {
+		if err := tx.Insert(a); err != nil {
+			return err
+		}
+		for _, principal := range owners {
+			if err := tx.Insert(&account.Owner{AccountID: a.ID, Principal: principal}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 
@@ -41,6 +81,9 @@ func (r *accountRepository) Store(account *account.Account) error {
 func (r *accountRepository) Find(id account.ID) (*account.Account, error) {
 	a := &account.Account{ID: id}
 	err := r.conn.Select(a)
+	if err == pg.ErrNoRows {
+		return nil, errs.ErrUnknownAccount
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -60,10 +103,36 @@ func (r *accountRepository) FindAll() []*account.Account {
 	return accounts
 }
 
-// MarkDeleted is mark as deleted specified account in the system
-func (r *accountRepository) MarkDeleted(id account.ID) error {
+// FindByCustomer returns accounts held by the customer
+func (r *accountRepository) FindByCustomer(customerID uuid.UUID) []*account.Account {
+	var accounts []*account.Account
+	err := r.conn.Model(&accounts).Where("deleted = ?", false).Where("customer_id = ?", customerID).Select()
+	if err != nil {
+		return nil
+	}
+	return accounts
+}
+
+// FindOwned returns accounts owned by the principal
+func (r *accountRepository) FindOwned(principal string) []*account.Account {
+	var accounts []*account.Account
+	err := r.conn.Model(&accounts).
+		Where("deleted = ?", false).
+		Where("id IN (SELECT account_id FROM account_owners WHERE principal = ?)", principal).
+		Select()
+	if err != nil {
+		return nil
+	}
+	return accounts
+}
+
+// MarkDeleted is mark as deleted specified account in the system, storing events in the same transaction
+func (r *accountRepository) MarkDeleted(id account.ID, events ...*outbox.Event) error {
 	a := &account.Account{ID: id}
 	err := r.conn.Select(a)
+	if err == pg.ErrNoRows {
+		return errs.ErrUnknownAccount
+	}
 	if err != nil {
 		return err
 	}
@@ -71,13 +140,66 @@ func (r *accountRepository) MarkDeleted(id account.ID) error {
 		return errs.ErrUnknownAccount
 	}
 	a.Deleted = true
-	err = r.conn.Update(a)
+	// The balance read off the view is not stored, the initial balance is kept.
+	return r.conn.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Model(a).Column("deleted").WherePK().Update(); err != nil {
+			return err
+		}
+		return insertEvents(tx, events)
+	})
+}
+
+// MarkRestored clears deleted mark of specified account
+func (r *accountRepository) MarkRestored(id account.ID) error {
+	a := &account.Account{ID: id}
+	err := r.conn.Select(a)
+	if err == pg.ErrNoRows {
+		return errs.ErrUnknownAccount
+	}
 	if err != nil {
 		return err
 	}
+	if !a.Deleted {
+		return nil
+	}
+	a.Deleted = false
+	_, err = r.conn.Model(a).Column("deleted").WherePK().Update()
+	return err
+}
+
+// MarkFrozen sets or clears frozen mark of specified account
+func (r *accountRepository) MarkFrozen(id account.ID, frozen bool) error {
+	res, err := r.conn.Model((*account.Account)(nil)).
+		Set("frozen = ?", frozen).
+		Where("id = ?", id).
+		Where("deleted = ?", false).
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return errs.ErrUnknownAccount
+	}
 	return nil
 }
 
+// StoreOwner links the principal to the account as its owner
+func (r *accountRepository) StoreOwner(id account.ID, principal string) error {
+	_, err := r.conn.Model(&account.Owner{AccountID: id, Principal: principal}).
+		OnConflict("DO NOTHING").
+		Insert()
+	return err
+}
+
+// IsOwner reports whether the principal owns the account
+func (r *accountRepository) IsOwner(id account.ID, principal string) bool {
+	ok, err := r.conn.Model((*account.Owner)(nil)).
+		Where("account_id = ?", id).
+		Where("principal = ?", principal).
+		Exists()
+	return err == nil && ok
+}
+
 // NewAccountRepository returns a new instance of a PostgreSQL account repository.
 func NewAccountRepository(conn *pg.DB) account.Repository {
 	return &accountRepository{
@@ -86,19 +208,23 @@ func NewAccountRepository(conn *pg.DB) account.Repository {
 }
 
 type paymentRepository struct {
-	conn     *pg.DB
+	conn     querier
 	accounts account.Repository
 }
 
-// Store payments in the repository.
-func (r *paymentRepository) Store(payments ...*payment.Payment) error {
+// Store payments in the repository together with outbox events describing them, provided
+// the debited accounts still hold the money.
+func (r *paymentRepository) Store(events []*outbox.Event, payments ...*payment.Payment) error {
 	err := r.conn.RunInTransaction(func(tx *pg.Tx) error {
+		if err := checkDebits(tx, payments); err != nil {
+			return err
+		}
 		for _, val := range payments {
-			if err := r.conn.Insert(val); err != nil {
+			if err := tx.Insert(val); err != nil {
 				return err
 			}
 		}
-		return nil
+		return insertEvents(tx, events)
 	})
 	if err != nil {
 		return err
@@ -142,6 +268,195 @@ func (r *paymentRepository) MarkDeleted(id uuid.UUID) error {
 	return nil
 }
 
+// FindGroup returns the legs of a payment, the outgoing ones first.
+func (r *paymentRepository) FindGroup(group uuid.UUID) ([]*payment.Payment, error) {
+	var pp []*payment.Payment
+	err := r.conn.Model(&pp).
+		Where("deleted = ?", false).
+		Where("group_id = ?", group).
+		Order("direction DESC", "account").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	if len(pp) == 0 {
+		return nil, errs.ErrUnknownPayment
+	}
+	return pp, nil
+}
+
+// FindLedger returns an account together with every payment leg its balance is made of,
+// in the order they were made. Both are read in one snapshot, so that the legs add up to the
+// balance. Deleted payments are hidden from lists but still count in the balance, so they are
+// returned too; legs stored before their time was recorded come first.
+func (r *paymentRepository) FindLedger(id account.ID) (*account.Account, []*payment.Payment, error) {
+	a := &account.Account{ID: id}
+	var pp []*payment.Payment
+	err := r.conn.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY"); err != nil {
+			return err
+		}
+		if err := tx.Select(a); err != nil {
+			return err
+		}
+		return tx.Model(&pp).
+			Where("account = ?", id).
+			OrderExpr("created_at ASC NULLS FIRST, id").
+			Select()
+	})
+	if err == pg.ErrNoRows || err == nil && a.Deleted {
+		return nil, nil, errs.ErrUnknownAccount
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, pp, nil
+}
+
+// StoreReversal stores payments reversing the payment group together with outbox events,
+// provided the group has not been reversed yet. Legs of the group are locked until the
+// transaction ends, so that concurrent reversals of one payment do not both succeed.
+func (r *paymentRepository) StoreReversal(group uuid.UUID, events []*outbox.Event, payments ...*payment.Payment) error {
+	return r.conn.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SELECT id FROM payments WHERE group_id = ? FOR UPDATE", group); err != nil {
+			return err
+		}
+		reversed, err := tx.Model((*payment.Payment)(nil)).Where("reversal_of = ?", group).Exists()
+		if err != nil {
+			return err
+		}
+		if reversed {
+			return errs.ErrPaymentReversed
+		}
+		if err := checkDebits(tx, payments); err != nil {
+			return err
+		}
+		for _, val := range payments {
+			if err := tx.Insert(val); err != nil {
+				return err
+			}
+		}
+		return insertEvents(tx, events)
+	})
+}
+
+// StoreApproval stores a new payment approval.
+func (r *paymentRepository) StoreApproval(a *payment.Approval) error {
+	return r.conn.Insert(a)
+}
+
+// FindApproval returns a payment approval with specified id.
+func (r *paymentRepository) FindApproval(id uuid.UUID) (*payment.Approval, error) {
+	a := &payment.Approval{ID: id}
+	err := r.conn.Select(a)
+	if err == pg.ErrNoRows {
+		return nil, errs.ErrUnknownApproval
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// FindApprovals returns payment approvals in the given status.
+func (r *paymentRepository) FindApprovals(status payment.Status) []*payment.Approval {
+	var approvals []*payment.Approval
+	err := r.conn.Model(&approvals).Where("status = ?", status).Order("created_at").Select()
+	if err != nil {
+		return nil
+	}
+	return approvals
+}
+
+// UpdateApproval stores the decision on an approval, provided it is still in the given status.
+func (r *paymentRepository) UpdateApproval(a *payment.Approval, status payment.Status) error {
+	return updateApproval(r.conn, a, status)
+}
+
+// ExecuteApproval stores the decision on an approval still in the given status together with payments and events,
+// provided the debited accounts still hold the money.
+func (r *paymentRepository) ExecuteApproval(a *payment.Approval, status payment.Status, events []*outbox.Event, payments ...*payment.Payment) error {
+	return r.conn.RunInTransaction(func(tx *pg.Tx) error {
+		if err := updateApproval(tx, a, status); err != nil {
+			return err
+		}
+		if err := checkDebits(tx, payments); err != nil {
+			return err
+		}
+		for _, val := range payments {
+			if err := tx.Insert(val); err != nil {
+				return err
+			}
+		}
+		return insertEvents(tx, events)
+	})
+}
+
+// ExpireApprovals marks approvals pending approval or review longer than allowed as expired.
+func (r *paymentRepository) ExpireApprovals(now time.Time) (int, error) {
+	res, err := r.conn.Model((*payment.Approval)(nil)).
+		Set("status = ?", payment.Expired).
+		WhereIn("status IN (?)", []payment.Status{payment.PendingApproval, payment.PendingReview}).
+		Where("expires_at < ?", now).
+		Update()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// updateApproval updates an approval if it is in the given status, within a transaction or not.
+func updateApproval(db orm.DB, a *payment.Approval, status payment.Status) error {
+	res, err := db.Model(a).
+		Column("status", "checker", "reason", "decided_at").
+		WherePK().
+		Where("status = ?", status).
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return errs.ErrApprovalNotPending
+	}
+	return nil
+}
+
+// checkDebits locks the accounts debited by payments until the transaction ends and checks that
+// they hold the money. Payments debiting an account are stored only under its lock, so balances
+// read under it are not changed by concurrent payments before these are stored. Accounts are
+// locked in order of their ids, so that payments locking several do not deadlock.
+func checkDebits(tx *pg.Tx, payments []*payment.Payment) error {
+	debits := make(map[account.ID]decimal.Decimal)
+	var ids []string
+	for _, p := range payments {
+		if p.Direction != payment.Outgoing {
+			continue
+		}
+		if _, ok := debits[p.Account]; !ok {
+			ids = append(ids, string(p.Account))
+		}
+		debits[p.Account] = debits[p.Account].Add(p.Amount)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		var balance decimal.Decimal
+		if _, err := tx.Exec("SELECT id FROM accounts WHERE id = ? FOR UPDATE", id); err != nil {
+			return err
+		}
+		_, err := tx.QueryOne(pg.Scan(&balance), "SELECT balance FROM accounts_view WHERE id = ?", id)
+		if err == pg.ErrNoRows {
+			return errs.ErrUnknownSourceAccount
+		}
+		if err != nil {
+			return err
+		}
+		if balance.LessThan(debits[account.ID(id)]) {
+			return errs.ErrInsufficientMoney
+		}
+	}
+	return nil
+}
+
 // NewPaymentRepository returns a new instance of a PostgreSQL payment repository.
 func NewPaymentRepository(conn *pg.DB, accounts account.Repository) payment.Repository {
 	return &paymentRepository{
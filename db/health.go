@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// Ping checks that the database answers queries.
+func Ping(ctx context.Context, conn *pg.DB) error {
+	_, err := conn.WithContext(ctx).Exec("SELECT 1")
+	return err
+}
+
+// CheckSchema checks that the schema is in place: every table of CreateSchema exists and
+// the audit log is append-only.
+func CheckSchema(ctx context.Context, conn *pg.DB) error {
+	c := conn.WithContext(ctx)
+	for _, model := range models {
+		name := string(orm.GetTable(reflect.TypeOf(model).Elem()).FullName)
+		var exists bool
+		if _, err := c.QueryOne(pg.Scan(&exists), "SELECT to_regclass(?) IS NOT NULL", name); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("table %s does not exist", name)
+		}
+	}
+	var triggers int
+	_, err := c.QueryOne(pg.Scan(&triggers), `SELECT count(*) FROM pg_trigger
+		WHERE tgrelid = 'audit_log'::regclass AND tgname IN ('audit_log_append_only', 'audit_log_no_truncate')`)
+	if err != nil {
+		return err
+	}
+	if triggers != 2 {
+		return fmt.Errorf("audit log triggers are missing")
+	}
+	return nil
+}
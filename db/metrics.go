@@ -0,0 +1,48 @@
+package db
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector exports go-pg connection pool stats to Prometheus on every scrape.
+type poolCollector struct {
+	conn *pg.DB
+
+	hits, misses, timeouts, stale *prometheus.Desc
+	total, idle                   *prometheus.Desc
+}
+
+// NewPoolCollector returns a Prometheus collector of the connection pool stats.
+func NewPoolCollector(conn *pg.DB, namespace string) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		conn:     conn,
+		hits:     desc("hits_total", "Number of times a free connection was found in the pool."),
+		misses:   desc("misses_total", "Number of times a free connection was not found in the pool."),
+		timeouts: desc("timeouts_total", "Number of times waiting for a connection timed out."),
+		stale:    desc("stale_connections_total", "Number of stale connections removed from the pool."),
+		total:    desc("connections", "Number of connections in the pool."),
+		idle:     desc("idle_connections", "Number of idle connections in the pool."),
+	}
+}
+
+// Describe sends descriptors of the pool stats.
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.hits, c.misses, c.timeouts, c.stale, c.total, c.idle} {
+		ch <- d
+	}
+}
+
+// Collect sends current pool stats.
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.conn.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.stale, prometheus.CounterValue, float64(s.StaleConns))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns))
+}
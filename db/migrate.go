@@ -0,0 +1,31 @@
+package db
+
+// migrations add what CreateSchema does not to tables created by older versions: columns
+// added since, the index balances at a moment are worked out by, and the accounts view reading
+// balances off payments.
+const migrations = `
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS frozen boolean NOT NULL DEFAULT false;
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS created_at timestamptz;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS reversal_of varchar(36);
+CREATE INDEX IF NOT EXISTS payments_account_created_at ON payments (account, created_at);
+CREATE OR REPLACE VIEW accounts_view AS
+SELECT A.id,
+       A.balance
+           + (SELECT COALESCE(SUM(P.amount), 0)
+              FROM payments AS P
+              WHERE P.account = A.id
+              AND P.direction='incoming')
+           - (SELECT COALESCE(SUM(P.amount), 0)
+              FROM payments AS P
+              WHERE P.account = A.id
+              AND P.direction='outgoing')
+       AS balance,
+       A.country,
+       A.city,
+       A.currency,
+       A.deleted,
+       A.customer_id,
+       A.frozen,
+       A.created_at
+FROM accounts AS A;
+`
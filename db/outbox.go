@@ -0,0 +1,202 @@
+package db
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/outbox"
+)
+
+type outboxRepository struct {
+	conn querier
+}
+
+// StoreWebhook stores a new webhook.
+func (r *outboxRepository) StoreWebhook(w *outbox.Webhook) error {
+	return r.conn.Insert(w)
+}
+
+// FindWebhooks returns all registered webhooks.
+func (r *outboxRepository) FindWebhooks() []*outbox.Webhook {
+	var hooks []*outbox.Webhook
+	err := r.conn.Model(&hooks).Order("created_at").Select()
+	if err != nil {
+		return nil
+	}
+	return hooks
+}
+
+// DeleteWebhook removes a webhook with its pending deliveries.
+func (r *outboxRepository) DeleteWebhook(id uuid.UUID) error {
+	return r.conn.RunInTransaction(func(tx *pg.Tx) error {
+		res, err := tx.Model((*outbox.Webhook)(nil)).Where("id = ?", id).Delete()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return errs.ErrUnknownWebhook
+		}
+		_, err = tx.Model((*outbox.Delivery)(nil)).
+			Where("webhook_id = ?", id).
+			Where("status = ?", outbox.Pending).
+			Delete()
+		return err
+	})
+}
+
+// Enqueue creates pending deliveries of undispatched events for every subscribed webhook.
+func (r *outboxRepository) Enqueue(limit int) (int, error) {
+	var events []*outbox.Event
+	err := r.conn.RunInTransaction(func(tx *pg.Tx) error {
+		err := tx.Model(&events).
+			Where("dispatched = ?", false).
+			Order("created_at").
+			Limit(limit).
+			For("UPDATE SKIP LOCKED").
+			Select()
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		var hooks []*outbox.Webhook
+		if err := tx.Model(&hooks).Select(); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		ids := make([]uuid.UUID, 0, len(events))
+		for _, e := range events {
+			ids = append(ids, e.ID)
+			for _, w := range hooks {
+				if !w.Accepts(e.Type) {
+					continue
+				}
+				err := tx.Insert(&outbox.Delivery{
+					ID:            uuid.New(),
+					EventID:       e.ID,
+					WebhookID:     w.ID,
+					Status:        outbox.Pending,
+					NextAttemptAt: now,
+					UpdatedAt:     now,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		_, err = tx.Model((*outbox.Event)(nil)).
+			Set("dispatched = ?", true).
+			Where("id IN (?)", pg.In(ids)).
+			Update()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// Claim returns pending deliveries which are due, postponing them by lease.
+func (r *outboxRepository) Claim(limit int, lease time.Duration) ([]*outbox.Delivery, error) {
+	now := time.Now().UTC()
+	var deliveries []*outbox.Delivery
+	_, err := r.conn.Query(&deliveries, `
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, now.Add(lease), outbox.Pending, now, limit)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+	if err := r.load(deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// FindDeliveries returns deliveries in the given status.
+func (r *outboxRepository) FindDeliveries(status outbox.DeliveryStatus) []*outbox.Delivery {
+	var deliveries []*outbox.Delivery
+	err := r.conn.Model(&deliveries).Where("status = ?", status).Order("updated_at").Select()
+	if err != nil {
+		return nil
+	}
+	if err := r.load(deliveries); err != nil {
+		return nil
+	}
+	for _, d := range deliveries {
+		d.Webhook = nil
+	}
+	return deliveries
+}
+
+// FindDelivery returns a delivery with specified id.
+func (r *outboxRepository) FindDelivery(id uuid.UUID) (*outbox.Delivery, error) {
+	d := &outbox.Delivery{ID: id}
+	if err := r.conn.Select(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// UpdateDelivery stores delivery state.
+func (r *outboxRepository) UpdateDelivery(d *outbox.Delivery) error {
+	return r.conn.Update(d)
+}
+
+// load fills events and webhooks of the deliveries.
+func (r *outboxRepository) load(deliveries []*outbox.Delivery) error {
+	eventIDs := make([]uuid.UUID, 0, len(deliveries))
+	hookIDs := make([]uuid.UUID, 0, len(deliveries))
+	for _, d := range deliveries {
+		eventIDs = append(eventIDs, d.EventID)
+		hookIDs = append(hookIDs, d.WebhookID)
+	}
+
+	var events []*outbox.Event
+	if err := r.conn.Model(&events).Where("id IN (?)", pg.In(eventIDs)).Select(); err != nil {
+		return err
+	}
+	var hooks []*outbox.Webhook
+	if err := r.conn.Model(&hooks).Where("id IN (?)", pg.In(hookIDs)).Select(); err != nil {
+		return err
+	}
+
+	byEvent := make(map[uuid.UUID]*outbox.Event, len(events))
+	for _, e := range events {
+		byEvent[e.ID] = e
+	}
+	byHook := make(map[uuid.UUID]*outbox.Webhook, len(hooks))
+	for _, w := range hooks {
+		byHook[w.ID] = w
+	}
+	for _, d := range deliveries {
+		d.Event = byEvent[d.EventID]
+		d.Webhook = byHook[d.WebhookID]
+	}
+	return nil
+}
+
+// NewOutboxRepository returns a new instance of a PostgreSQL outbox repository.
+func NewOutboxRepository(conn *pg.DB) outbox.Repository {
+	return &outboxRepository{
+		conn: conn,
+	}
+}
+
+// insertEvents stores outbox events within a transaction of the change they describe.
+func insertEvents(tx *pg.Tx, events []*outbox.Event) error {
+	for _, e := range events {
+		if err := tx.Insert(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ilyareist/task1/ratelimit"
+)
+
+// bucketTTL is how long buckets are kept after their last use, by then refilled under any sane rule.
+const bucketTTL = time.Hour
+
+type rateLimiter struct {
+	conn  *pg.DB
+	mtx   sync.Mutex
+	swept time.Time
+}
+
+// Take takes a token from the bucket with the key, locking its row for the time, so that
+// every instance of the service draws on the same budget. Time is told by the database clock.
+func (l *rateLimiter) Take(ctx context.Context, key string, r ratelimit.Rule) (time.Duration, error) {
+	l.sweep()
+	var wait time.Duration
+	err := l.conn.WithContext(ctx).RunInTransaction(func(tx *pg.Tx) error {
+		var now time.Time
+		if _, err := tx.QueryOne(pg.Scan(&now), "SELECT now()"); err != nil {
+			return err
+		}
+		b := &ratelimit.Bucket{Key: key, Tokens: r.Burst, UpdatedAt: now}
+		if _, err := tx.Model(b).OnConflict("DO NOTHING").Insert(); err != nil {
+			return err
+		}
+		if err := tx.Model(b).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+		wait = b.Take(now, r)
+		_, err := tx.Model(b).Column("tokens", "updated_at").WherePK().Update()
+		return err
+	})
+	return wait, err
+}
+
+// Refund gives a token back to the bucket with the key, up to the burst of the rule.
+func (l *rateLimiter) Refund(ctx context.Context, key string, r ratelimit.Rule) error {
+	_, err := l.conn.WithContext(ctx).Model((*ratelimit.Bucket)(nil)).
+		Set("tokens = least(tokens + 1, ?)", r.Burst).
+		Where("key = ?", key).
+		Update()
+	return err
+}
+
+// sweep deletes buckets unused for longer than bucketTTL, at most once in a while.
+func (l *rateLimiter) sweep() {
+	l.mtx.Lock()
+	if time.Since(l.swept) < bucketTTL/4 {
+		l.mtx.Unlock()
+		return
+	}
+	l.swept = time.Now()
+	l.mtx.Unlock()
+	_, _ = l.conn.Model((*ratelimit.Bucket)(nil)).
+		Where("updated_at < now() - ? * interval '1 second'", bucketTTL.Seconds()).
+		Delete()
+}
+
+// NewRateLimiter returns a limiter keeping buckets in PostgreSQL, shared by all instances of the service.
+func NewRateLimiter(conn *pg.DB) ratelimit.Limiter {
+	return &rateLimiter{conn: conn}
+}
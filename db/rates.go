@@ -0,0 +1,48 @@
+package db
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/payment"
+)
+
+type rateRepository struct {
+	conn querier
+}
+
+// StoreRates stores rates, replacing stored ones of the same currency and date, all in one transaction.
+func (r *rateRepository) StoreRates(rates ...payment.Rate) error {
+	return r.conn.RunInTransaction(func(tx *pg.Tx) error {
+		for i := range rates {
+			_, err := tx.Model(&rates[i]).
+				OnConflict("(currency, date) DO UPDATE").
+				Set("rate = EXCLUDED.rate").
+				Insert()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindRate returns the stored rate of currency on the date, the most recent one for payment.Latest.
+func (r *rateRepository) FindRate(currency string, date string) (payment.Rate, error) {
+	var rate payment.Rate
+	q := r.conn.Model(&rate).Where("currency = ?", currency)
+	if date == payment.Latest {
+		q = q.Order("date DESC").Limit(1)
+	} else {
+		q = q.Where("date = ?", date)
+	}
+	err := q.Select()
+	if err == pg.ErrNoRows {
+		return payment.Rate{}, errs.ErrRateUnavailable
+	}
+	return rate, err
+}
+
+// NewRateRepository returns a new instance of a PostgreSQL store of imported exchange rates.
+func NewRateRepository(conn *pg.DB) payment.RateStore {
+	return &rateRepository{conn: conn}
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/payment"
+	"github.com/ilyareist/task1/screening"
+	"github.com/shopspring/decimal"
+)
+
+type screeningRepository struct {
+	conn *pg.DB
+}
+
+// Store logs a decision.
+func (r *screeningRepository) Store(s *screening.Screening) error {
+	return r.conn.Insert(s)
+}
+
+// Find returns logged decisions matching the filter, newest first.
+func (r *screeningRepository) Find(f screening.Filter) []*screening.Screening {
+	var ss []*screening.Screening
+	q := r.conn.Model(&ss).Order("created_at DESC")
+	if f.Decision != "" {
+		q = q.Where("decision = ?", f.Decision)
+	}
+	if f.Account != "" {
+		q = q.Where("account = ? OR ? = ANY(counterparties)", f.Account, f.Account)
+	}
+	if !f.Since.IsZero() {
+		q = q.Where("created_at >= ?", f.Since)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	if err := q.Select(); err != nil {
+		return nil
+	}
+	return ss
+}
+
+// NewScreeningRepository returns a new instance of a PostgreSQL screening decision log.
+func NewScreeningRepository(conn *pg.DB) screening.Repository {
+	return &screeningRepository{
+		conn: conn,
+	}
+}
+
+type paymentHistory struct {
+	conn *pg.DB
+}
+
+// DebitsSince returns the number and the sum of payments debited from the account since the time.
+func (h *paymentHistory) DebitsSince(id account.ID, since time.Time) (int, decimal.Decimal, error) {
+	var (
+		count int
+		sum   decimal.Decimal
+	)
+	err := h.conn.Model((*payment.Payment)(nil)).
+		ColumnExpr("count(*), COALESCE(SUM(amount), 0)").
+		Where("deleted = ?", false).
+		Where("account = ?", id).
+		Where("direction = ?", payment.Outgoing).
+		Where("created_at >= ?", since).
+		Select(pg.Scan(&count, &sum))
+	return count, sum, err
+}
+
+// LastDebits returns amounts of up to n latest payments debited from the account.
+func (h *paymentHistory) LastDebits(id account.ID, n int) ([]decimal.Decimal, error) {
+	var pp []*payment.Payment
+	err := h.conn.Model(&pp).
+		Column("amount").
+		Where("deleted = ?", false).
+		Where("account = ?", id).
+		Where("direction = ?", payment.Outgoing).
+		Order("created_at DESC NULLS LAST").
+		Limit(n).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	amounts := make([]decimal.Decimal, len(pp))
+	for i, p := range pp {
+		amounts[i] = p.Amount
+	}
+	return amounts, nil
+}
+
+// HasPaid reports whether the account has paid to the counterparty before.
+func (h *paymentHistory) HasPaid(from, to account.ID) (bool, error) {
+	return h.conn.Model((*payment.Payment)(nil)).
+		Where("deleted = ?", false).
+		Where("account = ?", to).
+		Where("from_account = ?", from).
+		Where("direction = ?", payment.Incoming).
+		Exists()
+}
+
+// NewPaymentHistory returns past payments of accounts stored in PostgreSQL, for screening rules.
+func NewPaymentHistory(conn *pg.DB) screening.History {
+	return &paymentHistory{
+		conn: conn,
+	}
+}
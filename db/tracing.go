@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-pg/pg"
+	"github.com/ilyareist/task1/redact"
+	"github.com/ilyareist/task1/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type spanKey struct{}
+
+// queryHook traces statements and, given a logger, logs them with string literals masked,
+// as they may carry any data.
+type queryHook struct {
+	logger log.Logger
+}
+
+// NewQueryHook returns a hook starting a span for every statement, logging statements as well
+// when logger is not nil. Spans are children of the span in the context of the query, if any.
+func NewQueryHook(logger log.Logger) pg.QueryHook {
+	return &queryHook{logger}
+}
+
+// BeforeQuery starts the span of the statement.
+func (h *queryHook) BeforeQuery(q *pg.QueryEvent) {
+	ctx := q.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	query, _ := q.FormattedQuery()
+	_, span := tracing.Start(ctx, "db.query",
+		semconv.DBSystemPostgreSQL,
+		semconv.DBStatementKey.String(redact.SQL(query)),
+	)
+	q.Data[spanKey{}] = span
+}
+
+// AfterQuery ends the span of the statement and logs it.
+func (h *queryHook) AfterQuery(q *pg.QueryEvent) {
+	if span, ok := q.Data[spanKey{}].(trace.Span); ok {
+		err := q.Error
+		if err == pg.ErrNoRows {
+			err = nil
+		}
+		tracing.End(span, err)
+	}
+	if h.logger == nil {
+		return
+	}
+	query, err := q.FormattedQuery()
+	if err != nil {
+		_ = h.logger.Log("error", err)
+		return
+	}
+	_ = h.logger.Log("query", redact.SQL(query), "error", q.Error)
+}
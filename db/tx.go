@@ -0,0 +1,68 @@
+package db
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/audit"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/customer"
+	"github.com/ilyareist/task1/outbox"
+	"github.com/ilyareist/task1/payment"
+)
+
+// querier is a connection pool, or a transaction the repositories are bound to.
+type querier interface {
+	orm.DB
+	RunInTransaction(fn func(*pg.Tx) error) error
+}
+
+// nestedTx is a transaction in which transactions of the repositories run as savepoints,
+// so that they roll back on their own and the transaction is committed only once, at its end.
+type nestedTx struct {
+	*pg.Tx
+}
+
+// RunInTransaction runs fn in a savepoint, which is rolled back when fn returns an error
+// and released otherwise.
+func (tx nestedTx) RunInTransaction(fn func(*pg.Tx) error) error {
+	if _, err := tx.Exec("SAVEPOINT nested"); err != nil {
+		return err
+	}
+	if err := fn(tx.Tx); err != nil {
+		_, _ = tx.Exec("ROLLBACK TO SAVEPOINT nested")
+		return err
+	}
+	_, err := tx.Exec("RELEASE SAVEPOINT nested")
+	return err
+}
+
+// Repositories are repositories bound to one transaction.
+type Repositories struct {
+	Accounts  account.Repository
+	Customers customer.Repository
+	Payments  payment.Repository
+	Events    outbox.Repository
+	Entries   audit.Repository
+	Keys      auth.KeyRepository
+	Rates     payment.RateStore
+}
+
+// RunInTransaction runs fn on repositories bound to one transaction, which is committed when fn
+// returns nil and rolled back otherwise. Reads of the accounts in one snapshot, FindLedger and
+// FindDailyBalances, set up transactions of their own and cannot run in it.
+func RunInTransaction(conn *pg.DB, fn func(r *Repositories) error) error {
+	return conn.RunInTransaction(func(tx *pg.Tx) error {
+		q := nestedTx{tx}
+		accounts := &accountRepository{conn: q}
+		return fn(&Repositories{
+			Accounts:  accounts,
+			Customers: &customerRepository{conn: q},
+			Payments:  &paymentRepository{conn: q, accounts: accounts},
+			Events:    &outboxRepository{conn: q},
+			Entries:   &auditRepository{conn: q},
+			Keys:      &keyRepository{conn: q},
+			Rates:     &rateRepository{conn: q},
+		})
+	})
+}
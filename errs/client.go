@@ -0,0 +1,104 @@
+package errs
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// known lists errs values which may be restored from a server response.
+var known = []*Error{
+	ErrUnknownAccount,
+	ErrInvalidArgument,
+	ErrUnknownSourceAccount,
+	ErrUnknownTargetAccount,
+	ErrAccountsAreEqual,
+	ErrInsufficientMoney,
+	ErrInvalidSplit,
+	ErrStorePayments,
+	ErrStoreSourceAccount,
+	ErrStoreTargetAccount,
+	ErrBadRoute,
+	ErrUnknownWebhook,
+	ErrUnknownDelivery,
+	ErrUnauthenticated,
+	ErrForbidden,
+	ErrUnknownCustomer,
+	ErrCustomerNotVerified,
+	ErrUnknownApproval,
+	ErrApprovalNotPending,
+	ErrApprovalExpired,
+	ErrSelfApproval,
+	ErrPaymentBlocked,
+	ErrScreening,
+	ErrRateUnavailable,
+	ErrMalformedRequest,
+	ErrRequestTooLarge,
+	ErrUnsupportedMediaType,
+	ErrAccountFrozen,
+	ErrUnknownPayment,
+	ErrPaymentReversed,
+	ErrRateLimited,
+}
+
+// StatusError is returned by clients for failed responses which do not map to any errs value.
+// ErrorCode is the problem code, if the server reported one.
+type StatusError struct {
+	Code      int
+	ErrorCode Code
+	Message   string
+}
+
+func (e StatusError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.Code)
+	}
+	return e.Message
+}
+
+// DecodeErrorResponse restores the error encoded by EncodeError from a response by its problem code.
+// It returns nil for successful responses, leaving the body untouched.
+func DecodeErrorResponse(r *http.Response) error {
+	if r.StatusCode >= 200 && r.StatusCode < 300 {
+		return nil
+	}
+	var p Problem
+	_ = json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&p)
+	_, _ = io.Copy(ioutil.Discard, r.Body)
+
+	for _, err := range known {
+		if p.Code == err.Code {
+			return err
+		}
+	}
+	if p.Code == CodeValidation {
+		return ValidationError{Err: errors.New(p.Detail)}
+	}
+	msg := p.Title
+	if p.Detail != "" {
+		msg += ": " + p.Detail
+	}
+	return StatusError{Code: r.StatusCode, ErrorCode: p.Code, Message: msg}
+}
+
+// Retryable reports whether a call failed with err may succeed if repeated:
+// transport failures, server errors and unavailable rates are, business errors are not.
+func Retryable(err error) bool {
+	if errors.Is(err, ErrRateUnavailable) || errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	for _, e := range known {
+		if errors.Is(err, e) {
+			return false
+		}
+	}
+	switch e := err.(type) {
+	case ValidationError:
+		return false
+	case StatusError:
+		return e.Code >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -6,21 +6,126 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/ilyareist/task1/requestid"
+)
+
+// Code identifies an error for machines. Codes are stable, unlike messages, so clients may switch on them.
+type Code string
+
+// Codes of errs values and of the errors without a value of their own.
+const (
+	CodeUnknownAccount       Code = "unknown_account"
+	CodeInvalidArgument      Code = "invalid_argument"
+	CodeUnknownSourceAccount Code = "unknown_source_account"
+	CodeUnknownTargetAccount Code = "unknown_target_account"
+	CodeAccountsAreEqual     Code = "accounts_are_equal"
+	CodeInsufficientMoney    Code = "insufficient_money"
+	CodeInvalidSplit         Code = "invalid_split"
+	CodeStorePayments        Code = "store_payments_failed"
+	CodeStoreSourceAccount   Code = "store_source_account_failed"
+	CodeStoreTargetAccount   Code = "store_target_account_failed"
+	CodeBadRoute             Code = "bad_route"
+	CodeUnknownWebhook       Code = "unknown_webhook"
+	CodeUnknownDelivery      Code = "unknown_delivery"
+	CodeUnauthenticated      Code = "unauthenticated"
+	CodeForbidden            Code = "forbidden"
+	CodeUnknownCustomer      Code = "unknown_customer"
+	CodeCustomerNotVerified  Code = "customer_not_verified"
+	CodeUnknownApproval      Code = "unknown_approval"
+	CodeApprovalNotPending   Code = "approval_not_pending"
+	CodeApprovalExpired      Code = "approval_expired"
+	CodeSelfApproval         Code = "self_approval"
+	CodePaymentBlocked       Code = "payment_blocked"
+	CodeScreening            Code = "screening_failed"
+	CodeRateUnavailable      Code = "rate_unavailable"
+	CodeMalformedRequest     Code = "malformed_request"
+	CodeRequestTooLarge      Code = "request_too_large"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeAccountFrozen        Code = "account_frozen"
+	CodeUnknownPayment       Code = "unknown_payment"
+	CodePaymentReversed      Code = "payment_already_reversed"
+	CodeRateLimited          Code = "rate_limited"
+	CodeValidation           Code = "validation_failed"
+	CodeInternal             Code = "internal"
 )
 
 var (
-	ErrUnknownAccount       = errors.New("unknown account")
-	ErrInvalidArgument      = errors.New("invalid argument")
-	ErrUnknownSourceAccount = errors.New("unknown source account")
-	ErrUnknownTargetAccount = errors.New("unknown target account")
-	ErrAccountsAreEqual     = errors.New("target account must not be equal to source account")
-	ErrInsufficientMoney    = errors.New("insufficient money on source account")
-	ErrStorePayments        = errors.New("can not store payments")
-	ErrStoreSourceAccount   = errors.New("can not update source account")
-	ErrStoreTargetAccount   = errors.New("can not update target account")
-	ErrBadRoute             = errors.New("bad route")
+	ErrUnknownAccount       = New(CodeUnknownAccount, http.StatusNotFound, "unknown account")
+	ErrInvalidArgument      = New(CodeInvalidArgument, http.StatusBadRequest, "invalid argument")
+	ErrUnknownSourceAccount = New(CodeUnknownSourceAccount, http.StatusNotFound, "unknown source account")
+	ErrUnknownTargetAccount = New(CodeUnknownTargetAccount, http.StatusNotFound, "unknown target account")
+	ErrAccountsAreEqual     = New(CodeAccountsAreEqual, http.StatusUnprocessableEntity, "target account must not be equal to source account")
+	ErrInsufficientMoney    = New(CodeInsufficientMoney, http.StatusUnprocessableEntity, "insufficient money on source account")
+	ErrInvalidSplit         = New(CodeInvalidSplit, http.StatusBadRequest, "split legs must be distinct and use either amounts or percentages summing to 100")
+	ErrStorePayments        = New(CodeStorePayments, http.StatusInternalServerError, "can not store payments")
+	ErrStoreSourceAccount   = New(CodeStoreSourceAccount, http.StatusInternalServerError, "can not update source account")
+	ErrStoreTargetAccount   = New(CodeStoreTargetAccount, http.StatusInternalServerError, "can not update target account")
+	ErrBadRoute             = New(CodeBadRoute, http.StatusBadRequest, "bad route")
+	ErrUnknownWebhook       = New(CodeUnknownWebhook, http.StatusNotFound, "unknown webhook")
+	ErrUnknownDelivery      = New(CodeUnknownDelivery, http.StatusNotFound, "unknown webhook delivery")
+	ErrUnauthenticated      = New(CodeUnauthenticated, http.StatusUnauthorized, "missing or invalid credentials")
+	ErrForbidden            = New(CodeForbidden, http.StatusForbidden, "operation is not permitted")
+	ErrUnknownCustomer      = New(CodeUnknownCustomer, http.StatusNotFound, "unknown customer")
+	ErrCustomerNotVerified  = New(CodeCustomerNotVerified, http.StatusConflict, "customer is not verified")
+	ErrUnknownApproval      = New(CodeUnknownApproval, http.StatusNotFound, "unknown payment approval")
+	ErrApprovalNotPending   = New(CodeApprovalNotPending, http.StatusConflict, "payment is not pending approval")
+	ErrApprovalExpired      = New(CodeApprovalExpired, http.StatusConflict, "payment approval has expired")
+	ErrSelfApproval         = New(CodeSelfApproval, http.StatusForbidden, "payment must be decided by another principal")
+	ErrPaymentBlocked       = New(CodePaymentBlocked, http.StatusForbidden, "payment is blocked by screening")
+	ErrScreening            = New(CodeScreening, http.StatusInternalServerError, "can not screen payment")
+	ErrRateUnavailable      = New(CodeRateUnavailable, http.StatusServiceUnavailable, "exchange rate is unavailable")
+	ErrMalformedRequest     = New(CodeMalformedRequest, http.StatusBadRequest, "malformed request body")
+	ErrRequestTooLarge      = New(CodeRequestTooLarge, http.StatusRequestEntityTooLarge, "request body is too large")
+	ErrUnsupportedMediaType = New(CodeUnsupportedMediaType, http.StatusUnsupportedMediaType, "request body must be application/json")
+	ErrAccountFrozen        = New(CodeAccountFrozen, http.StatusConflict, "account is frozen")
+	ErrUnknownPayment       = New(CodeUnknownPayment, http.StatusNotFound, "unknown payment")
+	ErrPaymentReversed      = New(CodePaymentReversed, http.StatusConflict, "payment is already reversed")
+	ErrRateLimited          = New(CodeRateLimited, http.StatusTooManyRequests, "too many requests")
+
+	errValidation = New(CodeValidation, http.StatusUnprocessableEntity, "validation error")
+	errInternal   = New(CodeInternal, http.StatusInternalServerError, "internal error")
 )
 
+// Error is an error with a stable code, reported with the HTTP status. It may wrap the error
+// causing it; wrapped or not, errors.Is matches it against the errs value with the same code.
+type Error struct {
+	Code    Code
+	Status  int
+	Message string
+	Err     error
+}
+
+// New returns an error with the code, reported with the HTTP status and the message.
+func New(code Code, status int, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the cause of the error, if any.
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is reports whether target is an Error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error caused by err.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
 // ValidationError represents validation error, for right choosing of HTTP status in response.
 type ValidationError struct {
 	Err error
@@ -32,6 +137,85 @@ func (e ValidationError) Error() string {
 	return "validation error: " + e.Err.Error()
 }
 
+// Unwrap returns the error of the validator.
+func (e ValidationError) Unwrap() error { return e.Err }
+
+// FieldError tells what is wrong with a field of a request, named as in JSON.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Fields lists the fields failed govalidator validation, it is empty for other errors.
+func (e ValidationError) Fields() []FieldError {
+	var fields []FieldError
+	var walk func(err error)
+	walk = func(err error) {
+		switch err := err.(type) {
+		case govalidator.Errors:
+			for _, e := range err {
+				walk(e)
+			}
+		case govalidator.Error:
+			fields = append(fields, FieldError{
+				Field:   strings.Join(append(err.Path, err.Name), "."),
+				Message: err.Err.Error(),
+			})
+		}
+	}
+	walk(e.Err)
+	return fields
+}
+
+// ProblemType prefixes codes to make the RFC 7807 problem type URI.
+const ProblemType = "urn:payments:problem:"
+
+// Problem is the RFC 7807 problem details object errors are reported with, extended with
+// the code, the request id and details of invalid fields.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// ProblemOf describes err as a problem. Errors not known to errs are internal, their
+// messages are not disclosed.
+func ProblemOf(err error) Problem {
+	var (
+		e      *Error
+		v      ValidationError
+		detail string
+		fields []FieldError
+	)
+	switch {
+	case errors.As(err, &e):
+		if e.Err != nil && e.Status < http.StatusInternalServerError {
+			detail = e.Err.Error()
+		}
+	case errors.As(err, &v):
+		e, detail, fields = errValidation, v.Err.Error(), v.Fields()
+	default:
+		e = errInternal
+	}
+	return Problem{
+		Type:   ProblemType + string(e.Code),
+		Title:  e.Message,
+		Status: e.Status,
+		Detail: detail,
+		Code:   e.Code,
+		Errors: fields,
+	}
+}
+
+// CodeOf returns the code of err, CodeInternal for errors not known to errs.
+func CodeOf(err error) Code {
+	return ProblemOf(err).Code
+}
+
 type errorer interface {
 	ErrError() error
 }
@@ -49,27 +233,16 @@ func EncodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 	return json.NewEncoder(w).Encode(response)
 }
 
-// EncodeError encode errs from business-logic
-func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	switch err {
-	case ErrUnknownAccount, ErrUnknownSourceAccount, ErrUnknownTargetAccount:
-		w.WriteHeader(http.StatusNotFound)
-	case ErrInvalidArgument, ErrInsufficientMoney:
-		w.WriteHeader(http.StatusBadRequest)
-	case ErrAccountsAreEqual:
-		w.WriteHeader(http.StatusNotAcceptable)
-	default:
-		switch err.(type) {
-		case ValidationError:
-			w.WriteHeader(http.StatusNotAcceptable)
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+// EncodeError encode errs from business-logic as application/problem+json.
+func EncodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	p := ProblemOf(err)
+	p.RequestID = requestid.FromContext(ctx)
+	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	if p.Code == CodeUnauthenticated {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="payments"`)
 	}
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": err.Error(),
-	})
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
 }
 
 // ErrorOnlyResponse represents response which may contain only error or nothing.
@@ -77,4 +250,12 @@ type ErrorOnlyResponse struct {
 	Err error `json:"error,omitempty"`
 }
 
-func (r ErrorOnlyResponse) error() error { return r.Err }
+func (r ErrorOnlyResponse) ErrError() error { return r.Err }
+
+// Label names err for metrics and traces by its code. It is empty for nil.
+func Label(err error) string {
+	if err == nil {
+		return ""
+	}
+	return string(CodeOf(err))
+}
@@ -0,0 +1,96 @@
+package errs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/ilyareist/task1/requestid"
+)
+
+func TestProblemOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   Code
+		status int
+		detail string
+	}{
+		{"errs value", ErrUnknownAccount, CodeUnknownAccount, http.StatusNotFound, ""},
+		{"wrapped by fmt", fmt.Errorf("load: %w", ErrInsufficientMoney), CodeInsufficientMoney, http.StatusUnprocessableEntity, ""},
+		{"wrapping cause", ErrMalformedRequest.Wrap(errors.New("unexpected EOF")), CodeMalformedRequest, http.StatusBadRequest, "unexpected EOF"},
+		{"server error hides cause", ErrStorePayments.Wrap(errors.New("connection refused")), CodeStorePayments, http.StatusInternalServerError, ""},
+		{"validation", ValidationError{Err: errors.New("amount: non zero value required")}, CodeValidation, http.StatusUnprocessableEntity, "amount: non zero value required"},
+		{"unknown", errors.New("pq: password authentication failed"), CodeInternal, http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		p := ProblemOf(tt.err)
+		if p.Code != tt.code || p.Status != tt.status || p.Detail != tt.detail {
+			t.Errorf("%s: got %+v", tt.name, p)
+		}
+		if p.Type != ProblemType+string(tt.code) {
+			t.Errorf("%s: type %q", tt.name, p.Type)
+		}
+		if p.Title == "" {
+			t.Errorf("%s: no title", tt.name)
+		}
+	}
+}
+
+func TestProblemOfFields(t *testing.T) {
+	err := ValidationError{Err: govalidator.Errors{
+		govalidator.Error{Name: "amount", Err: errors.New("non zero value required")},
+		govalidator.Errors{govalidator.Error{Name: "to", Path: []string{"legs"}, Err: errors.New("does not validate as alphanum")}},
+	}}
+	p := ProblemOf(err)
+	want := []FieldError{{"amount", "non zero value required"}, {"legs.to", "does not validate as alphanum"}}
+	if len(p.Errors) != len(want) {
+		t.Fatalf("got fields %+v", p.Errors)
+	}
+	for i := range want {
+		if p.Errors[i] != want[i] {
+			t.Errorf("field %d: got %+v, want %+v", i, p.Errors[i], want[i])
+		}
+	}
+}
+
+func TestIs(t *testing.T) {
+	wrapped := fmt.Errorf("approve: %w", ErrApprovalNotPending.Wrap(errors.New("updated concurrently")))
+	if !errors.Is(wrapped, ErrApprovalNotPending) {
+		t.Error("wrapped error does not match its errs value")
+	}
+	if errors.Is(wrapped, ErrApprovalExpired) {
+		t.Error("wrapped error matches another errs value")
+	}
+	if CodeOf(wrapped) != CodeApprovalNotPending || Label(nil) != "" {
+		t.Errorf("got code %q, label %q", CodeOf(wrapped), Label(nil))
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	ctx := requestid.NewContext(context.Background(), "req-1")
+	w := httptest.NewRecorder()
+	EncodeError(ctx, ErrUnauthenticated, w)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json; charset=utf-8" {
+		t.Errorf("content type %q", ct)
+	}
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("no WWW-Authenticate challenge")
+	}
+	var p Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Code != CodeUnauthenticated || p.RequestID != "req-1" {
+		t.Errorf("got %+v", p)
+	}
+}
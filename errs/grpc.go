@@ -0,0 +1,76 @@
+package errs
+
+import (
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of ErrorInfo details of gRPC status errors.
+const ErrorDomain = "payments"
+
+// grpcCodes are the gRPC codes of errors, where they do not follow from the HTTP status.
+var grpcCodes = map[Code]codes.Code{
+	CodeInsufficientMoney:   codes.FailedPrecondition,
+	CodeAccountsAreEqual:    codes.FailedPrecondition,
+	CodeCustomerNotVerified: codes.FailedPrecondition,
+	CodeApprovalNotPending:  codes.FailedPrecondition,
+	CodeApprovalExpired:     codes.FailedPrecondition,
+	CodeAccountFrozen:       codes.FailedPrecondition,
+	CodePaymentReversed:     codes.FailedPrecondition,
+}
+
+// grpcStatusCodes are the gRPC codes of HTTP statuses.
+var grpcStatusCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.FailedPrecondition,
+	http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+	http.StatusUnsupportedMediaType:  codes.InvalidArgument,
+	http.StatusUnprocessableEntity:   codes.InvalidArgument,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusServiceUnavailable:    codes.Unavailable,
+}
+
+// GRPCError converts errs from business-logic into gRPC status errors, choosing codes the
+// way EncodeError chooses HTTP statuses. The errs code is attached as ErrorInfo reason and
+// invalid fields as BadRequest field violations.
+func GRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	p := ProblemOf(err)
+	code, ok := grpcCodes[p.Code]
+	if !ok {
+		if code, ok = grpcStatusCodes[p.Status]; !ok {
+			code = codes.Internal
+		}
+	}
+	msg := p.Title
+	if p.Detail != "" {
+		msg += ": " + p.Detail
+	}
+	st := status.New(code, msg)
+	info := &errdetails.ErrorInfo{Reason: string(p.Code), Domain: ErrorDomain}
+	var detailed *status.Status
+	if len(p.Errors) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(p.Errors))
+		for i, f := range p.Errors {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+		detailed, err = st.WithDetails(info, &errdetails.BadRequest{FieldViolations: violations})
+	} else {
+		detailed, err = st.WithDetails(info)
+	}
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
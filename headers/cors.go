@@ -0,0 +1,167 @@
+// Package headers sets response headers browsers act on: CORS for cross-origin calls and
+// headers hardening responses against their misuse.
+package headers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyareist/task1/errs"
+)
+
+// Any allows any origin or request header.
+const Any = "*"
+
+// CORS is the policy of cross-origin requests.
+type CORS struct {
+	// Origins allowed to call, as scheme://host[:port]; "*" allows any origin and
+	// https://*.example.com any subdomain of example.com.
+	Origins []string
+	// Methods allowed in cross-origin requests.
+	Methods []string
+	// Headers allowed in cross-origin requests, "*" allows any.
+	Headers []string
+	// Expose lists response headers scripts may read.
+	Expose []string
+	// Credentials lets cookies and authorization go with requests, for origins listed
+	// by name only.
+	Credentials bool
+	// MaxAge is how long browsers may cache preflight responses.
+	MaxAge time.Duration
+}
+
+// Handler answers preflight requests of allowed origins and lets the rest through to next,
+// with CORS headers for allowed origins. Preflight requests which are not allowed are
+// answered 403, other requests of origins which are not allowed get no CORS headers and
+// so are not readable by scripts.
+func (c CORS) Handler(next http.Handler) http.Handler {
+	methods := strings.Join(c.Methods, ", ")
+	expose := strings.Join(c.Expose, ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !c.allowsOrigin(origin) {
+			if preflight {
+				errs.EncodeError(r.Context(), errs.ErrForbidden, w)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.Credentials || !c.allowsAnyOrigin() {
+			h.Set("Access-Control-Allow-Origin", origin)
+		} else {
+			h.Set("Access-Control-Allow-Origin", Any)
+		}
+		if c.Credentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if expose != "" {
+				h.Set("Access-Control-Expose-Headers", expose)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requested := requestedHeaders(r.Header.Get("Access-Control-Request-Headers"))
+		if !c.allowsMethod(r.Header.Get("Access-Control-Request-Method")) || !c.allowsHeaders(requested) {
+			h.Del("Access-Control-Allow-Origin")
+			h.Del("Access-Control-Allow-Credentials")
+			errs.EncodeError(r.Context(), errs.ErrForbidden, w)
+			return
+		}
+		h.Set("Access-Control-Allow-Methods", methods)
+		if len(requested) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+		}
+		if c.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (c CORS) allowsAnyOrigin() bool {
+	for _, o := range c.Origins {
+		if o == Any {
+			return true
+		}
+	}
+	return false
+}
+
+func (c CORS) allowsOrigin(origin string) bool {
+	for _, o := range c.Origins {
+		if MatchOrigin(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c CORS) allowsMethod(method string) bool {
+	for _, m := range c.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c CORS) allowsHeaders(requested []string) bool {
+	for _, r := range requested {
+		allowed := false
+		for _, h := range c.Headers {
+			if h == Any || strings.EqualFold(h, r) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchOrigin reports whether the origin is allowed by the pattern: "*", an origin or an
+// origin with "*." in front of its host, which matches subdomains of the host.
+func MatchOrigin(pattern, origin string) bool {
+	if pattern == Any || strings.EqualFold(pattern, origin) {
+		return true
+	}
+	i := strings.Index(pattern, "://*.")
+	if i < 0 {
+		return false
+	}
+	prefix, suffix := pattern[:i+3], pattern[i+4:]
+	origin = strings.ToLower(origin)
+	return len(origin) > len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, strings.ToLower(prefix)) &&
+		strings.HasSuffix(origin, strings.ToLower(suffix))
+}
+
+// requestedHeaders splits the list of Access-Control-Request-Headers.
+func requestedHeaders(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,170 @@
+package headers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var policy = CORS{
+	Origins:     []string{"https://app.example.com", "https://*.example.org"},
+	Methods:     []string{"GET", "POST"},
+	Headers:     []string{"Content-Type", "Authorization"},
+	Expose:      []string{"X-Request-ID"},
+	Credentials: true,
+	MaxAge:      10 * time.Minute,
+}
+
+// serve passes the request through the policy to a handler answering 200, telling whether it was called.
+func serve(c CORS, method, origin string, header http.Header) (*httptest.ResponseRecorder, bool) {
+	var called bool
+	h := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	r := httptest.NewRequest(method, "/api/accounts/v1/accounts", nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w, called
+}
+
+func preflight(method, headers string) http.Header {
+	h := http.Header{"Access-Control-Request-Method": {method}}
+	if headers != "" {
+		h.Set("Access-Control-Request-Headers", headers)
+	}
+	return h
+}
+
+func TestPreflight(t *testing.T) {
+	w, called := serve(policy, "OPTIONS", "https://app.example.com", preflight("POST", "content-type, authorization"))
+	if w.Code != http.StatusNoContent || called {
+		t.Fatalf("got status %d, called %v", w.Code, called)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "content-type, authorization",
+		"Access-Control-Max-Age":           "600",
+		"Access-Control-Expose-Headers":    "",
+	}
+	for k, v := range want {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("%s: got %q, want %q", k, got, v)
+		}
+	}
+	if vary := strings.Join(w.Header()["Vary"], ", "); vary != "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" {
+		t.Errorf("Vary: got %q", vary)
+	}
+}
+
+func TestPreflightRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin string
+		header http.Header
+	}{
+		{"origin", "https://evil.example.net", preflight("GET", "")},
+		{"method", "https://app.example.com", preflight("DELETE", "")},
+		{"header", "https://app.example.com", preflight("POST", "Content-Type, X-Debug")},
+	}
+	for _, tt := range tests {
+		w, called := serve(policy, "OPTIONS", tt.origin, tt.header)
+		if w.Code != http.StatusForbidden || called {
+			t.Errorf("%s: got status %d, called %v", tt.name, w.Code, called)
+		}
+		for _, k := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Access-Control-Allow-Methods"} {
+			if v := w.Header().Get(k); v != "" {
+				t.Errorf("%s: %s: got %q", tt.name, k, v)
+			}
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+			t.Errorf("%s: content type %q", tt.name, ct)
+		}
+	}
+}
+
+func TestSimpleRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy CORS
+		origin string
+		allow  string
+		expose string
+	}{
+		{"listed origin", policy, "https://app.example.com", "https://app.example.com", "X-Request-ID"},
+		{"subdomain", policy, "https://eu.api.example.org", "https://eu.api.example.org", "X-Request-ID"},
+		{"other origin", policy, "https://example.org", "", ""},
+		{"any origin", CORS{Origins: []string{Any}, Methods: []string{"GET"}}, "https://example.net", Any, ""},
+		{"any origin with credentials", CORS{Origins: []string{Any}, Credentials: true}, "https://example.net", "https://example.net", ""},
+		{"no origin", policy, "", "", ""},
+	}
+	for _, tt := range tests {
+		w, called := serve(tt.policy, "GET", tt.origin, nil)
+		if w.Code != http.StatusOK || !called {
+			t.Errorf("%s: got status %d, called %v", tt.name, w.Code, called)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allow {
+			t.Errorf("%s: allow origin %q, want %q", tt.name, got, tt.allow)
+		}
+		if got := w.Header().Get("Access-Control-Expose-Headers"); got != tt.expose {
+			t.Errorf("%s: expose %q, want %q", tt.name, got, tt.expose)
+		}
+		// Responses differ by origin whenever there is a policy, so caches must tell them apart.
+		if vary := strings.Join(w.Header()["Vary"], ", "); vary != "Origin" {
+			t.Errorf("%s: Vary %q", tt.name, vary)
+		}
+	}
+}
+
+func TestAnyHeader(t *testing.T) {
+	c := CORS{Origins: []string{Any}, Methods: []string{"PUT"}, Headers: []string{Any}}
+	w, _ := serve(c, "OPTIONS", "https://example.net", preflight("put", "X-Anything"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("got status %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != Any {
+		t.Errorf("allow origin %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); got != "X-Anything" {
+		t.Errorf("allow headers %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("max age %q", got)
+	}
+}
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		match   bool
+	}{
+		{"*", "https://example.com", true},
+		{"https://example.com", "https://example.com", true},
+		{"https://example.com", "HTTPS://Example.com", true},
+		{"https://example.com", "http://example.com", false},
+		{"https://example.com", "https://example.com:8443", false},
+		{"https://*.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://APP.Example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://.example.com", false},
+		{"https://*.example.com", "https://evilexample.com", false},
+		{"https://*.example.com", "https://app.example.com.evil.net", false},
+		{"https://*.example.com", "http://app.example.com", false},
+		{"https://*.example.com", "https://app.example.com:8443", false},
+		{"https://*.example.com:8443", "https://app.example.com:8443", true},
+	}
+	for _, tt := range tests {
+		if got := MatchOrigin(tt.pattern, tt.origin); got != tt.match {
+			t.Errorf("MatchOrigin(%q, %q) = %v, want %v", tt.pattern, tt.origin, got, tt.match)
+		}
+	}
+}
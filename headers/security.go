@@ -0,0 +1,46 @@
+package headers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Security are headers hardening responses.
+type Security struct {
+	// HSTS is the max-age of Strict-Transport-Security, which is not sent when zero.
+	HSTS time.Duration
+	// FrameOptions is X-Frame-Options, e.g. DENY.
+	FrameOptions string
+	// ReferrerPolicy is Referrer-Policy, e.g. no-referrer.
+	ReferrerPolicy string
+	// ContentSecurityPolicy is Content-Security-Policy.
+	ContentSecurityPolicy string
+	// NoStore keeps responses out of caches.
+	NoStore bool
+}
+
+// Handler sets the headers of responses of next, which may override them. Content types
+// are never sniffed.
+func (s Security) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		if s.FrameOptions != "" {
+			h.Set("X-Frame-Options", s.FrameOptions)
+		}
+		if s.ReferrerPolicy != "" {
+			h.Set("Referrer-Policy", s.ReferrerPolicy)
+		}
+		if s.ContentSecurityPolicy != "" {
+			h.Set("Content-Security-Policy", s.ContentSecurityPolicy)
+		}
+		if s.HSTS > 0 {
+			h.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(s.HSTS.Seconds()))+"; includeSubDomains")
+		}
+		if s.NoStore {
+			h.Set("Cache-Control", "no-store")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
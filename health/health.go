@@ -0,0 +1,114 @@
+// Package health serves liveness and readiness probes of the service.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses reported by probes.
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+)
+
+// Check is a dependency the service needs to serve requests.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+	// Optional checks failing degrade the service, without taking it out of rotation.
+	Optional bool
+}
+
+// Report is the body of probe responses.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Probe reports readiness of the service by running its checks.
+type Probe struct {
+	checks   []Check
+	timeout  time.Duration
+	draining int32
+}
+
+// NewProbe returns a probe running the checks, each within the timeout.
+func NewProbe(timeout time.Duration, checks ...Check) *Probe {
+	return &Probe{checks: checks, timeout: timeout}
+}
+
+// Drain makes the service report it is not ready, so that it is taken out of rotation
+// before it stops serving.
+func (p *Probe) Drain() {
+	atomic.StoreInt32(&p.draining, 1)
+}
+
+// Ready runs all checks concurrently. The service is unavailable when any required check fails
+// and degraded when an optional one does.
+func (p *Probe) Ready(ctx context.Context) Report {
+	if atomic.LoadInt32(&p.draining) == 1 {
+		return Report{Status: StatusDraining}
+	}
+	results := make([]error, len(p.checks))
+	var wg sync.WaitGroup
+	for i, c := range p.checks {
+		wg.Add(1)
+		go func(i int, c Check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, p.timeout)
+			defer cancel()
+			results[i] = c.Check(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	r := Report{Status: StatusOK, Checks: make(map[string]string, len(p.checks))}
+	for i, c := range p.checks {
+		err := results[i]
+		if err == nil {
+			r.Checks[c.Name] = StatusOK
+			continue
+		}
+		r.Checks[c.Name] = err.Error()
+		if !c.Optional {
+			r.Status = StatusUnavailable
+		} else if r.Status == StatusOK {
+			r.Status = StatusDegraded
+		}
+	}
+	return r
+}
+
+// ReadinessHandler serves the readiness report, with 503 Service Unavailable when the
+// service is unavailable or draining.
+func (p *Probe) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := p.Ready(r.Context())
+		code := http.StatusOK
+		if report.Status == StatusUnavailable || report.Status == StatusDraining {
+			code = http.StatusServiceUnavailable
+		}
+		encode(w, code, report)
+	})
+}
+
+// LivenessHandler reports the process is up and serving, without checking dependencies.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encode(w, http.StatusOK, Report{Status: StatusOK})
+	})
+}
+
+func encode(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -1,125 +1,706 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
 	"flag"
-	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/go-pg/pg"
 	"github.com/ilyareist/task1/db"
 
 	"github.com/go-kit/kit/log"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
 	"github.com/ilyareist/task1/account"
+	accountpb "github.com/ilyareist/task1/account/pb"
+	"github.com/ilyareist/task1/activity"
+	"github.com/ilyareist/task1/audit"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/config"
+	"github.com/ilyareist/task1/customer"
+	"github.com/ilyareist/task1/headers"
+	"github.com/ilyareist/task1/health"
+	"github.com/ilyareist/task1/openapi"
+	"github.com/ilyareist/task1/outbox"
 	"github.com/ilyareist/task1/payment"
+	paymentpb "github.com/ilyareist/task1/payment/pb"
+	"github.com/ilyareist/task1/ratelimit"
+	"github.com/ilyareist/task1/redact"
+	"github.com/ilyareist/task1/requestid"
+	"github.com/ilyareist/task1/screening"
+	"github.com/ilyareist/task1/tlsreload"
+	"github.com/ilyareist/task1/tracing"
+	"github.com/ilyareist/task1/validate"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
-type dbLogger struct{}
+const metricsNamespace = "payments"
 
-func (d dbLogger) BeforeQuery(q *pg.QueryEvent) {
-}
+// ratesProbeCurrency is requested by the readiness check of the rate provider.
+const ratesProbeCurrency = "EUR"
+
+// serviceFieldKeys label service request metrics.
+var serviceFieldKeys = []string{"method", "error"}
+
+// cfg is the configuration of the service, loaded by main.
+var cfg *config.Config
+
+func main() {
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	logger = redact.NewLogger(logger)
+	logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+
+	var err error
+	cfg, err = config.Load(flag.CommandLine, os.Args[1:])
+	if flag.Arg(0) == "config" {
+		os.Exit(runConfig(flag.Arg(1), err, logger))
+	}
+	if err != nil {
+		_ = logger.Log("msg", "load configuration", "error", err)
+		os.Exit(2)
+	}
+	validate.MaxBodySize = cfg.HTTP.MaxBodySize
 
-func (d dbLogger) AfterQuery(q *pg.QueryEvent) {
-	fmt.Println(q.FormattedQuery())
+	if flag.Arg(0) == "openapi" {
+		os.Exit(runOpenAPI(flag.Arg(1), logger))
+	}
+
+	shutdownTracing := setupTracing(logger)
+	code := runCommand(flag.Args(), logger)
+	if err := shutdownTracing(context.Background()); err != nil {
+		_ = logger.Log("component", "tracing", "error", err)
+	}
+	os.Exit(code)
 }
 
-var (
-	flagHttpAddr = flag.String("http_address", "0.0.0.0:8080", "Http address for web server running")
+// wiring holds the repositories and services shared by the server and the admin commands.
+type wiring struct {
+	accounts   account.Repository
+	customers  customer.Repository
+	payments   payment.Repository
+	events     outbox.Repository
+	screenings screening.Repository
+	entries    audit.Repository
+	rates      payment.RateStore
+	keys       auth.KeyRepository
 
-	flagDBAddr     = flag.String("db_address", "postgres:5432", "Address to connect to PostgreSQL server")
-	flagDBUser     = flag.String("db_user", "postgres", "PostgreSQL connection user")
-	flagDBPassword = flag.String("db_password", "password", "PostgreSQL connection password")
-	flagDBDatabase = flag.String("database", "payments", "PostgreSQL database name")
-	flagDBAppName  = flag.String("app_name", "payments", "PostgreSQL application name (for logging)")
-	flagDBPoolSize = flag.Int("pool_size", 10, "PostgreSQL connection pool size")
-	flagDBLog = flag.Bool("db_log", false, "Switch for statements logging")
-)
+	// transact runs fn on repositories bound to one transaction.
+	transact func(fn func(r *db.Repositories) error) error
 
-func main() {
-	flag.Parse()
+	recorder     audit.Recorder
+	rateProvider payment.RateProvider
 
-	logger := log.NewLogfmtLogger(os.Stderr)
-	logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+	accountService  account.Service
+	customerService customer.Service
+	paymentService  payment.Service
+}
 
+// wire makes the repositories on conn and the services on them, activity of payments is
+// published to the publisher.
+func wire(conn *pg.DB, publisher activity.Publisher, logger log.Logger) *wiring {
+	w := &wiring{
+		accounts:   db.NewAccountRepository(conn),
+		customers:  db.NewCustomerRepository(conn),
+		events:     db.NewOutboxRepository(conn),
+		screenings: db.NewScreeningRepository(conn),
+		entries:    db.NewAuditRepository(conn),
+		rates:      db.NewRateRepository(conn),
+		keys:       db.NewKeyRepository(conn),
+		transact: func(fn func(r *db.Repositories) error) error {
+			return db.RunInTransaction(conn, fn)
+		},
+	}
+	w.payments = db.NewPaymentRepository(conn, w.accounts)
+	w.recorder = audit.NewRecorder(w.entries, log.With(logger, "component", "audit"))
+	w.rateProvider = setupRateProvider(w.rates)
+
+	w.customerService = setupCustomerService(conn, w.customers, w.recorder, logger)
+	w.accountService = setupAccountService(conn, w.accounts, w.customers, w.recorder, logger)
+	screener := setupScreener(w.screenings, db.NewPaymentHistory(conn), w.customers, logger)
+	w.paymentService = setupPaymentService(conn, w.payments, w.accounts, publisher, w.rateProvider, screener, w.recorder, logger)
+	return w
+}
+
+// serve runs the HTTP and gRPC servers until the process is signalled to stop, and returns
+// the exit status of the command.
+func serve(logger log.Logger) int {
 	conn := setupDB(logger)
-	defer func() {
-		if err := conn.Close(); err != nil {
-			_ = logger.Log("error", err)
-		}
-	}()
+	defer closeDB(conn, logger)
+
+	// Background workers run until ctx is cancelled on shutdown, which waits for them to return.
+	ctx, cancel := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	run := func(worker func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker(ctx)
+		}()
+	}
+
+	broker := activity.NewBroker(cfg.Activity.History)
+	var publisher activity.Publisher = broker
+	if cfg.Activity.Notify {
+		bridge := db.NewActivityBridge(conn, broker, log.With(logger, "component", "activity"))
+		run(bridge.Run)
+		publisher = bridge
+	}
+
+	stdprometheus.MustRegister(db.NewPoolCollector(conn, metricsNamespace))
+
+	w := wire(conn, publisher, logger)
+	rates := w.rateProvider
+	cs, as, ps := w.customerService, w.accountService, w.paymentService
+	ws := outbox.NewAuditingService(w.recorder, func(fn func(outbox.Service, outbox.Repository, audit.Repository) error) error {
+		return db.RunInTransaction(conn, func(r *db.Repositories) error {
+			return fn(outbox.NewService(r.Events), r.Events, r.Entries)
+		})
+	}, outbox.NewService(w.events))
+	ss := screening.NewService(w.screenings)
+	ls := audit.NewService(w.entries)
+
+	run(setupDispatcher(w.events, logger).Run)
+	approvalsLogger := log.With(logger, "component", "approvals")
+	run(func(ctx context.Context) {
+		payment.ExpireApprovals(ctx, w.payments, cfg.Approvals.ExpireInterval, approvalsLogger)
+	})
+	balancesLogger := log.With(logger, "component", "balances")
+	run(func(ctx context.Context) {
+		account.TakeSnapshots(ctx, w.accounts, cfg.Balances.SnapshotInterval, cfg.Balances.SnapshotDelay, balancesLogger)
+	})
+
+	authenticator := setupAuthenticator(w.keys, logger)
+	authenticate := auth.NewMiddleware(authenticator)
 
 	var (
-		accounts = db.NewAccountRepository(conn)
-		payments = db.NewPaymentRepository(conn, accounts)
+		accountEndpoints  = account.MakeEndpoints(as, authenticate)
+		customerEndpoints = customer.MakeEndpoints(cs, authenticate)
+		paymentEndpoints  = payment.MakeEndpoints(ps, authenticate)
+		webhookEndpoints  = outbox.MakeEndpoints(ws, authenticate)
+		screenEndpoints   = screening.MakeEndpoints(ss, authenticate)
+		auditEndpoints    = audit.MakeEndpoints(ls, authenticate)
 	)
 
-	as := setupAccountService(accounts, logger)
-	ps := setupPaymentService(payments, accounts, logger)
+	probe := health.NewProbe(cfg.Health.Timeout,
+		health.Check{Name: "db", Check: func(ctx context.Context) error {
+			return db.Ping(ctx, conn)
+		}},
+		health.Check{Name: "schema", Check: func(ctx context.Context) error {
+			return db.CheckSchema(ctx, conn)
+		}},
+		health.Check{Name: "rates", Optional: true, Check: func(ctx context.Context) error {
+			_, err := rates.Rate(ctx, ratesProbeCurrency, payment.Latest)
+			return err
+		}},
+	)
 
 	httpLogger := log.With(logger, "component", "http")
 
 	mux := http.NewServeMux()
 
-	mux.Handle("/api/accounts/v1/", account.MakeHandler(as, httpLogger))
-	mux.Handle("/api/payments/v1/", payment.MakeHandler(ps, httpLogger))
+	limit := setupRateLimit(conn, authenticator, logger)
+	cors := cfg.CORS.Policy()
+	secure := headers.Security{
+		HSTS:                  cfg.Security.HSTS,
+		FrameOptions:          cfg.Security.FrameOptions,
+		ReferrerPolicy:        cfg.Security.ReferrerPolicy,
+		ContentSecurityPolicy: cfg.Security.ContentSecurityPolicy,
+		NoStore:               true,
+	}
+	ui := secure
+	ui.ContentSecurityPolicy, ui.NoStore = openapi.UIContentSecurityPolicy, false
+
+	// Route groups: the API is open to scripts of allowed origins and throttled, its documentation
+	// is open to them too, while probes and metrics are for operators only.
+	api := func(h http.Handler) http.Handler { return cors.Handler(secure.Handler(limit(h))) }
+	docs := func(h http.Handler) http.Handler { return cors.Handler(ui.Handler(h)) }
+	ops := secure.Handler
+
+	mux.Handle("/api/accounts/v1/", api(payment.MakeStatementHandler(paymentEndpoints, httpLogger, account.MakeHandler(accountEndpoints, httpLogger))))
+	mux.Handle("/api/customers/v1/", api(customer.MakeHandler(customerEndpoints, httpLogger)))
+	mux.Handle("/api/payments/v1/", api(payment.MakeHandler(paymentEndpoints, httpLogger)))
+	mux.Handle("/api/payments/v1/accounts/", api(auth.Handler(authenticator, activity.MakeHandler(broker, activity.AuthorizeLoad(as), httpLogger))))
+	mux.Handle("/api/webhooks/v1/", api(outbox.MakeHandler(webhookEndpoints, httpLogger)))
+	mux.Handle("/api/screening/v1/", api(screening.MakeHandler(screenEndpoints, httpLogger)))
+	mux.Handle("/api/audit/v1/", api(audit.MakeHandler(auditEndpoints, httpLogger)))
+	mux.Handle("/openapi.json", docs(openapi.Handler(apiSpec())))
+	mux.Handle("/docs/", docs(openapi.UIHandler("/docs/", "/openapi.json")))
+	mux.Handle("/metrics", ops(promhttp.Handler()))
+	mux.Handle("/healthz", ops(health.LivenessHandler()))
+	mux.Handle("/readyz", ops(probe.ReadinessHandler()))
+
+	httpServer := &http.Server{
+		Addr:    cfg.HTTP.Address(),
+		Handler: requestid.Handler(mux),
+	}
+	if cfg.HTTP.TLSCert != "" {
+		var certs *tlsreload.Reloader
+		certs, httpServer.TLSConfig = setupTLS(logger)
+		tlsLogger := log.With(logger, "component", "tls")
+		run(func(ctx context.Context) { certs.Run(ctx, cfg.HTTP.TLSReload, tlsLogger) })
+	}
+	// Activity streams never end on their own, they are closed for the server to drain.
+	httpServer.RegisterOnShutdown(broker.Close)
 
-	http.Handle("/", accessControl(mux))
+	grpcLogger := log.With(logger, "component", "grpc")
+
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(requestid.UnaryServerInterceptor(kitgrpc.Interceptor)),
+		grpc.MaxRecvMsgSize(int(cfg.HTTP.MaxBodySize)),
+	)
+	accountpb.RegisterAccountServiceServer(grpcServer, account.NewGRPCServer(accountEndpoints, grpcLogger))
+	paymentpb.RegisterPaymentServiceServer(grpcServer, payment.NewGRPCServer(paymentEndpoints, grpcLogger))
+	reflection.Register(grpcServer)
 
-	errs := make(chan error, 2)
+	// Servers failing to listen or serve stop the process with status 1, signals with status 0.
+	failed := make(chan error, 2)
 	go func() {
-		_ = logger.Log("transport", "http", "address", *flagHttpAddr, "msg", "listening")
-		errs <- http.ListenAndServe(*flagHttpAddr, nil)
+		if httpServer.TLSConfig != nil {
+			_ = logger.Log("transport", "https", "address", cfg.HTTP.Address(), "msg", "listening")
+			if err := httpServer.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
+				failed <- err
+			}
+			return
+		}
+		_ = logger.Log("transport", "http", "address", cfg.HTTP.Address(), "msg", "listening")
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			failed <- err
+		}
+	}()
+	go func() {
+		ln, err := net.Listen("tcp", cfg.GRPC.Address())
+		if err != nil {
+			failed <- err
+			return
+		}
+		_ = logger.Log("transport", "grpc", "address", cfg.GRPC.Address(), "msg", "listening")
+		if err := grpcServer.Serve(ln); err != nil {
+			failed <- err
+		}
 	}()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	code := 0
+	select {
+	case err := <-failed:
+		_ = logger.Log("terminated", err)
+		code = 1
+	case sig := <-stop:
+		_ = logger.Log("terminated", sig)
+	}
+
+	shutdown(logger, probe, httpServer, grpcServer, cancel, &workers)
+	return code
+}
+
+// shutdown stops taking new requests, waits for those in flight for at most the drain timeout,
+// then stops background workers.
+func shutdown(logger log.Logger, probe *health.Probe, httpServer *http.Server, grpcServer *grpc.Server, cancel context.CancelFunc, workers *sync.WaitGroup) {
+	logger = log.With(logger, "component", "shutdown")
+	probe.Drain()
+	if cfg.Health.DrainDelay > 0 {
+		_ = logger.Log("msg", "draining", "delay", cfg.Health.DrainDelay)
+		time.Sleep(cfg.Health.DrainDelay)
+	}
+
+	ctx, cancelDrain := context.WithTimeout(context.Background(), cfg.Health.DrainTimeout)
+	defer cancelDrain()
+
+	stopped := make(chan struct{})
 	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, syscall.SIGINT)
-		errs <- fmt.Errorf("%s", <-c)
+		grpcServer.GracefulStop()
+		close(stopped)
 	}()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		_ = logger.Log("transport", "http", "msg", "drain timed out", "error", err)
+		_ = httpServer.Close()
+	}
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		_ = logger.Log("transport", "grpc", "msg", "drain timed out")
+		grpcServer.Stop()
+	}
 
-	_ = logger.Log("terminated", <-errs)
+	cancel()
+	workers.Wait()
+	_ = logger.Log("msg", "stopped")
 }
 
-func setupDB(logger log.Logger) *pg.DB {
-	conn := pg.Connect(&pg.Options{
-		Addr:            *flagDBAddr,
-		User:            *flagDBUser,
-		Password:        *flagDBPassword,
-		Database:        *flagDBDatabase,
-		ApplicationName: *flagDBAppName,
-		PoolSize:        *flagDBPoolSize,
+func setupTracing(logger log.Logger) func(context.Context) error {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		Service:     cfg.DB.AppName,
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.OTLPEndpoint,
+		Insecure:    cfg.Tracing.OTLPInsecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
 	})
-	if *flagDBLog {
-		conn.AddQueryHook(dbLogger{})
+	if err != nil {
+		_ = logger.Log("msg", "setup tracing", "exporter", cfg.Tracing.Exporter, "error", err)
+		panic(err)
 	}
+	return shutdown
+}
+
+// setupDB connects to the database and brings its schema up to date.
+func setupDB(logger log.Logger) *pg.DB {
+	conn := connectDB(logger)
 	if err := db.CreateSchema(conn); err != nil {
-		_ = logger.Log("transport", "DB", "address", *flagDBAddr, "msg", err)
+		_ = logger.Log("transport", "DB", "address", cfg.DB.Address(), "msg", err)
 		panic(err)
 	}
 	return conn
 }
 
-func setupPaymentService(payments payment.Repository, accounts account.Repository, logger log.Logger) payment.Service {
-	ps := payment.NewService(payments, accounts)
+// connectDB connects to the database as it is.
+func connectDB(logger log.Logger) *pg.DB {
+	conn := pg.Connect(&pg.Options{
+		Addr:            cfg.DB.Address(),
+		User:            cfg.DB.User,
+		Password:        cfg.DB.Password,
+		Database:        cfg.DB.Name,
+		ApplicationName: cfg.DB.AppName,
+		PoolSize:        cfg.DB.PoolSize,
+	})
+	var queryLogger log.Logger
+	if cfg.DB.Log {
+		queryLogger = log.With(logger, "component", "db")
+	}
+	conn.AddQueryHook(db.NewQueryHook(queryLogger))
+	return conn
+}
+
+func closeDB(conn *pg.DB, logger log.Logger) {
+	if err := conn.Close(); err != nil {
+		_ = logger.Log("error", err)
+	}
+}
+
+// setupPaymentService returns the payment service on the repositories, audited calls run on
+// repositories bound to a transaction on conn.
+func setupPaymentService(conn *pg.DB, payments payment.Repository, accounts account.Repository, publisher activity.Publisher, rates payment.RateProvider, screener screening.Screener, recorder audit.Recorder, logger log.Logger) payment.Service {
+	policy := payment.ApprovalPolicy{
+		Thresholds: cfg.Approvals.Thresholds,
+		TTL:        cfg.Approvals.TTL,
+	}
+	ps := payment.NewService(payments, accounts, publisher, rates, policy, screener)
+	ps = payment.NewAuditingService(recorder, publisher, func(events activity.Publisher, fn func(payment.Service, payment.Repository, audit.Repository) error) error {
+		return db.RunInTransaction(conn, func(r *db.Repositories) error {
+			return fn(payment.NewService(r.Payments, r.Accounts, events, rates, policy, screener), r.Payments, r.Entries)
+		})
+	}, ps)
+	ps = payment.NewLoggingService(log.With(logger, "component", "payment"), ps)
+	ps = payment.NewInstrumentingService(payment.Metrics{
+		RequestCount: kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "payment_service",
+			Name:      "requests_total",
+			Help:      "Number of requests received.",
+		}, serviceFieldKeys),
+		RequestLatency: kitprometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "payment_service",
+			Name:      "request_duration_seconds",
+			Help:      "Total duration of requests in seconds.",
+		}, serviceFieldKeys),
+		Payments: kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "payments",
+			Name:      "accepted_total",
+			Help:      "Number of accepted payment requests by kind and status.",
+		}, []string{"kind", "status"}),
+		Volume: kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "payments",
+			Name:      "volume_total",
+			Help:      "Sum of executed payments by kind and source account currency.",
+		}, []string{"kind", "currency"}),
+	}, payments, ps)
+	ps = payment.NewTracingService(ps)
 	return ps
 }
 
-func setupAccountService(accounts account.Repository, logger log.Logger) account.Service {
-	as := account.NewService(accounts)
+func setupRateProvider(store payment.RateStore) payment.RateProvider {
+	rates := payment.NewRateProvider(cfg.Rates.URL, cfg.Rates.Timeout)
+	rates = payment.NewInstrumentingRateProvider(kitprometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "rates",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of exchange rate requests in seconds.",
+	}, []string{"error"}), rates)
+	rates = payment.NewStoredRateProvider(store, rates)
+	return payment.NewRateCache(rates, cfg.Rates.CacheTTL,
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "rates",
+			Name:      "cache_hits_total",
+			Help:      "Number of exchange rates served from cache.",
+		}, nil),
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "rates",
+			Name:      "cache_misses_total",
+			Help:      "Number of exchange rates requested from the provider.",
+		}, nil),
+	)
+}
+
+// setupRateLimit returns the middleware throttling API clients, by limits of every instance
+// or, if they are shared, of all instances together. Keys are charged once authenticator
+// accepts them.
+func setupRateLimit(conn *pg.DB, authenticator *auth.Authenticator, logger log.Logger) func(http.Handler) http.Handler {
+	limiter := ratelimit.NewMemoryLimiter()
+	if cfg.RateLimit.Shared {
+		limiter = db.NewRateLimiter(conn)
+	}
+	policy := ratelimit.Policy{
+		Read:       ratelimit.Limits{Key: cfg.RateLimit.KeyRead, IP: cfg.RateLimit.IPRead},
+		Money:      ratelimit.Limits{Key: cfg.RateLimit.KeyMoney, IP: cfg.RateLimit.IPMoney},
+		Classify:   classifyRequest,
+		TrustProxy: cfg.RateLimit.TrustProxy,
+		Client:     authenticator.Client,
+	}
+	return ratelimit.NewMiddleware(limiter, policy,
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "http",
+			Name:      "rate_limited_total",
+			Help:      "Number of requests rejected by rate limits, by class.",
+		}, []string{"class"}),
+		log.With(logger, "component", "ratelimit"),
+	)
+}
+
+// classifyRequest tells requests moving money, which create, split, deposit, decide on or
+// reverse payments, from the rest.
+func classifyRequest(r *http.Request) ratelimit.Class {
+	const payments = "/api/payments/v1/payments"
+	if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, payments) && r.URL.Path != payments+"/rates" {
+		return ratelimit.Money
+	}
+	return ratelimit.Read
+}
+
+func setupScreener(decisions screening.Repository, history screening.History, customers customer.Repository, logger log.Logger) screening.Screener {
+	rules := []screening.Rule{
+		&screening.Velocity{
+			History:   history,
+			Window:    cfg.Screening.VelocityWindow,
+			MaxCount:  cfg.Screening.VelocityCount,
+			MaxAmount: cfg.Screening.VelocityAmount,
+		},
+		&screening.UnusualAmount{History: history, Factor: cfg.Screening.UnusualFactor, Samples: cfg.Screening.UnusualSamples},
+	}
+	if cfg.Screening.NewCounterpartyAmount.Sign() > 0 {
+		rules = append(rules, &screening.NewCounterparty{History: history, MinAmount: cfg.Screening.NewCounterpartyAmount})
+	}
+	if countries := cfg.Screening.Countries(); len(countries) > 0 {
+		rules = append(rules, &screening.CountryBlocklist{Countries: countries})
+	}
+	if cfg.Screening.SanctionsFile != "" {
+		sanctions, err := screening.LoadSanctions(cfg.Screening.SanctionsFile, customers)
+		if err != nil {
+			_ = logger.Log("msg", "load sanctions", "error", err)
+			panic(err)
+		}
+		rules = append(rules, sanctions)
+	}
+	return screening.NewPipeline(decisions, rules...)
+}
+
+// setupAccountService returns the account service on the repositories, audited calls run on
+// repositories bound to a transaction on conn.
+func setupAccountService(conn *pg.DB, accounts account.Repository, customers customer.Repository, recorder audit.Recorder, logger log.Logger) account.Service {
+	as := account.NewService(accounts, customers)
+	as = account.NewAuditingService(recorder, func(fn func(account.Service, account.Repository, audit.Repository) error) error {
+		return db.RunInTransaction(conn, func(r *db.Repositories) error {
+			return fn(account.NewService(r.Accounts, r.Customers), r.Accounts, r.Entries)
+		})
+	}, as)
+	as = account.NewLoggingService(log.With(logger, "component", "account"), as)
+	as = account.NewInstrumentingService(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "account_service",
+			Name:      "requests_total",
+			Help:      "Number of requests received.",
+		}, serviceFieldKeys),
+		kitprometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "account_service",
+			Name:      "request_duration_seconds",
+			Help:      "Total duration of requests in seconds.",
+		}, serviceFieldKeys),
+		as,
+	)
+	as = account.NewTracingService(as)
 	return as
 }
 
-func accessControl(h http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type")
+// setupCustomerService returns the customer service on the repository, audited calls run on
+// repositories bound to a transaction on conn.
+func setupCustomerService(conn *pg.DB, customers customer.Repository, recorder audit.Recorder, logger log.Logger) customer.Service {
+	cs := customer.NewService(customers)
+	cs = customer.NewAuditingService(recorder, func(fn func(customer.Service, customer.Repository, audit.Repository) error) error {
+		return db.RunInTransaction(conn, func(r *db.Repositories) error {
+			return fn(customer.NewService(r.Customers), r.Customers, r.Entries)
+		})
+	}, cs)
+	cs = customer.NewLoggingService(log.With(logger, "component", "customer"), cs)
+	cs = customer.NewTracingService(cs)
+	return cs
+}
 
-		if r.Method == "OPTIONS" {
-			return
+func setupAuthenticator(keys auth.KeyRepository, logger log.Logger) *auth.Authenticator {
+	opts := []auth.Option{
+		auth.WithIssuer(cfg.Auth.JWTIssuer),
+		auth.WithAudience(cfg.Auth.JWTAudience),
+	}
+	if cfg.Auth.JWTSecret != "" {
+		opts = append(opts, auth.WithHMACSecret([]byte(cfg.Auth.JWTSecret)))
+	}
+	if cfg.Auth.JWKSFile != "" {
+		keys, err := auth.LoadJWKS(cfg.Auth.JWKSFile)
+		if err != nil {
+			_ = logger.Log("msg", "load JWKS", "file", cfg.Auth.JWKSFile, "error", err)
+			panic(err)
+		}
+		opts = append(opts, auth.WithRSAKeys(keys))
+	}
+	if cfg.HTTP.TLSClientCA != "" {
+		var certs auth.Certificates
+		if cfg.HTTP.TLSPrincipals != "" {
+			var err error
+			if certs, err = auth.LoadCertificates(cfg.HTTP.TLSPrincipals); err != nil {
+				_ = logger.Log("msg", "load certificate principals", "file", cfg.HTTP.TLSPrincipals, "error", err)
+				panic(err)
+			}
 		}
+		opts = append(opts, auth.WithCertificates(certs))
+	}
+	return auth.NewAuthenticator(keys, opts...)
+}
 
-		h.ServeHTTP(w, r)
-	})
+// setupTLS loads the certificates the HTTP server is served with, returning them with the
+// server configuration using them.
+func setupTLS(logger log.Logger) (*tlsreload.Reloader, *tls.Config) {
+	certs, err := tlsreload.New(cfg.HTTP.TLSCert, cfg.HTTP.TLSKey, cfg.HTTP.TLSClientCA)
+	if err != nil {
+		_ = logger.Log("msg", "load certificates", "cert", cfg.HTTP.TLSCert, "error", err)
+		panic(err)
+	}
+	config, err := certs.Config(cfg.HTTP.TLSClientAuth)
+	if err != nil {
+		_ = logger.Log("msg", "setup TLS", "error", err)
+		panic(err)
+	}
+	return certs, config
+}
+
+func setupDispatcher(events outbox.Repository, logger log.Logger) *outbox.Dispatcher {
+	d := outbox.NewDispatcher(events, log.With(logger, "component", "webhooks"))
+	d.Interval = cfg.Webhooks.Interval
+	d.MaxAttempts = cfg.Webhooks.MaxAttempts
+	d.Client.Timeout = cfg.Webhooks.Timeout
+	return d
+}
+
+// apiInfo describes the API in the OpenAPI specification.
+var apiInfo = openapi.Info{Title: "Payments API", Version: "1.0.0"}
+
+// apiSpec returns the OpenAPI specification of the HTTP API.
+func apiSpec() *openapi.Document {
+	return openapi.New(apiInfo,
+		account.Operations(),
+		customer.Operations(),
+		payment.Operations(),
+		activity.Operations(),
+		outbox.Operations(),
+		screening.Operations(),
+		audit.Operations(),
+	)
+}
+
+// apiRoutes lists the routes of the HTTP API handlers. Routes do not depend on endpoints,
+// handlers are made without any.
+func apiRoutes() ([]openapi.Route, error) {
+	nop := log.NewNopLogger()
+	return openapi.Routes(
+		account.MakeHandler(account.Endpoints{}, nop),
+		customer.MakeHandler(customer.Endpoints{}, nop),
+		payment.MakeHandler(payment.Endpoints{}, nop),
+		payment.MakeStatementHandler(payment.Endpoints{}, nop, nil),
+		activity.MakeHandler(nil, nil, nop),
+		outbox.MakeHandler(outbox.Endpoints{}, nop),
+		screening.MakeHandler(screening.Endpoints{}, nop),
+		audit.MakeHandler(audit.Endpoints{}, nop),
+	)
+}
+
+// runOpenAPI prints the OpenAPI specification, or with "check" compares it with the routes
+// of the API handlers, and returns the exit status of the command.
+func runOpenAPI(cmd string, logger log.Logger) int {
+	spec := apiSpec()
+	switch cmd {
+	case "", "print":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(spec); err != nil {
+			_ = logger.Log("msg", "encode OpenAPI specification", "error", err)
+			return 1
+		}
+		return 0
+	case "check":
+		routes, err := apiRoutes()
+		if err != nil {
+			_ = logger.Log("msg", "list routes", "error", err)
+			return 1
+		}
+		diff := openapi.Diff(spec, routes)
+		for _, d := range diff {
+			_ = logger.Log("msg", "OpenAPI specification diverges from routes", "route", d)
+		}
+		if len(diff) > 0 {
+			return 1
+		}
+		_ = logger.Log("msg", "OpenAPI specification matches routes", "routes", len(routes))
+		return 0
+	}
+	_ = logger.Log("msg", "unknown openapi command", "command", cmd)
+	return 2
+}
+
+// runConfig prints the effective configuration, secrets redacted, and returns the exit status
+// of the command. Invalid configurations are printed too, for the errors to be found.
+func runConfig(cmd string, invalid error, logger log.Logger) int {
+	if cmd != "print" {
+		_ = logger.Log("msg", "unknown config command", "command", cmd)
+		return 2
+	}
+	if cfg == nil {
+		_ = logger.Log("msg", "load configuration", "error", invalid)
+		return 2
+	}
+	if err := cfg.Print(os.Stdout); err != nil {
+		_ = logger.Log("msg", "print configuration", "error", err)
+		return 1
+	}
+	if invalid != nil {
+		_ = logger.Log("msg", "load configuration", "error", invalid)
+		return 1
+	}
+	return 0
 }
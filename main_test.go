@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ilyareist/task1/openapi"
+)
+
+func TestOpenAPIRoutes(t *testing.T) {
+	routes, err := apiRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) == 0 {
+		t.Fatal("no routes")
+	}
+	for _, d := range openapi.Diff(apiSpec(), routes) {
+		t.Error(d)
+	}
+}
@@ -0,0 +1,220 @@
+// Package openapi builds the OpenAPI 3 specification of the HTTP API from the operations
+// transports declare, and checks it against the routes they serve.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ilyareist/task1/errs"
+)
+
+// Version of OpenAPI the specification follows.
+const Version = "3.0.3"
+
+// Operation describes a route for the specification. Request and response bodies are given
+// as values of the types transports decode and encode.
+type Operation struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	// Query lists the query parameters.
+	Query []Parameter
+	// Request is the JSON body, nil when there is none.
+	Request interface{}
+	// Response is the JSON body of successful responses, nil when it is an empty object.
+	Response interface{}
+	// Stream marks Server-Sent Events responses.
+	Stream bool
+	// Formats lists media types successful responses may also come in, besides JSON.
+	Formats []string
+}
+
+// Parameter is a string parameter of an operation.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Query returns a query parameter.
+func Query(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*OpObject `json:"paths"`
+	Components Components                      `json:"components"`
+	Security   []map[string][]string           `json:"security"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpObject is an operation object of a path.
+type OpObject struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *Body                `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Body is a request body.
+type Body struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a response of an operation.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds schemas referenced by operations and the security schemes.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme is a way callers authenticate.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// New builds the specification of the operations.
+func New(info Info, ops ...[]Operation) *Document {
+	g := &generator{components: make(map[string]*Schema)}
+	problem := g.schema(reflect.TypeOf(errs.Problem{}))
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]map[string]*OpObject),
+		Components: Components{
+			Schemas: g.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"apiKey": {Type: "apiKey", Name: "X-API-Key", In: "header"},
+				"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []map[string][]string{{"apiKey": {}}, {"bearer": {}}},
+	}
+	for _, list := range ops {
+		for _, op := range list {
+			item := doc.Paths[op.Path]
+			if item == nil {
+				item = make(map[string]*OpObject)
+				doc.Paths[op.Path] = item
+			}
+			item[strings.ToLower(op.Method)] = g.operation(op, problem)
+		}
+	}
+	return doc
+}
+
+func (g *generator) operation(op Operation, problem *Schema) *OpObject {
+	o := &OpObject{
+		Summary:     op.Summary,
+		OperationID: operationID(op),
+		Responses: map[string]*Response{
+			"default": {
+				Description: "Problem details of the failure",
+				Content:     map[string]*MediaType{"application/problem+json": {Schema: problem}},
+			},
+		},
+	}
+	if op.Tag != "" {
+		o.Tags = []string{op.Tag}
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+		o.Parameters = append(o.Parameters, Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	o.Parameters = append(o.Parameters, op.Query...)
+	if op.Request != nil {
+		o.RequestBody = &Body{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: g.schema(reflect.TypeOf(op.Request))}},
+		}
+	}
+	ok := &Response{Description: "Success"}
+	switch {
+	case op.Stream:
+		ok.Content = map[string]*MediaType{"text/event-stream": {Schema: &Schema{Type: "string"}}}
+	case op.Response != nil:
+		ok.Content = map[string]*MediaType{"application/json": {Schema: g.schema(reflect.TypeOf(op.Response))}}
+	default:
+		ok.Content = map[string]*MediaType{"application/json": {Schema: &Schema{Type: "object"}}}
+	}
+	for _, format := range op.Formats {
+		ok.Content[format] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+	o.Responses["200"] = ok
+	return o
+}
+
+// operationID names an operation by its method and path, e.g. get_api_accounts_v1_accounts_id.
+func operationID(op Operation) string {
+	id := strings.ToLower(op.Method) + op.Path
+	id = strings.NewReplacer("{", "", "}", "", "/", "_", "-", "_").Replace(id)
+	return id
+}
+
+// Handler serves the document as JSON.
+func Handler(doc *Document) http.Handler {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			errs.EncodeError(r.Context(), err, w)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write(body)
+	})
+}
+
+// Operations lists method and path of every operation of the document, sorted.
+func (d *Document) Operations() []Route {
+	var routes []Route
+	for path, item := range d.Paths {
+		for method := range item {
+			routes = append(routes, Route{Method: strings.ToUpper(method), Path: path})
+		}
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+func sortRoutes(routes []Route) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+}
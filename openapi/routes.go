@@ -0,0 +1,68 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Route is a method and a path template served by a handler.
+type Route struct {
+	Method string
+	Path   string
+}
+
+func (r Route) String() string { return r.Method + " " + r.Path }
+
+// Routes lists the routes of handlers made by transports, which must be gorilla/mux routers.
+func Routes(handlers ...http.Handler) ([]Route, error) {
+	var routes []Route
+	for _, h := range handlers {
+		router, ok := h.(*mux.Router)
+		if !ok {
+			return nil, fmt.Errorf("openapi: %T is not a router", h)
+		}
+		err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			path, err := route.GetPathTemplate()
+			if err != nil {
+				return err
+			}
+			methods, err := route.GetMethods()
+			if err != nil {
+				return err
+			}
+			for _, m := range methods {
+				routes = append(routes, Route{Method: m, Path: path})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sortRoutes(routes)
+	return routes, nil
+}
+
+// Diff tells how the document and the routes diverge: routes served but not specified and
+// operations specified but not served. It is empty when they agree.
+func Diff(doc *Document, routes []Route) []string {
+	specified := make(map[Route]bool)
+	for _, r := range doc.Operations() {
+		specified[r] = true
+	}
+	var diff []string
+	for _, r := range routes {
+		if !specified[r] {
+			diff = append(diff, fmt.Sprintf("%s is served but not specified", r))
+		}
+		delete(specified, r)
+	}
+	for _, r := range doc.Operations() {
+		if specified[r] {
+			diff = append(diff, fmt.Sprintf("%s is specified but not served", r))
+		}
+	}
+	return diff
+}
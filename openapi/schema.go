@@ -0,0 +1,176 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Schema is an OpenAPI schema object, as far as JSON bodies of the API need it.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	rawType     = reflect.TypeOf(json.RawMessage{})
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// validators maps govalidator tags to schema constraints.
+var validators = map[string]func(s *Schema){
+	"email":         func(s *Schema) { s.Format = "email" },
+	"url":           func(s *Schema) { s.Format = "uri" },
+	"alphanum":      func(s *Schema) { s.Pattern = "^[a-zA-Z0-9]+$" },
+	"numeric":       func(s *Schema) { s.Pattern = "^[0-9]+$" },
+	"ISO3166Alpha2": func(s *Schema) { s.Pattern = "^[A-Z]{2}$" },
+	"decimal":       func(s *Schema) { s.Format = "decimal" },
+	"money":         func(s *Schema) { s.Pattern = `^-?[0-9]{1,12}(\.[0-9]{1,4})?$` },
+}
+
+var (
+	inTag     = regexp.MustCompile(`^in\((.*)\)$`)
+	lengthTag = regexp.MustCompile(`^stringlength\((\d+)\|(\d+)\)$`)
+)
+
+// generator derives schemas from Go types, named structs become components referenced by name.
+type generator struct {
+	components map[string]*Schema
+}
+
+// schema returns the schema of values of t as encoded by encoding/json.
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case decimalType:
+		return &Schema{Type: "string", Format: "decimal"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawType:
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := component(t)
+		if _, ok := g.components[name]; !ok {
+			// Registered before descending, for recursive types to refer to it.
+			g.components[name] = &Schema{}
+			*g.components[name] = *g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+// object returns the schema of a struct, fields of embedded structs included.
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(t, s)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type == errorType {
+			// Errors of responses are encoded as problems, never within bodies.
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, s)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := g.schema(f.Type)
+		if validate(f.Tag.Get("valid"), fs) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+}
+
+// validate applies govalidator constraints of the tag to the schema and reports whether
+// the field is required.
+func validate(tag string, s *Schema) (required bool) {
+	if tag == "" || s.Ref != "" {
+		return strings.Contains(tag, "required")
+	}
+	for _, v := range strings.Split(tag, ",") {
+		if v == "required" {
+			required = true
+			continue
+		}
+		if f, ok := validators[v]; ok {
+			f(s)
+			continue
+		}
+		if m := inTag.FindStringSubmatch(v); m != nil {
+			s.Enum = strings.Split(m[1], "|")
+			continue
+		}
+		if m := lengthTag.FindStringSubmatch(v); m != nil {
+			min, _ := strconv.Atoi(m[1])
+			max, _ := strconv.Atoi(m[2])
+			s.MinLength, s.MaxLength = &min, &max
+		}
+	}
+	return required
+}
+
+// component names a named type by its package and name.
+func component(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	return pkg + "." + t.Name()
+}
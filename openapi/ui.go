@@ -0,0 +1,40 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	swaggerFiles "github.com/swaggo/files"
+)
+
+// initializer replaces the one bundled with Swagger UI, which opens the Petstore example.
+const initializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: %q,
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// UIContentSecurityPolicy lets the bundled Swagger UI run from its own files, which set styles
+// inline and draw icons from data URLs.
+const UIContentSecurityPolicy = "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'"
+
+// UIHandler serves the bundled Swagger UI under prefix, showing the document at specURL.
+func UIHandler(prefix, specURL string) http.Handler {
+	files := http.StripPrefix(prefix, http.FileServer(swaggerFiles.HTTP))
+	js := []byte(fmt.Sprintf(initializer, specURL))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimPrefix(r.URL.Path, prefix) == "swagger-initializer.js" {
+			w.Header().Set("Content-Type", "application/javascript")
+			_, _ = w.Write(js)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,89 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/audit"
+)
+
+// Transactor runs fn in one transaction, handing it the service, the repository and the audit log
+// bound to the transaction. The transaction is committed when fn returns nil.
+type Transactor func(fn func(s Service, repo Repository, entries audit.Repository) error) error
+
+type auditingService struct {
+	recorder audit.Recorder
+	transact Transactor
+	Service
+}
+
+// NewAuditingService returns a Service recording its state-changing calls to the audit log.
+// Calls run on the service bound to a transaction of the transactor, their entries are appended
+// in the same transaction. Webhook secrets are never recorded.
+func NewAuditingService(recorder audit.Recorder, transact Transactor, s Service) Service {
+	return &auditingService{recorder: recorder, transact: transact, Service: s}
+}
+
+func (s *auditingService) RegisterWebhook(ctx context.Context, url string, secret string, events []Type) (*Webhook, error) {
+	var w *Webhook
+	err := s.record(ctx, "webhook.register", func(svc Service, repo Repository) (resource string, before, after interface{}, err error) {
+		w, err = svc.RegisterWebhook(ctx, url, secret, events)
+		if w != nil {
+			resource = w.ID.String()
+		}
+		return resource, nil, redact(w), err
+	})
+	return w, err
+}
+
+func (s *auditingService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	return s.record(ctx, "webhook.delete", func(svc Service, repo Repository) (resource string, before, after interface{}, err error) {
+		for _, w := range repo.FindWebhooks() {
+			if w.ID == id {
+				before = redact(w)
+			}
+		}
+		return id.String(), before, nil, svc.DeleteWebhook(ctx, id)
+	})
+}
+
+func (s *auditingService) Replay(ctx context.Context, id uuid.UUID) error {
+	return s.record(ctx, "delivery.replay", func(svc Service, repo Repository) (resource string, before, after interface{}, err error) {
+		before, _ = repo.FindDelivery(id)
+		if err = svc.Replay(ctx, id); err == nil {
+			after, _ = repo.FindDelivery(id)
+		}
+		return id.String(), before, after, err
+	})
+}
+
+// record runs the call and appends its entry in one transaction, so that no change is stored
+// without its entry. Failed calls are recorded too. When the transaction fails, the change is
+// rolled back and the call is recorded as failed outside of it.
+func (s *auditingService) record(ctx context.Context, action string, call func(Service, Repository) (resource string, before, after interface{}, err error)) error {
+	var resource string
+	var before interface{}
+	var callErr error
+	err := s.transact(func(svc Service, repo Repository, entries audit.Repository) error {
+		var after interface{}
+		resource, before, after, callErr = call(svc, repo)
+		return entries.Append(audit.NewEntry(ctx, action, resource, before, after, callErr))
+	})
+	if err != nil {
+		if callErr == nil {
+			callErr = err
+		}
+		s.recorder.Record(ctx, action, resource, before, nil, callErr)
+	}
+	return callErr
+}
+
+// redact returns a copy of the webhook without its secret.
+func redact(w *Webhook) *Webhook {
+	if w == nil {
+		return nil
+	}
+	c := *w
+	c.Secret = ""
+	return &c
+}
@@ -0,0 +1,139 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+)
+
+// Dispatcher delivers outbox events to registered webhooks, retrying failed
+// deliveries with exponential backoff and dead-lettering them after MaxAttempts.
+type Dispatcher struct {
+	Repo        Repository
+	Client      *http.Client
+	Logger      kitlog.Logger
+	Interval    time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	BatchSize   int
+}
+
+// NewDispatcher creates a dispatcher with default settings.
+func NewDispatcher(repo Repository, logger kitlog.Logger) *Dispatcher {
+	return &Dispatcher{
+		Repo:        repo,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		Logger:      logger,
+		Interval:    time.Second,
+		MaxAttempts: 8,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Hour,
+		BatchSize:   100,
+	}
+}
+
+// Run dispatches events until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		d.Dispatch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch performs one round of fanning events out to webhooks and delivering due ones.
+func (d *Dispatcher) Dispatch(ctx context.Context) {
+	if _, err := d.Repo.Enqueue(d.BatchSize); err != nil {
+		_ = d.Logger.Log("msg", "enqueue events", "error", err)
+	}
+	deliveries, err := d.Repo.Claim(d.BatchSize, d.Client.Timeout+d.Interval)
+	if err != nil {
+		_ = d.Logger.Log("msg", "claim deliveries", "error", err)
+		return
+	}
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		d.deliver(ctx, delivery)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *Delivery) {
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.UpdatedAt = now
+
+	if err := d.send(ctx, delivery); err != nil {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= d.MaxAttempts {
+			delivery.Status = Dead
+		} else {
+			delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+		}
+		_ = d.Logger.Log("msg", "webhook delivery failed", "delivery", delivery.ID,
+			"attempts", delivery.Attempts, "status", delivery.Status, "error", err)
+	} else {
+		delivery.Status = Delivered
+		delivery.LastError = ""
+	}
+
+	if err := d.Repo.UpdateDelivery(delivery); err != nil {
+		_ = d.Logger.Log("msg", "update delivery", "delivery", delivery.ID, "error", err)
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *Delivery) error {
+	if delivery.Event == nil || delivery.Webhook == nil {
+		return fmt.Errorf("event or webhook of delivery is gone")
+	}
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-Webhook-Event", string(delivery.Event.Type))
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(delivery.Webhook.Secret, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff returns delay before the next attempt, doubling with every attempt made.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.Backoff
+	for i := 1; i < attempts && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		delay = d.MaxBackoff
+	}
+	return delay
+}
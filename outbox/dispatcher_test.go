@@ -0,0 +1,180 @@
+package outbox_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/outbox"
+	"github.com/ilyareist/task1/outbox/webhooktest"
+)
+
+// queue is an outbox.Repository keeping events and deliveries in memory. Deliveries are due
+// when their next attempt is not after now.
+type queue struct {
+	outbox.Repository
+	webhooks   []*outbox.Webhook
+	events     []*outbox.Event
+	deliveries []*outbox.Delivery
+	now        time.Time
+}
+
+func (q *queue) Enqueue(limit int) (int, error) {
+	n := 0
+	for _, e := range q.events {
+		if e.Dispatched || n == limit {
+			continue
+		}
+		for _, w := range q.webhooks {
+			if w.Accepts(e.Type) {
+				q.deliveries = append(q.deliveries, &outbox.Delivery{ID: uuid.New(), EventID: e.ID, WebhookID: w.ID, Status: outbox.Pending, Event: e, Webhook: w})
+			}
+		}
+		e.Dispatched = true
+		n++
+	}
+	return n, nil
+}
+
+func (q *queue) Claim(limit int, lease time.Duration) ([]*outbox.Delivery, error) {
+	var due []*outbox.Delivery
+	for _, d := range q.deliveries {
+		if d.Status == outbox.Pending && !d.NextAttemptAt.After(q.now) && len(due) < limit {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (q *queue) UpdateDelivery(d *outbox.Delivery) error { return nil }
+
+// setup returns a dispatcher of an event to a webhook of the stub signing with secret.
+func setup(t *testing.T, stub *webhooktest.Server, secret string) (*outbox.Dispatcher, *queue) {
+	e, err := outbox.NewEvent(outbox.PaymentCreated, map[string]string{"id": "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := &queue{
+		webhooks: []*outbox.Webhook{{ID: uuid.New(), URL: stub.URL, Secret: secret}},
+		events:   []*outbox.Event{e},
+		now:      time.Now().UTC(),
+	}
+	d := outbox.NewDispatcher(q, log.NewNopLogger())
+	d.MaxAttempts = 4
+	d.Backoff = time.Second
+	d.MaxBackoff = 3 * time.Second
+	return d, q
+}
+
+func TestDispatchDelivers(t *testing.T) {
+	stub := webhooktest.NewServer("secret")
+	defer stub.Close()
+	d, q := setup(t, stub, "secret")
+
+	d.Dispatch(context.Background())
+	if len(q.deliveries) != 1 || !q.events[0].Dispatched {
+		t.Fatalf("got %d deliveries, event dispatched %v", len(q.deliveries), q.events[0].Dispatched)
+	}
+	delivery := q.deliveries[0]
+	if delivery.Status != outbox.Delivered || delivery.Attempts != 1 || delivery.LastError != "" {
+		t.Errorf("got %+v", delivery)
+	}
+	got := stub.Received()
+	if len(got) != 1 {
+		t.Fatalf("received %d deliveries", len(got))
+	}
+	if got[0].DeliveryID != delivery.ID.String() || got[0].Event.ID != q.events[0].ID || !got[0].ValidSignature {
+		t.Errorf("received %+v", got[0])
+	}
+
+	// Delivered events are neither enqueued nor sent again.
+	d.Dispatch(context.Background())
+	if len(q.deliveries) != 1 || len(stub.Received()) != 1 {
+		t.Errorf("redelivered: %d deliveries, %d received", len(q.deliveries), len(stub.Received()))
+	}
+}
+
+func TestDispatchRetries(t *testing.T) {
+	stub := webhooktest.NewServer("secret")
+	defer stub.Close()
+	d, q := setup(t, stub, "secret")
+	stub.FailNext(1)
+
+	d.Dispatch(context.Background())
+	delivery := q.deliveries[0]
+	if delivery.Status != outbox.Pending || delivery.Attempts != 1 || !strings.Contains(delivery.LastError, "500") {
+		t.Fatalf("got %+v", delivery)
+	}
+	if wait := delivery.NextAttemptAt.Sub(delivery.UpdatedAt); wait != d.Backoff {
+		t.Errorf("retried after %v, want %v", wait, d.Backoff)
+	}
+
+	// Nothing is sent before the next attempt is due.
+	d.Dispatch(context.Background())
+	if delivery.Attempts != 1 {
+		t.Fatalf("retried early, %d attempts", delivery.Attempts)
+	}
+
+	q.now = delivery.NextAttemptAt
+	d.Dispatch(context.Background())
+	if delivery.Status != outbox.Delivered || delivery.Attempts != 2 || delivery.LastError != "" {
+		t.Errorf("got %+v", delivery)
+	}
+	if got := stub.Received(); len(got) != 1 || !got[0].ValidSignature {
+		t.Errorf("received %+v", got)
+	}
+}
+
+func TestDispatchDeadLetters(t *testing.T) {
+	stub := webhooktest.NewServer("secret")
+	defer stub.Close()
+	d, q := setup(t, stub, "secret")
+	stub.FailNext(d.MaxAttempts + 1)
+
+	// Delays double with every attempt up to MaxBackoff.
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, wait := range want {
+		d.Dispatch(context.Background())
+		delivery := q.deliveries[0]
+		if delivery.Status != outbox.Pending || delivery.Attempts != i+1 {
+			t.Fatalf("attempt %d: got %+v", i+1, delivery)
+		}
+		if got := delivery.NextAttemptAt.Sub(delivery.UpdatedAt); got != wait {
+			t.Errorf("attempt %d: retried after %v, want %v", i+1, got, wait)
+		}
+		q.now = delivery.NextAttemptAt
+	}
+
+	d.Dispatch(context.Background())
+	delivery := q.deliveries[0]
+	if delivery.Status != outbox.Dead || delivery.Attempts != d.MaxAttempts || delivery.LastError == "" {
+		t.Fatalf("got %+v", delivery)
+	}
+	q.now = q.now.Add(time.Hour)
+	d.Dispatch(context.Background())
+	if delivery.Attempts != d.MaxAttempts || len(stub.Received()) != 0 {
+		t.Errorf("dead delivery sent again: %d attempts, %d received", delivery.Attempts, len(stub.Received()))
+	}
+}
+
+func TestDispatchSignature(t *testing.T) {
+	stub := webhooktest.NewServer("secret")
+	defer stub.Close()
+	d, _ := setup(t, stub, "other secret")
+
+	d.Dispatch(context.Background())
+	got := stub.Received()
+	if len(got) != 1 || got[0].ValidSignature {
+		t.Fatalf("received %+v", got)
+	}
+	signature := outbox.Sign("other secret", got[0].Body)
+	if !outbox.Verify("other secret", got[0].Body, signature) {
+		t.Error("signature of the body is not verified")
+	}
+	if outbox.Verify("other secret", append(got[0].Body, ' '), signature) {
+		t.Error("signature verified for another body")
+	}
+}
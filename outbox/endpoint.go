@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+)
+
+// Endpoints collects all of the endpoints that compose a webhook service.
+type Endpoints struct {
+	RegisterWebhookEndpoint endpoint.Endpoint
+	LoadWebhooksEndpoint    endpoint.Endpoint
+	DeleteWebhookEndpoint   endpoint.Endpoint
+	LoadDeliveriesEndpoint  endpoint.Endpoint
+	ReplayDeliveryEndpoint  endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints wired to the provided service, each wrapped
+// by middlewares in the given order, the first one being the outermost.
+func MakeEndpoints(s Service, mws ...endpoint.Middleware) Endpoints {
+	wrap := func(e endpoint.Endpoint) endpoint.Endpoint {
+		for i := len(mws) - 1; i >= 0; i-- {
+			e = mws[i](e)
+		}
+		return e
+	}
+	return Endpoints{
+		RegisterWebhookEndpoint: wrap(makeRegisterWebhookEndpoint(s)),
+		LoadWebhooksEndpoint:    wrap(makeLoadWebhooksEndpoint(s)),
+		DeleteWebhookEndpoint:   wrap(makeDeleteWebhookEndpoint(s)),
+		LoadDeliveriesEndpoint:  wrap(makeLoadDeliveriesEndpoint(s)),
+		ReplayDeliveryEndpoint:  wrap(makeReplayDeliveryEndpoint(s)),
+	}
+}
+
+type errorOnlyResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r errorOnlyResponse) ErrError() error { return r.Err }
+
+type registerWebhookRequest struct {
+	URL    string `json:"url" valid:"url,required"`
+	Secret string `json:"secret"`
+	Events []Type `json:"events"`
+}
+
+type registerWebhookResponse struct {
+	Webhook *Webhook `json:"webhook,omitempty"`
+	Err     error    `json:"error,omitempty"`
+}
+
+func (r registerWebhookResponse) ErrError() error { return r.Err }
+
+func makeRegisterWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(registerWebhookRequest)
+		w, err := s.RegisterWebhook(ctx, req.URL, req.Secret, req.Events)
+		return registerWebhookResponse{Webhook: w, Err: err}, nil
+	}
+}
+
+func makeLoadWebhooksEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return s.Webhooks(ctx)
+	}
+}
+
+type idRequest struct {
+	ID uuid.UUID
+}
+
+func makeDeleteWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		err := s.DeleteWebhook(ctx, req.ID)
+		return errorOnlyResponse{Err: err}, nil
+	}
+}
+
+type loadDeliveriesRequest struct {
+	Status DeliveryStatus
+}
+
+func makeLoadDeliveriesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(loadDeliveriesRequest)
+		return s.Deliveries(ctx, req.Status)
+	}
+}
+
+func makeReplayDeliveryEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		err := s.Replay(ctx, req.ID)
+		return errorOnlyResponse{Err: err}, nil
+	}
+}
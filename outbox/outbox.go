@@ -0,0 +1,110 @@
+// Package outbox provides transactional outbox of domain events and their delivery to webhooks.
+package outbox
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type of a domain event.
+type Type string
+
+const (
+	PaymentCreated   Type = "payment.created"
+	PaymentReversed  Type = "payment.reversed"
+	DepositCompleted Type = "deposit.completed"
+	AccountClosed    Type = "account.closed"
+)
+
+// Event is a domain event, stored in the outbox within the same transaction as the change it describes.
+type Event struct {
+	TableName  struct{}        `json:"-" sql:"outbox_events"`
+	ID         uuid.UUID       `json:"id" sql:"id,pk,type:varchar(36)"`
+	Type       Type            `json:"type" sql:"type,notnull,type:varchar(64)"`
+	Payload    json.RawMessage `json:"payload" sql:"payload,notnull"`
+	CreatedAt  time.Time       `json:"created_at" sql:"created_at,notnull"`
+	Dispatched bool            `json:"-" sql:"dispatched,notnull"`
+}
+
+// NewEvent creates a new event of the given type with payload serialized to JSON.
+func NewEvent(typ Type, payload interface{}) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		ID:        uuid.New(),
+		Type:      typ,
+		Payload:   data,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Webhook is an endpoint registered to receive events.
+type Webhook struct {
+	TableName struct{}  `json:"-" sql:"webhooks"`
+	ID        uuid.UUID `json:"id" sql:"id,pk,type:varchar(36)"`
+	URL       string    `json:"url" sql:"url,notnull"`
+	Secret    string    `json:"secret,omitempty" sql:"secret,notnull"`
+	Events    []Type    `json:"events,omitempty" sql:"events,array"`
+	CreatedAt time.Time `json:"created_at" sql:"created_at,notnull"`
+}
+
+// Accepts reports whether the webhook is subscribed to events of the given type.
+// Webhook without explicit subscriptions receives every event.
+func (w *Webhook) Accepts(typ Type) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, t := range w.Events {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is a state of event delivery to a webhook.
+type DeliveryStatus string
+
+const (
+	Pending   DeliveryStatus = "pending"
+	Delivered DeliveryStatus = "delivered"
+	Dead      DeliveryStatus = "dead"
+)
+
+// Delivery tracks delivery of one event to one webhook.
+type Delivery struct {
+	TableName     struct{}       `json:"-" sql:"webhook_deliveries"`
+	ID            uuid.UUID      `json:"id" sql:"id,pk,type:varchar(36)"`
+	EventID       uuid.UUID      `json:"event_id" sql:"event_id,notnull,type:varchar(36)"`
+	WebhookID     uuid.UUID      `json:"webhook_id" sql:"webhook_id,notnull,type:varchar(36)"`
+	Status        DeliveryStatus `json:"status" sql:"status,notnull,type:varchar(16)"`
+	Attempts      int            `json:"attempts" sql:"attempts,notnull"`
+	NextAttemptAt time.Time      `json:"next_attempt_at" sql:"next_attempt_at,notnull"`
+	LastError     string         `json:"last_error,omitempty" sql:"last_error"`
+	UpdatedAt     time.Time      `json:"updated_at" sql:"updated_at,notnull"`
+
+	Event   *Event   `json:"event,omitempty" sql:"-"`
+	Webhook *Webhook `json:"-" sql:"-"`
+}
+
+// SignatureHeader is the HTTP header carrying the HMAC signature of a delivered body.
+const SignatureHeader = "X-Webhook-Signature"
+
+// Sign returns HMAC-SHA256 signature of the body with the webhook secret, as sent in SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that signature matches the body signed with the webhook secret.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,142 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+)
+
+// Service is the interface that provides webhook management methods.
+// Webhooks are managed by admins only.
+type Service interface {
+	// RegisterWebhook registers an endpoint for events of given types (all events when empty).
+	// Random secret is generated when none is given; it is returned only once, on registration.
+	RegisterWebhook(ctx context.Context, url string, secret string, events []Type) (*Webhook, error)
+
+	// Webhooks returns all registered webhooks.
+	Webhooks(ctx context.Context) ([]*Webhook, error)
+
+	// DeleteWebhook unregisters a webhook.
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+
+	// Deliveries returns deliveries in the given status, dead-lettered ones for example.
+	Deliveries(ctx context.Context, status DeliveryStatus) ([]*Delivery, error)
+
+	// Replay schedules a delivery to be sent again immediately.
+	Replay(ctx context.Context, id uuid.UUID) error
+}
+
+type service struct {
+	repo Repository
+}
+
+// RegisterWebhook registers an endpoint for events of given types.
+func (s *service) RegisterWebhook(ctx context.Context, url string, secret string, events []Type) (*Webhook, error) {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(b)
+	}
+	w := &Webhook{
+		ID:        uuid.New(),
+		URL:       url,
+		Secret:    secret,
+		Events:    events,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.StoreWebhook(w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Webhooks returns all registered webhooks.
+func (s *service) Webhooks(ctx context.Context) ([]*Webhook, error) {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+	hooks := s.repo.FindWebhooks()
+	for _, w := range hooks {
+		w.Secret = ""
+	}
+	return hooks, nil
+}
+
+// DeleteWebhook unregisters a webhook.
+func (s *service) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+	return s.repo.DeleteWebhook(id)
+}
+
+// Deliveries returns deliveries in the given status.
+func (s *service) Deliveries(ctx context.Context, status DeliveryStatus) ([]*Delivery, error) {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+	return s.repo.FindDeliveries(status), nil
+}
+
+// Replay schedules a delivery to be sent again immediately.
+func (s *service) Replay(ctx context.Context, id uuid.UUID) error {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+	d, err := s.repo.FindDelivery(id)
+	if err != nil {
+		return errs.ErrUnknownDelivery
+	}
+	d.Status = Pending
+	d.Attempts = 0
+	d.LastError = ""
+	d.NextAttemptAt = time.Now().UTC()
+	d.UpdatedAt = d.NextAttemptAt
+	return s.repo.UpdateDelivery(d)
+}
+
+// NewService creates a webhook service with necessary dependencies.
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// Repository interface for outbox events, webhooks and deliveries storing.
+type Repository interface {
+	// StoreWebhook stores a new webhook.
+	StoreWebhook(w *Webhook) error
+
+	// FindWebhooks returns all registered webhooks.
+	FindWebhooks() []*Webhook
+
+	// DeleteWebhook removes a webhook with its pending deliveries.
+	DeleteWebhook(id uuid.UUID) error
+
+	// Enqueue creates pending deliveries of up to limit undispatched events for every
+	// subscribed webhook, marking those events dispatched. Returns number of events processed.
+	Enqueue(limit int) (int, error)
+
+	// Claim returns up to limit pending deliveries which are due, with Event and Webhook loaded,
+	// and postpones them by lease so concurrent dispatchers do not pick them up.
+	Claim(limit int, lease time.Duration) ([]*Delivery, error)
+
+	// FindDeliveries returns deliveries in the given status.
+	FindDeliveries(status DeliveryStatus) []*Delivery
+
+	// FindDelivery returns a delivery with specified id.
+	FindDelivery(id uuid.UUID) (*Delivery, error)
+
+	// UpdateDelivery stores delivery state.
+	UpdateDelivery(d *Delivery) error
+}
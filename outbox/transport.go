@@ -0,0 +1,123 @@
+package outbox
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/openapi"
+	"github.com/ilyareist/task1/tracing"
+	"github.com/ilyareist/task1/validate"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a handler for the webhook service endpoints.
+func MakeHandler(eps Endpoints, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(errs.EncodeError),
+		kithttp.ServerBefore(tracing.HTTPToContext, auth.HTTPToContext),
+		kithttp.ServerFinalizer(tracing.HTTPFinalizer),
+	}
+
+	registerWebhookHandler := kithttp.NewServer(
+		eps.RegisterWebhookEndpoint,
+		decodeRegisterWebhookRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	loadWebhooksHandler := kithttp.NewServer(
+		eps.LoadWebhooksEndpoint,
+		decodeEmptyRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	deleteWebhookHandler := kithttp.NewServer(
+		eps.DeleteWebhookEndpoint,
+		decodeIDRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	loadDeliveriesHandler := kithttp.NewServer(
+		eps.LoadDeliveriesEndpoint,
+		decodeLoadDeliveriesRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	replayDeliveryHandler := kithttp.NewServer(
+		eps.ReplayDeliveryEndpoint,
+		decodeIDRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	router := mux.NewRouter()
+
+	router.Handle("/api/webhooks/v1/webhooks", registerWebhookHandler).Methods("POST")
+	router.Handle("/api/webhooks/v1/webhooks", loadWebhooksHandler).Methods("GET")
+	router.Handle("/api/webhooks/v1/webhooks/{id}", deleteWebhookHandler).Methods("DELETE")
+	router.Handle("/api/webhooks/v1/deliveries", loadDeliveriesHandler).Methods("GET")
+	router.Handle("/api/webhooks/v1/deliveries/{id}/replay", replayDeliveryHandler).Methods("POST")
+
+	return router
+}
+
+// Operations describe the routes of MakeHandler for the OpenAPI specification.
+func Operations() []openapi.Operation {
+	const tag = "webhooks"
+	return []openapi.Operation{
+		{Method: "POST", Path: "/api/webhooks/v1/webhooks", Tag: tag, Summary: "Register a webhook", Request: registerWebhookRequest{}, Response: registerWebhookResponse{}},
+		{Method: "GET", Path: "/api/webhooks/v1/webhooks", Tag: tag, Summary: "List webhooks", Response: []*Webhook{}},
+		{Method: "DELETE", Path: "/api/webhooks/v1/webhooks/{id}", Tag: tag, Summary: "Delete a webhook"},
+		{Method: "GET", Path: "/api/webhooks/v1/deliveries", Tag: tag, Summary: "List deliveries",
+			Query: []openapi.Parameter{openapi.Query("status", "Delivery status")}, Response: []*Delivery{}},
+		{Method: "POST", Path: "/api/webhooks/v1/deliveries/{id}/replay", Tag: tag, Summary: "Replay a delivery"},
+	}
+}
+
+func decodeRegisterWebhookRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var body registerWebhookRequest
+	if err := validate.DecodeJSON(r, &body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func decodeEmptyRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errs.ErrInvalidArgument
+	}
+	return idRequest{ID: uid}, nil
+}
+
+func decodeLoadDeliveriesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	status := DeliveryStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "":
+		status = Dead
+	case Pending, Delivered, Dead:
+	default:
+		return nil, errs.ErrInvalidArgument
+	}
+	return loadDeliveriesRequest{Status: status}, nil
+}
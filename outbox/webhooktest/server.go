@@ -0,0 +1,80 @@
+// Package webhooktest provides a local webhook receiver for testing event delivery.
+package webhooktest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/ilyareist/task1/outbox"
+)
+
+// Received is an event delivery accepted by the stub.
+type Received struct {
+	DeliveryID     string
+	Event          outbox.Event
+	Body           []byte
+	ValidSignature bool
+}
+
+// Server is an HTTP webhook stub. It records every delivery and checks its signature
+// against Secret. It can be told to fail a number of upcoming deliveries to exercise retries.
+type Server struct {
+	*httptest.Server
+	Secret string
+
+	mu       sync.Mutex
+	received []Received
+	failures int
+}
+
+// NewServer starts a stub listening on a local port. Call Close when done.
+func NewServer(secret string) *Server {
+	s := &Server{Secret: secret}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// FailNext makes the next n deliveries respond with 500 Internal Server Error.
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+// Received returns deliveries recorded so far, failed ones excluded.
+func (s *Server) Received() []Received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Received(nil), s.received...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	rec := Received{
+		DeliveryID:     r.Header.Get("X-Webhook-Delivery"),
+		Body:           body,
+		ValidSignature: outbox.Verify(s.Secret, body, r.Header.Get(outbox.SignatureHeader)),
+	}
+	if err := json.Unmarshal(body, &rec.Event); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.received = append(s.received, rec)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,230 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/outbox"
+	"github.com/ilyareist/task1/screening"
+	"github.com/shopspring/decimal"
+
+	kitlog "github.com/go-kit/kit/log"
+)
+
+// Status of a payment request.
+type Status string
+
+const (
+	// Executed payments are stored and have moved money.
+	Executed Status = "executed"
+	// PendingApproval payments wait for a decision of a second principal.
+	PendingApproval Status = "pending_approval"
+	// PendingReview payments were sent to review by screening and wait for a decision of an operator.
+	PendingReview Status = "pending_review"
+	// Rejected payments were declined by a checker.
+	Rejected Status = "rejected"
+	// Expired payments were not decided in time.
+	Expired Status = "expired"
+	// Failed payments were approved, but could not be executed, for lack of money for example.
+	Failed Status = "failed"
+)
+
+// Receipt tells the outcome of a payment request. ID is the payment group, or the approval
+// which becomes the payment group once approved. Amount is debited from the source account,
+// or credited to the deposited one, in its Currency.
+type Receipt struct {
+	ID       uuid.UUID        `json:"id"`
+	Status   Status           `json:"status"`
+	Amount   decimal.Decimal  `json:"amount"`
+	Currency account.Currency `json:"currency"`
+}
+
+// Approval is a payment held until another principal approves it.
+// Transfers have To set, split payments have Legs; From of deposits is the credited account.
+// Payments held by screening carry the findings which sent them to review.
+type Approval struct {
+	TableName struct{}            `json:"-" sql:"payment_approvals"`
+	ID        uuid.UUID           `json:"id" sql:"id,pk,type:varchar(36)"`
+	Kind      screening.Kind      `json:"kind" sql:"kind,notnull,type:varchar(16)"`
+	From      account.ID          `json:"from" sql:"from_account,notnull,type:varchar(255)"`
+	To        account.ID          `json:"to,omitempty" sql:"to_account,type:varchar(255)"`
+	Legs      []Leg               `json:"legs,omitempty" sql:"legs"`
+	Amount    decimal.Decimal     `json:"amount" sql:"amount,notnull,type:'decimal(16,4)'"`
+	Debit     decimal.Decimal     `json:"debit" sql:"debit,notnull,type:'decimal(16,4)'"`
+	Currency  account.Currency    `json:"currency" sql:"currency,notnull,type:varchar(3)"`
+	Status    Status              `json:"status" sql:"status,notnull,type:varchar(16)"`
+	Maker     string              `json:"maker" sql:"maker,notnull,type:varchar(255)"`
+	Checker   string              `json:"checker,omitempty" sql:"checker,type:varchar(255)"`
+	Reason    string              `json:"reason,omitempty" sql:"reason,type:text"`
+	Findings  []screening.Finding `json:"findings,omitempty" sql:"findings"`
+	CreatedAt time.Time           `json:"created_at" sql:"created_at,notnull"`
+	ExpiresAt time.Time           `json:"expires_at" sql:"expires_at,notnull"`
+	DecidedAt time.Time           `json:"decided_at,omitempty" sql:"decided_at"`
+}
+
+// receipt tells the outcome of the payment described by the approval.
+func (a *Approval) receipt(status Status) Receipt {
+	return Receipt{ID: a.ID, Status: status, Amount: a.Debit, Currency: a.Currency}
+}
+
+// ApprovalPolicy decides which payments need approval of a second principal.
+type ApprovalPolicy struct {
+	// Thresholds are amounts in source account currency; payments debiting more need approval.
+	// Payments from accounts in currencies without threshold never do. There are no thresholds
+	// by account tier, as accounts have none.
+	Thresholds map[account.Currency]decimal.Decimal
+
+	// TTL is how long a payment may wait for approval before it expires.
+	TTL time.Duration
+}
+
+// requires reports whether debit of the amount in the currency needs approval.
+func (p ApprovalPolicy) requires(currency account.Currency, amount decimal.Decimal) bool {
+	threshold, ok := p.Thresholds[currency]
+	return ok && amount.GreaterThan(threshold)
+}
+
+// hold stores the payment as pending approval or review of another principal.
+// The approval keeps the id the payment was screened with.
+func (s *service) hold(ctx context.Context, a *Approval, status Status) (Receipt, error) {
+	p, _ := auth.FromContext(ctx)
+	now := time.Now().UTC()
+	a.Status = status
+	a.Maker = p.ID
+	a.CreatedAt = now
+	a.ExpiresAt = now.Add(s.policy.TTL)
+	if err := s.payments.StoreApproval(a); err != nil {
+		return Receipt{}, errs.ErrStorePayments
+	}
+	return a.receipt(status), nil
+}
+
+// Approve executes a payment pending approval or review, re-checking the balance of the source account
+// as the payment is stored, under a lock of the account.
+// The payment keeps the approval id as its group. Approved payments are not screened again.
+func (s *service) Approve(ctx context.Context, id uuid.UUID) error {
+	a, err := s.decide(ctx, id)
+	if err != nil {
+		return err
+	}
+	pending := a.Status
+	var payments []*Payment
+	switch {
+	case a.Kind == screening.Deposit:
+		payments, _, err = s.deposit(ctx, a.ID, a.From, a.Amount)
+	case len(a.Legs) > 0:
+		payments, _, err = s.split(ctx, a.ID, a.From, a.Amount, a.Legs)
+	default:
+		payments, _, err = s.transfer(ctx, a.ID, a.From, a.Amount, a.To)
+	}
+	var events []*outbox.Event
+	if err == nil {
+		events, err = paymentEvents(a.Kind, payments)
+	}
+	if err == nil {
+		a.Status = Executed
+		err = s.payments.ExecuteApproval(a, pending, events, payments...)
+		if err != nil && !errors.Is(err, errs.ErrApprovalNotPending) && !errors.Is(err, errs.ErrInsufficientMoney) {
+			err = errs.ErrStorePayments
+		}
+	}
+	if errors.Is(err, errs.ErrApprovalNotPending) {
+		return err
+	}
+	if err != nil {
+		a.Status = Failed
+		a.Reason = err.Error()
+		if uerr := s.payments.UpdateApproval(a, pending); uerr != nil {
+			return uerr
+		}
+		return err
+	}
+	s.notify(payments...)
+	return nil
+}
+
+// Reject declines a payment pending approval or review.
+func (s *service) Reject(ctx context.Context, id uuid.UUID, reason string) error {
+	a, err := s.decide(ctx, id)
+	if err != nil {
+		return err
+	}
+	pending := a.Status
+	a.Status = Rejected
+	a.Reason = reason
+	return s.payments.UpdateApproval(a, pending)
+}
+
+// decide loads an approval the principal of the call may decide on, recording the principal as checker.
+// Overdue approvals are expired on the way.
+func (s *service) decide(ctx context.Context, id uuid.UUID) (*Approval, error) {
+	p, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator)
+	if err != nil {
+		return nil, err
+	}
+	a, err := s.payments.FindApproval(id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status != PendingApproval && a.Status != PendingReview {
+		return nil, errs.ErrApprovalNotPending
+	}
+	if a.Maker == p.ID {
+		return nil, errs.ErrSelfApproval
+	}
+	now := time.Now().UTC()
+	if now.After(a.ExpiresAt) {
+		pending := a.Status
+		a.Status = Expired
+		if err := s.payments.UpdateApproval(a, pending); err != nil {
+			return nil, err
+		}
+		return nil, errs.ErrApprovalExpired
+	}
+	a.Checker = p.ID
+	a.DecidedAt = now
+	return a, nil
+}
+
+// Approvals returns payment approvals in the given status. Customers see only those they made.
+func (s *service) Approvals(ctx context.Context, status Status) ([]*Approval, error) {
+	p, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor, auth.RoleCustomer)
+	if err != nil {
+		return nil, err
+	}
+	approvals := s.payments.FindApprovals(status)
+	if p.HasRole(auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor) {
+		return approvals, nil
+	}
+	own := approvals[:0]
+	for _, a := range approvals {
+		if a.Maker == p.ID {
+			own = append(own, a)
+		}
+	}
+	return own, nil
+}
+
+// ExpireApprovals marks overdue payment approvals as expired every interval, until ctx is done.
+func ExpireApprovals(ctx context.Context, payments Repository, interval time.Duration, logger kitlog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := payments.ExpireApprovals(time.Now().UTC())
+			if err != nil {
+				_ = logger.Log("msg", "expire approvals", "error", err)
+			} else if n > 0 {
+				_ = logger.Log("msg", "approvals expired", "count", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/activity"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/outbox"
+	"github.com/ilyareist/task1/screening"
+	"github.com/shopspring/decimal"
+)
+
+// accounts is an account.Repository finding accounts in memory.
+type accounts struct {
+	account.Repository
+	found map[account.ID]*account.Account
+}
+
+func (r accounts) Find(id account.ID) (*account.Account, error) {
+	a, ok := r.found[id]
+	if !ok {
+		return nil, errs.ErrUnknownAccount
+	}
+	return a, nil
+}
+
+// payments is a Repository keeping approvals in memory. Storing payments fails with storeErr,
+// as it does when a debited account no longer holds the money under its lock.
+type payments struct {
+	Repository
+	approvals map[uuid.UUID]*Approval
+	stored    []*Payment
+	storeErr  error
+}
+
+func (r *payments) Store(events []*outbox.Event, p ...*Payment) error {
+	if r.storeErr != nil {
+		return r.storeErr
+	}
+	r.stored = append(r.stored, p...)
+	return nil
+}
+
+func (r *payments) StoreApproval(a *Approval) error { r.approvals[a.ID] = a; return nil }
+
+func (r *payments) FindApproval(id uuid.UUID) (*Approval, error) {
+	a, ok := r.approvals[id]
+	if !ok {
+		return nil, errs.ErrUnknownApproval
+	}
+	found := *a
+	return &found, nil
+}
+
+func (r *payments) UpdateApproval(a *Approval, status Status) error {
+	if r.approvals[a.ID].Status != status {
+		return errs.ErrApprovalNotPending
+	}
+	r.approvals[a.ID] = a
+	return nil
+}
+
+func (r *payments) ExecuteApproval(a *Approval, status Status, events []*outbox.Event, p ...*Payment) error {
+	if r.approvals[a.ID].Status != status {
+		return errs.ErrApprovalNotPending
+	}
+	if r.storeErr != nil {
+		return r.storeErr
+	}
+	r.approvals[a.ID] = a
+	r.stored = append(r.stored, p...)
+	return nil
+}
+
+type allow struct{}
+
+func (allow) Screen(ctx context.Context, s *screening.Subject) (*screening.Screening, error) {
+	return &screening.Screening{Decision: screening.Allow}, nil
+}
+
+type publisher struct{}
+
+func (publisher) Publish(activity.Event) {}
+
+func newTestService(repo *payments) Service {
+	accts := accounts{found: map[account.ID]*account.Account{
+		"alice": {ID: "alice", Balance: d("1000"), Currency: account.CurrencyUSD},
+		"bob":   {ID: "bob", Balance: d("0"), Currency: account.CurrencyUSD},
+	}}
+	policy := ApprovalPolicy{Thresholds: map[account.Currency]decimal.Decimal{account.CurrencyUSD: d("500")}, TTL: time.Hour}
+	return NewService(repo, accts, publisher{}, nil, policy, allow{})
+}
+
+func as(id string, roles ...auth.Role) context.Context {
+	return auth.NewContext(context.Background(), auth.Principal{ID: id, Roles: roles})
+}
+
+func TestApprove(t *testing.T) {
+	repo := &payments{approvals: map[uuid.UUID]*Approval{}}
+	s := newTestService(repo)
+
+	r, err := s.New(as("maker", auth.RoleOperator), "alice", d("600"), "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Status != PendingApproval || len(repo.stored) != 0 {
+		t.Fatalf("got %+v, stored %d", r, len(repo.stored))
+	}
+	if err := s.Approve(as("maker", auth.RoleOperator), r.ID); !errors.Is(err, errs.ErrSelfApproval) {
+		t.Errorf("self approval: got %v", err)
+	}
+	if err := s.Approve(as("checker", auth.RoleOperator), r.ID); err != nil {
+		t.Fatal(err)
+	}
+	if a := repo.approvals[r.ID]; a.Status != Executed || a.Checker != "checker" || len(repo.stored) != 2 {
+		t.Errorf("got %+v, stored %d", a, len(repo.stored))
+	}
+	if err := s.Approve(as("checker", auth.RoleOperator), r.ID); !errors.Is(err, errs.ErrApprovalNotPending) {
+		t.Errorf("second approval: got %v", err)
+	}
+}
+
+func TestApproveOverdrawn(t *testing.T) {
+	repo := &payments{approvals: map[uuid.UUID]*Approval{}}
+	s := newTestService(repo)
+	r, err := s.New(as("maker", auth.RoleOperator), "alice", d("600"), "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another payment spent the money after the balance was checked, before the approval was stored.
+	repo.storeErr = errs.ErrInsufficientMoney
+	if err := s.Approve(as("checker", auth.RoleOperator), r.ID); !errors.Is(err, errs.ErrInsufficientMoney) {
+		t.Errorf("got %v, want %v", err, errs.ErrInsufficientMoney)
+	}
+	if a := repo.approvals[r.ID]; a.Status != Failed || a.Reason == "" {
+		t.Errorf("got %+v", a)
+	}
+}
+
+func TestNewOverdrawn(t *testing.T) {
+	repo := &payments{approvals: map[uuid.UUID]*Approval{}, storeErr: errs.ErrInsufficientMoney}
+	s := newTestService(repo)
+	if _, err := s.New(as("maker", auth.RoleOperator), "alice", d("100"), "bob"); !errors.Is(err, errs.ErrInsufficientMoney) {
+		t.Errorf("got %v, want %v", err, errs.ErrInsufficientMoney)
+	}
+	repo.storeErr = errors.New("connection reset")
+	if _, err := s.New(as("maker", auth.RoleOperator), "alice", d("100"), "bob"); !errors.Is(err, errs.ErrStorePayments) {
+		t.Errorf("got %v, want %v", err, errs.ErrStorePayments)
+	}
+	if _, err := s.New(as("maker", auth.RoleOperator), "alice", d("1001"), "bob"); !errors.Is(err, errs.ErrInsufficientMoney) {
+		t.Errorf("got %v, want %v", err, errs.ErrInsufficientMoney)
+	}
+}
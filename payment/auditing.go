@@ -0,0 +1,133 @@
+package payment
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/activity"
+	"github.com/ilyareist/task1/audit"
+	"github.com/shopspring/decimal"
+)
+
+// Transactor runs fn in one transaction, handing it the service publishing activity to the
+// publisher, the payments and the audit log bound to the transaction. The transaction is
+// committed when fn returns nil.
+type Transactor func(publisher activity.Publisher, fn func(s Service, payments Repository, entries audit.Repository) error) error
+
+type auditingService struct {
+	recorder  audit.Recorder
+	publisher activity.Publisher
+	transact  Transactor
+	Service
+}
+
+// NewAuditingService returns a Service recording its state-changing calls to the audit log.
+// Payments are recorded with the request and its receipt, decisions with the approval around them.
+// Calls run on the service bound to a transaction of the transactor, their entries are appended
+// in the same transaction. Activity of the calls is published to the publisher once the
+// transaction commits.
+func NewAuditingService(recorder audit.Recorder, publisher activity.Publisher, transact Transactor, s Service) Service {
+	return &auditingService{recorder: recorder, publisher: publisher, transact: transact, Service: s}
+}
+
+// request is the recorded state of a payment request.
+type request struct {
+	From    account.ID      `json:"from,omitempty"`
+	To      account.ID      `json:"to,omitempty"`
+	Account account.ID      `json:"account,omitempty"`
+	Legs    []Leg           `json:"legs,omitempty"`
+	Amount  decimal.Decimal `json:"amount"`
+	Receipt *Receipt        `json:"receipt,omitempty"`
+}
+
+func (s *auditingService) New(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, toAccountID account.ID) (Receipt, error) {
+	return s.request(ctx, "payment.create", request{From: fromAccountID, To: toAccountID, Amount: amount}, func(svc Service) (Receipt, error) {
+		return svc.New(ctx, fromAccountID, amount, toAccountID)
+	})
+}
+
+func (s *auditingService) Split(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, legs []Leg) (Receipt, error) {
+	return s.request(ctx, "payment.split", request{From: fromAccountID, Legs: legs, Amount: amount}, func(svc Service) (Receipt, error) {
+		return svc.Split(ctx, fromAccountID, amount, legs)
+	})
+}
+
+func (s *auditingService) Deposit(ctx context.Context, accountID account.ID, amount decimal.Decimal) (Receipt, error) {
+	return s.request(ctx, "payment.deposit", request{Account: accountID, Amount: amount}, func(svc Service) (Receipt, error) {
+		return svc.Deposit(ctx, accountID, amount)
+	})
+}
+
+func (s *auditingService) Approve(ctx context.Context, id uuid.UUID) error {
+	return s.record(ctx, "payment.approve", func(svc Service, payments Repository) (resource string, before, after interface{}, err error) {
+		before = find(payments, id)
+		err = svc.Approve(ctx, id)
+		return id.String(), before, find(payments, id), err
+	})
+}
+
+func (s *auditingService) Reject(ctx context.Context, id uuid.UUID, reason string) error {
+	return s.record(ctx, "payment.reject", func(svc Service, payments Repository) (resource string, before, after interface{}, err error) {
+		before = find(payments, id)
+		err = svc.Reject(ctx, id, reason)
+		return id.String(), before, find(payments, id), err
+	})
+}
+
+func (s *auditingService) Reverse(ctx context.Context, id uuid.UUID) (Receipt, error) {
+	var rcpt Receipt
+	err := s.record(ctx, "payment.reverse", func(svc Service, payments Repository) (resource string, before, after interface{}, err error) {
+		if rcpt, err = svc.Reverse(ctx, id); err == nil {
+			after = &rcpt
+		}
+		return id.String(), nil, after, err
+	})
+	return rcpt, err
+}
+
+// request records a payment request, identified by its receipt once there is one.
+func (s *auditingService) request(ctx context.Context, action string, req request, call func(Service) (Receipt, error)) (Receipt, error) {
+	var rcpt Receipt
+	err := s.record(ctx, action, func(svc Service, payments Repository) (resource string, before, after interface{}, err error) {
+		if rcpt, err = call(svc); err == nil {
+			resource = rcpt.ID.String()
+			req.Receipt = &rcpt
+		}
+		return resource, nil, req, err
+	})
+	return rcpt, err
+}
+
+// record runs the call and appends its entry in one transaction, so that no change is stored
+// without its entry. Failed calls are recorded too. When the transaction fails, the change is
+// rolled back, its activity is never published, and the call is recorded as failed outside of it.
+func (s *auditingService) record(ctx context.Context, action string, call func(Service, Repository) (resource string, before, after interface{}, err error)) error {
+	var resource string
+	var before interface{}
+	var callErr error
+	var events activity.Buffer
+	err := s.transact(&events, func(svc Service, payments Repository, entries audit.Repository) error {
+		var after interface{}
+		resource, before, after, callErr = call(svc, payments)
+		return entries.Append(audit.NewEntry(ctx, action, resource, before, after, callErr))
+	})
+	if err == nil {
+		events.Flush(s.publisher)
+		return callErr
+	}
+	if callErr == nil {
+		callErr = err
+	}
+	s.recorder.Record(ctx, action, resource, before, nil, callErr)
+	return callErr
+}
+
+// find returns the approval for the record, nil when there is none.
+func find(payments Repository, id uuid.UUID) *Approval {
+	a, err := payments.FindApproval(id)
+	if err != nil {
+		return nil
+	}
+	return a
+}
@@ -0,0 +1,77 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/activity"
+	"github.com/ilyareist/task1/audit"
+	"github.com/ilyareist/task1/auth"
+)
+
+// feed is a Publisher keeping the events published.
+type feed []activity.Event
+
+func (f *feed) Publish(e activity.Event) { *f = append(*f, e) }
+
+// entries is an audit log failing appends with err.
+type entries struct {
+	audit.Repository
+	appended []*audit.Entry
+	err      error
+}
+
+func (l *entries) Append(e *audit.Entry) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.appended = append(l.appended, e)
+	return nil
+}
+
+// recorder records entries outside of transactions.
+type recorder []*audit.Entry
+
+func (r *recorder) Record(ctx context.Context, action, resource string, before, after interface{}, err error) {
+	*r = append(*r, audit.NewEntry(ctx, action, resource, before, after, err))
+}
+
+func TestAuditingServicePublishesOnCommit(t *testing.T) {
+	storeErr := errors.New("connection reset")
+	tests := []struct {
+		name      string
+		appendErr error
+		published bool
+	}{
+		{"committed", nil, true},
+		{"failed append", storeErr, false},
+	}
+	for _, tt := range tests {
+		log := &entries{err: tt.appendErr}
+		var published feed
+		var rec recorder
+		transact := func(events activity.Publisher, fn func(Service, Repository, audit.Repository) error) error {
+			repo := &payments{approvals: map[uuid.UUID]*Approval{}}
+			accts := accounts{found: map[account.ID]*account.Account{
+				"alice": {ID: "alice", Balance: d("1000"), Currency: account.CurrencyUSD},
+				"bob":   {ID: "bob", Balance: d("0"), Currency: account.CurrencyUSD},
+			}}
+			return fn(NewService(repo, accts, events, nil, ApprovalPolicy{}, allow{}), repo, log)
+		}
+		s := NewAuditingService(&rec, &published, transact, nil)
+
+		_, err := s.New(as("alice", auth.RoleOperator), "alice", d("100"), "bob")
+		if !errors.Is(err, tt.appendErr) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.appendErr)
+		}
+		if got := len(published) > 0; got != tt.published {
+			t.Errorf("%s: published %d events", tt.name, len(published))
+		}
+		if !tt.published && (len(rec) != 1 || rec[0].Error == "") {
+			t.Errorf("%s: recorded %+v outside of the transaction", tt.name, rec)
+		}
+	}
+}
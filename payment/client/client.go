@@ -0,0 +1,421 @@
+// Package client provides payment.Service implementation talking to a remote
+// payment service over its HTTP API.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/kit/sd/lb"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/payment"
+	"github.com/shopspring/decimal"
+)
+
+// Option configures a client.
+type Option func(*options)
+
+type options struct {
+	httpClient *http.Client
+	timeout    time.Duration
+	retries    int
+	before     []kithttp.RequestFunc
+}
+
+// WithHTTPClient sets HTTP client used for requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) { o.httpClient = c }
+}
+
+// WithAPIKey authenticates every call with the API key.
+func WithAPIKey(key string) Option {
+	return func(o *options) { o.before = append(o.before, kithttp.SetRequestHeader("X-API-Key", key)) }
+}
+
+// WithBearerToken authenticates every call with the JWT bearer token.
+func WithBearerToken(token string) Option {
+	return func(o *options) {
+		o.before = append(o.before, kithttp.SetRequestHeader("Authorization", "Bearer "+token))
+	}
+}
+
+// WithTimeout sets time limit for one call, all its retries included.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithRetries sets how many times idempotent calls are attempted on transport or server failure.
+// Calls moving money are never retried.
+func WithRetries(n int) Option {
+	return func(o *options) { o.retries = n }
+}
+
+type client struct {
+	timeout time.Duration
+
+	newPayment endpoint.Endpoint
+	split      endpoint.Endpoint
+	deposit    endpoint.Endpoint
+	rates      endpoint.Endpoint
+	load       endpoint.Endpoint
+	statement  endpoint.Endpoint
+	loadAll    endpoint.Endpoint
+	approve    endpoint.Endpoint
+	reject     endpoint.Endpoint
+	approvals  endpoint.Endpoint
+	reverse    endpoint.Endpoint
+}
+
+// New returns a payment.Service backed by the HTTP API at instance, e.g. "http://payments:8080".
+func New(instance string, opts ...Option) (payment.Service, error) {
+	o := options{
+		httpClient: http.DefaultClient,
+		timeout:    10 * time.Second,
+		retries:    3,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if !strings.HasPrefix(instance, "http") {
+		instance = "http://" + instance
+	}
+	base, err := url.Parse(instance)
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSuffix(base.Path, "/")
+	base.Path = root + "/api/payments/v1"
+	target := func(path string) *url.URL {
+		u := *base
+		u.Path += "/payments" + path
+		return &u
+	}
+	approvals := *base
+	approvals.Path += "/approvals"
+	accounts := *base
+	accounts.Path = root + "/api/accounts/v1/accounts"
+
+	copts := []kithttp.ClientOption{
+		kithttp.SetClient(o.httpClient),
+		kithttp.ClientBefore(o.before...),
+	}
+	idempotent := func(e endpoint.Endpoint) endpoint.Endpoint {
+		return retry(e, o.retries, o.timeout)
+	}
+
+	return &client{
+		timeout: o.timeout,
+		newPayment: kithttp.NewClient(
+			"POST", target(""), kithttp.EncodeJSONRequest, decodeReceiptResponse, copts...,
+		).Endpoint(),
+		split: kithttp.NewClient(
+			"POST", target("/split"), kithttp.EncodeJSONRequest, decodeReceiptResponse, copts...,
+		).Endpoint(),
+		deposit: kithttp.NewClient(
+			"POST", target("/deposit"), kithttp.EncodeJSONRequest, decodeReceiptResponse, copts...,
+		).Endpoint(),
+		rates: idempotent(kithttp.NewClient(
+			"POST", target("/rates"), kithttp.EncodeJSONRequest, decodeRateResponse, copts...,
+		).Endpoint()),
+		load: idempotent(kithttp.NewClient(
+			"GET", target(""), encodeAccountIDRequest, decodePaymentsResponse, copts...,
+		).Endpoint()),
+		statement: idempotent(kithttp.NewClient(
+			"GET", &accounts, encodeStatementRequest, decodeStatementResponse, copts...,
+		).Endpoint()),
+		loadAll: idempotent(kithttp.NewClient(
+			"GET", target(""), encodeEmptyRequest, decodePaymentsResponse, copts...,
+		).Endpoint()),
+		approve: kithttp.NewClient(
+			"POST", target(""), encodeApprovalRequest("approve"), decodeErrorOnlyResponse, copts...,
+		).Endpoint(),
+		reject: kithttp.NewClient(
+			"POST", target(""), encodeApprovalRequest("reject"), decodeErrorOnlyResponse, copts...,
+		).Endpoint(),
+		approvals: idempotent(kithttp.NewClient(
+			"GET", &approvals, encodeStatusRequest, decodeApprovalsResponse, copts...,
+		).Endpoint()),
+		reverse: kithttp.NewClient(
+			"POST", target(""), encodeReverseRequest, decodeReceiptResponse, copts...,
+		).Endpoint(),
+	}, nil
+}
+
+// New registers a new payment in the system.
+func (c *client) New(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, toAccountID account.ID) (payment.Receipt, error) {
+	resp, err := c.call(ctx, c.newPayment, newPaymentRequest{
+		FromAccountID: fromAccountID,
+		Amount:        amount,
+		ToAccountID:   toAccountID,
+	})
+	if err != nil {
+		return payment.Receipt{}, err
+	}
+	return resp.(payment.Receipt), nil
+}
+
+// Split debits one account once and credits every leg target within one payment.
+func (c *client) Split(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, legs []payment.Leg) (payment.Receipt, error) {
+	req := splitPaymentRequest{
+		FromAccountID: fromAccountID,
+		Amount:        amount,
+		Legs:          make([]splitLeg, 0, len(legs)),
+	}
+	for _, l := range legs {
+		req.Legs = append(req.Legs, splitLeg{To: l.To, Amount: l.Amount, Percent: l.Percent})
+	}
+	resp, err := c.call(ctx, c.split, req)
+	if err != nil {
+		return payment.Receipt{}, err
+	}
+	return resp.(payment.Receipt), nil
+}
+
+// Approve executes a payment pending approval.
+func (c *client) Approve(ctx context.Context, id uuid.UUID) error {
+	_, err := c.call(ctx, c.approve, approvalRequest{ID: id})
+	return err
+}
+
+// Reject declines a payment pending approval.
+func (c *client) Reject(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := c.call(ctx, c.reject, approvalRequest{ID: id, Reason: reason})
+	return err
+}
+
+// Approvals returns payment approvals in the given status.
+func (c *client) Approvals(ctx context.Context, status payment.Status) ([]*payment.Approval, error) {
+	resp, err := c.call(ctx, c.approvals, status)
+	if err != nil {
+		return nil, err
+	}
+	return resp.([]*payment.Approval), nil
+}
+
+// Reverse undoes an executed payment by a new one moving every leg back.
+func (c *client) Reverse(ctx context.Context, id uuid.UUID) (payment.Receipt, error) {
+	resp, err := c.call(ctx, c.reverse, id)
+	if err != nil {
+		return payment.Receipt{}, err
+	}
+	return resp.(payment.Receipt), nil
+}
+
+// Deposit adds money to an account.
+func (c *client) Deposit(ctx context.Context, accountID account.ID, amount decimal.Decimal) (payment.Receipt, error) {
+	resp, err := c.call(ctx, c.deposit, depositRequest{AccountID: accountID, Amount: amount})
+	if err != nil {
+		return payment.Receipt{}, err
+	}
+	return resp.(payment.Receipt), nil
+}
+
+// Rates returns the rate of a currency on a date.
+func (c *client) Rates(ctx context.Context, currency string, date string) (payment.Rate, error) {
+	resp, err := c.call(ctx, c.rates, payment.RatesCurrencyRequest{Currency: currency, Date: date})
+	if err != nil {
+		return payment.Rate{}, err
+	}
+	return resp.(payment.Rate), nil
+}
+
+// Load returns payments list for an account.
+func (c *client) Load(ctx context.Context, accountID account.ID) ([]*payment.Payment, error) {
+	resp, err := c.call(ctx, c.load, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return resp.([]*payment.Payment), nil
+}
+
+// Statement returns the statement of an account over [from, to).
+func (c *client) Statement(ctx context.Context, accountID account.ID, from, to time.Time) (*payment.Statement, error) {
+	resp, err := c.call(ctx, c.statement, statementRequest{AccountID: accountID, From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*payment.Statement), nil
+}
+
+// LoadAll returns all payments registered in the system, nil when the call fails.
+func (c *client) LoadAll(ctx context.Context) []*payment.Payment {
+	resp, err := c.call(ctx, c.loadAll, nil)
+	if err != nil {
+		return nil
+	}
+	return resp.([]*payment.Payment)
+}
+
+func (c *client) call(ctx context.Context, e endpoint.Endpoint, request interface{}) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := e(ctx, request)
+	if re, ok := err.(lb.RetryError); ok {
+		err = re.Final
+	}
+	return resp, err
+}
+
+// retry repeats calls of the endpoint failed by transport or server errors, up to max attempts.
+func retry(e endpoint.Endpoint, max int, timeout time.Duration) endpoint.Endpoint {
+	balancer := lb.NewRoundRobin(sd.FixedEndpointer{e})
+	return lb.RetryWithCallback(timeout, balancer, func(n int, err error) (bool, error) {
+		return n < max && errs.Retryable(err), nil
+	})
+}
+
+type newPaymentRequest struct {
+	FromAccountID account.ID      `json:"from"`
+	Amount        decimal.Decimal `json:"amount"`
+	ToAccountID   account.ID      `json:"to"`
+}
+
+type splitLeg struct {
+	To      account.ID      `json:"to"`
+	Amount  decimal.Decimal `json:"amount"`
+	Percent decimal.Decimal `json:"percent"`
+}
+
+type splitPaymentRequest struct {
+	FromAccountID account.ID      `json:"from"`
+	Amount        decimal.Decimal `json:"amount"`
+	Legs          []splitLeg      `json:"legs"`
+}
+
+type depositRequest struct {
+	AccountID account.ID      `json:"account"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+type statementRequest struct {
+	AccountID account.ID
+	From, To  time.Time
+}
+
+type approvalRequest struct {
+	ID     uuid.UUID `json:"-"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// addPath appends segments to the path of u, each escaped once.
+func addPath(u *url.URL, segments ...string) {
+	raw := u.EscapedPath()
+	for _, s := range segments {
+		u.Path += "/" + s
+		raw += "/" + url.PathEscape(s)
+	}
+	u.RawPath = raw
+}
+
+// encodeApprovalRequest returns an encoder of decisions posted to the given action of a payment.
+func encodeApprovalRequest(action string) kithttp.EncodeRequestFunc {
+	return func(ctx context.Context, r *http.Request, request interface{}) error {
+		req := request.(approvalRequest)
+		addPath(r.URL, req.ID.String(), action)
+		return kithttp.EncodeJSONRequest(ctx, r, req)
+	}
+}
+
+func encodeReverseRequest(_ context.Context, r *http.Request, request interface{}) error {
+	addPath(r.URL, request.(uuid.UUID).String(), "reverse")
+	return nil
+}
+
+func encodeStatusRequest(_ context.Context, r *http.Request, request interface{}) error {
+	if status := request.(payment.Status); status != "" {
+		r.URL.RawQuery = url.Values{"status": {string(status)}}.Encode()
+	}
+	return nil
+}
+
+func encodeAccountIDRequest(_ context.Context, r *http.Request, request interface{}) error {
+	addPath(r.URL, string(request.(account.ID)))
+	return nil
+}
+
+func encodeStatementRequest(_ context.Context, r *http.Request, request interface{}) error {
+	req := request.(statementRequest)
+	addPath(r.URL, string(req.AccountID), "statement")
+	q := url.Values{"format": {"json"}}
+	if !req.From.IsZero() {
+		q.Set("from", req.From.Format(time.RFC3339Nano))
+	}
+	if !req.To.IsZero() {
+		q.Set("to", req.To.Format(time.RFC3339Nano))
+	}
+	r.URL.RawQuery = q.Encode()
+	return nil
+}
+
+func encodeEmptyRequest(_ context.Context, _ *http.Request, _ interface{}) error {
+	return nil
+}
+
+func decodeErrorOnlyResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	return nil, errs.DecodeErrorResponse(r)
+}
+
+func decodeReceiptResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if err := errs.DecodeErrorResponse(r); err != nil {
+		return nil, err
+	}
+	var rcpt payment.Receipt
+	if err := json.NewDecoder(r.Body).Decode(&rcpt); err != nil {
+		return nil, err
+	}
+	return rcpt, nil
+}
+
+func decodeApprovalsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if err := errs.DecodeErrorResponse(r); err != nil {
+		return nil, err
+	}
+	var approvals []*payment.Approval
+	if err := json.NewDecoder(r.Body).Decode(&approvals); err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+func decodeRateResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if err := errs.DecodeErrorResponse(r); err != nil {
+		return nil, err
+	}
+	var rate payment.Rate
+	if err := json.NewDecoder(r.Body).Decode(&rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func decodePaymentsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if err := errs.DecodeErrorResponse(r); err != nil {
+		return nil, err
+	}
+	var payments []*payment.Payment
+	if err := json.NewDecoder(r.Body).Decode(&payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func decodeStatementResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if err := errs.DecodeErrorResponse(r); err != nil {
+		return nil, err
+	}
+	var st payment.Statement
+	if err := json.NewDecoder(r.Body).Decode(&st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
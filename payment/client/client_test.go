@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/payment"
+	"github.com/shopspring/decimal"
+)
+
+// call is a request the server received.
+type call struct {
+	method, path, query, auth string
+	body                      map[string]interface{}
+}
+
+// server answers every request with status and body, or with err encoded as a problem, and
+// records the requests.
+type server struct {
+	status int
+	body   string
+	err    error
+	calls  []call
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c := call{method: r.Method, path: r.URL.EscapedPath(), query: r.URL.RawQuery, auth: r.Header.Get("Authorization")}
+	if b, _ := ioutil.ReadAll(r.Body); len(b) > 0 {
+		_ = json.Unmarshal(b, &c.body)
+	}
+	s.calls = append(s.calls, c)
+	if s.err != nil {
+		errs.EncodeError(r.Context(), s.err, w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(s.status)
+	_, _ = w.Write([]byte(s.body))
+}
+
+// setup returns a client of a server answering with status and body.
+func setup(t *testing.T, status int, body string) (payment.Service, *server, func()) {
+	s := &server{status: status, body: body}
+	srv := httptest.NewServer(s)
+	c, err := New(srv.URL, WithBearerToken("token"), WithRetries(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, s, srv.Close
+}
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func TestRequests(t *testing.T) {
+	id := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	from := time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		call        func(s payment.Service) error
+		method      string
+		path, query string
+		body        map[string]interface{}
+	}{
+		{"new", func(s payment.Service) error {
+			_, err := s.New(ctx, "alice", d("10.5"), "bob")
+			return err
+		}, "POST", "/api/payments/v1/payments", "", map[string]interface{}{"from": "alice", "amount": "10.5", "to": "bob"}},
+		{"split", func(s payment.Service) error {
+			_, err := s.Split(ctx, "alice", d("100"), []payment.Leg{{To: "bob", Percent: d("25")}, {To: "carol", Amount: d("75")}})
+			return err
+		}, "POST", "/api/payments/v1/payments/split", "", map[string]interface{}{
+			"from": "alice", "amount": "100",
+			"legs": "[map[amount:0 percent:25 to:bob] map[amount:75 percent:0 to:carol]]",
+		}},
+		{"deposit", func(s payment.Service) error {
+			_, err := s.Deposit(ctx, "alice", d("5"))
+			return err
+		}, "POST", "/api/payments/v1/payments/deposit", "", map[string]interface{}{"account": "alice", "amount": "5"}},
+		{"reverse", func(s payment.Service) error {
+			_, err := s.Reverse(ctx, id)
+			return err
+		}, "POST", "/api/payments/v1/payments/" + id.String() + "/reverse", "", nil},
+		{"approve", func(s payment.Service) error { return s.Approve(ctx, id) },
+			"POST", "/api/payments/v1/payments/" + id.String() + "/approve", "", nil},
+		{"reject", func(s payment.Service) error { return s.Reject(ctx, id, "no") },
+			"POST", "/api/payments/v1/payments/" + id.String() + "/reject", "", map[string]interface{}{"reason": "no"}},
+		{"rates", func(s payment.Service) error {
+			_, err := s.Rates(ctx, "EUR", "2019-10-01")
+			return err
+		}, "POST", "/api/payments/v1/payments/rates", "", map[string]interface{}{"currency": "EUR", "date": "2019-10-01"}},
+		{"statement", func(s payment.Service) error {
+			_, err := s.Statement(ctx, "John Doe", from, time.Time{})
+			return err
+		}, "GET", "/api/accounts/v1/accounts/John%20Doe/statement", "format=json&from=2019-10-01T00%3A00%3A00Z", nil},
+	}
+	for _, tt := range tests {
+		c, s, done := setup(t, http.StatusOK, "{}")
+		err := tt.call(c)
+		done()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(s.calls) != 1 {
+			t.Errorf("%s: %d requests", tt.name, len(s.calls))
+			continue
+		}
+		got := s.calls[0]
+		if got.method != tt.method || got.path != tt.path || got.query != tt.query || got.auth != "Bearer token" {
+			t.Errorf("%s: got %+v", tt.name, got)
+		}
+		for k, v := range tt.body {
+			if fmt.Sprint(got.body[k]) != fmt.Sprint(v) {
+				t.Errorf("%s: %s is %v, want %v", tt.name, k, got.body[k], v)
+			}
+		}
+	}
+
+	// Lists are asked for with their query.
+	for _, tt := range []struct {
+		name        string
+		call        func(s payment.Service) error
+		path, query string
+	}{
+		{"load", func(s payment.Service) error { _, err := s.Load(ctx, "alice"); return err }, "/api/payments/v1/payments/alice", ""},
+		{"approvals", func(s payment.Service) error { _, err := s.Approvals(ctx, payment.Rejected); return err }, "/api/payments/v1/approvals", "status=rejected"},
+	} {
+		c, s, done := setup(t, http.StatusOK, "[]")
+		err := tt.call(c)
+		done()
+		if err != nil || len(s.calls) != 1 || s.calls[0].method != "GET" || s.calls[0].path != tt.path || s.calls[0].query != tt.query {
+			t.Errorf("%s: got %+v, %v", tt.name, s.calls, err)
+		}
+	}
+}
+
+func TestResponses(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+
+	c, _, done := setup(t, http.StatusOK, `{"id": "`+id.String()+`", "status": "pending_approval", "amount": "600", "currency": "USD"}`)
+	r, err := c.New(ctx, "alice", d("600"), "bob")
+	done()
+	if err != nil || r.ID != id || r.Status != payment.PendingApproval || !r.Amount.Equal(d("600")) || r.Currency != "USD" {
+		t.Errorf("new: got %+v, %v", r, err)
+	}
+
+	c, _, done = setup(t, http.StatusOK, `[{"account": "alice", "direction": "outgoing", "amount": "10", "to_account": "bob"}]`)
+	payments, err := c.Load(ctx, "alice")
+	done()
+	if err != nil || len(payments) != 1 || payments[0].Direction != payment.Outgoing || payments[0].ToAccount != "bob" || !payments[0].Amount.Equal(d("10")) {
+		t.Errorf("load: got %+v, %v", payments, err)
+	}
+
+	c, _, done = setup(t, http.StatusOK, `{"currency": "EUR", "date": "2019-10-01", "rate": 0.91}`)
+	rate, err := c.Rates(ctx, "EUR", "2019-10-01")
+	done()
+	if err != nil || rate != (payment.Rate{Currency: "EUR", Date: "2019-10-01", Rate: 0.91}) {
+		t.Errorf("rates: got %+v, %v", rate, err)
+	}
+
+	c, _, done = setup(t, http.StatusOK, `{"account": "alice", "currency": "USD", "to": "2019-10-02T00:00:00Z",
+		"opening_balance": "100", "total_in": "5", "total_out": "0", "closing_balance": "105", "entries": []}`)
+	st, err := c.Statement(ctx, "alice", time.Time{}, time.Time{})
+	done()
+	if err != nil || st.Account != "alice" || st.From != nil || !st.Closing.Equal(d("105")) {
+		t.Errorf("statement: got %+v, %v", st, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		want     func(error) bool
+		attempts int
+	}{
+		{"errs value", errs.ErrInsufficientMoney, 0, func(err error) bool { return errors.Is(err, errs.ErrInsufficientMoney) }, 1},
+		{"validation", errs.ValidationError{Err: errors.New("amount is invalid")}, 0, func(err error) bool {
+			var v errs.ValidationError
+			return errors.As(err, &v)
+		}, 1},
+		// Unavailable rates are retried by idempotent calls.
+		{"rate unavailable", errs.ErrRateUnavailable, 0, func(err error) bool { return errors.Is(err, errs.ErrRateUnavailable) }, 2},
+		{"server error", nil, http.StatusInternalServerError, func(err error) bool {
+			var s errs.StatusError
+			return errors.As(err, &s) && s.Code == http.StatusInternalServerError
+		}, 2},
+	}
+	for _, tt := range tests {
+		c, s, done := setup(t, tt.status, "")
+		s.err = tt.err
+		_, err := c.Rates(ctx, "EUR", payment.Latest)
+		if !tt.want(err) || len(s.calls) != tt.attempts {
+			t.Errorf("%s: rates got %v after %d requests", tt.name, err, len(s.calls))
+		}
+		// Calls moving money are never retried.
+		s.calls = nil
+		if _, err := c.New(ctx, "alice", d("1"), "bob"); !tt.want(err) || len(s.calls) != 1 {
+			t.Errorf("%s: new got %v after %d requests", tt.name, err, len(s.calls))
+		}
+		done()
+	}
+}
@@ -2,6 +2,9 @@ package payment
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
 	"github.com/ilyareist/task1/account"
@@ -9,6 +12,46 @@ import (
 	"github.com/go-kit/kit/endpoint"
 )
 
+// Endpoints collects all of the endpoints that compose a payment service,
+// so that every transport serves the very same set.
+type Endpoints struct {
+	NewPaymentEndpoint      endpoint.Endpoint
+	SplitPaymentEndpoint    endpoint.Endpoint
+	DepositEndpoint         endpoint.Endpoint
+	RatesCurrencyEndpoint   endpoint.Endpoint
+	LoadPaymentsEndpoint    endpoint.Endpoint
+	StatementEndpoint       endpoint.Endpoint
+	LoadAllPaymentsEndpoint endpoint.Endpoint
+	ApprovePaymentEndpoint  endpoint.Endpoint
+	RejectPaymentEndpoint   endpoint.Endpoint
+	LoadApprovalsEndpoint   endpoint.Endpoint
+	ReversePaymentEndpoint  endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints wired to the provided service, each wrapped
+// by middlewares in the given order, the first one being the outermost.
+func MakeEndpoints(s Service, mws ...endpoint.Middleware) Endpoints {
+	wrap := func(e endpoint.Endpoint) endpoint.Endpoint {
+		for i := len(mws) - 1; i >= 0; i-- {
+			e = mws[i](e)
+		}
+		return e
+	}
+	return Endpoints{
+		NewPaymentEndpoint:      wrap(makeNewPaymentEndpoint(s)),
+		SplitPaymentEndpoint:    wrap(makeSplitPaymentEndpoint(s)),
+		DepositEndpoint:         wrap(makeDepositEndpoint(s)),
+		RatesCurrencyEndpoint:   wrap(makeRatesCurrencyEndpoint(s)),
+		LoadPaymentsEndpoint:    wrap(makeLoadPaymentsEndpoint(s)),
+		StatementEndpoint:       wrap(makeStatementEndpoint(s)),
+		LoadAllPaymentsEndpoint: wrap(makeLoadAllPaymentsEndpoint(s)),
+		ApprovePaymentEndpoint:  wrap(makeApprovePaymentEndpoint(s)),
+		RejectPaymentEndpoint:   wrap(makeRejectPaymentEndpoint(s)),
+		LoadApprovalsEndpoint:   wrap(makeLoadApprovalsEndpoint(s)),
+		ReversePaymentEndpoint:  wrap(makeReversePaymentEndpoint(s)),
+	}
+}
+
 type errorOnlyResponse struct {
 	Err error `json:"error,omitempty"`
 }
@@ -17,28 +60,60 @@ func (r errorOnlyResponse) ErrError() error { return r.Err }
 
 type newPaymentRequest struct {
 	FromAccountID account.ID      `json:"from" valid:"alphanum,required,stringlength(1|255)"`
-	Amount        decimal.Decimal `json:"amount" valid:"decimal,required"`
+	Amount        decimal.Decimal `json:"amount" valid:"decimal,required,positive,money"`
 	ToAccountID   account.ID      `json:"to" valid:"alphanum,required,stringlength(1|255)"`
 }
 
+// receiptResponse tells the outcome of a payment request.
+type receiptResponse struct {
+	Receipt
+	Err error `json:"error,omitempty"`
+}
+
+func (r receiptResponse) ErrError() error { return r.Err }
+
 func makeNewPaymentEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(newPaymentRequest)
-		err := s.New(req.FromAccountID, req.Amount, req.ToAccountID)
-		return errorOnlyResponse{Err: err}, nil
+		rcpt, err := s.New(ctx, req.FromAccountID, req.Amount, req.ToAccountID)
+		return receiptResponse{Receipt: rcpt, Err: err}, nil
+	}
+}
+
+type splitLeg struct {
+	To      account.ID      `json:"to" valid:"alphanum,required,stringlength(1|255)"`
+	Amount  decimal.Decimal `json:"amount" valid:"decimal,nonnegative,money"`
+	Percent decimal.Decimal `json:"percent" valid:"decimal,nonnegative,money"`
+}
+
+type splitPaymentRequest struct {
+	FromAccountID account.ID      `json:"from" valid:"alphanum,required,stringlength(1|255)"`
+	Amount        decimal.Decimal `json:"amount" valid:"decimal,nonnegative,money"`
+	Legs          []splitLeg      `json:"legs" valid:"required"`
+}
+
+func makeSplitPaymentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(splitPaymentRequest)
+		legs := make([]Leg, len(req.Legs))
+		for i, l := range req.Legs {
+			legs[i] = Leg{To: l.To, Amount: l.Amount, Percent: l.Percent}
+		}
+		rcpt, err := s.Split(ctx, req.FromAccountID, req.Amount, legs)
+		return receiptResponse{Receipt: rcpt, Err: err}, nil
 	}
 }
 
 type newDepositRequest struct {
 	AccountID account.ID      `json:"account" valid:"alphanum,required,stringlength(1|255)"`
-	Amount    decimal.Decimal `json:"amount" valid:"decimal,required"`
+	Amount    decimal.Decimal `json:"amount" valid:"decimal,required,positive,money"`
 }
 
 func makeDepositEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(newDepositRequest)
-		err := s.Deposit(req.AccountID, req.Amount)
-		return errorOnlyResponse{Err: err}, nil
+		rcpt, err := s.Deposit(ctx, req.AccountID, req.Amount)
+		return receiptResponse{Receipt: rcpt, Err: err}, nil
 	}
 }
 
@@ -60,7 +135,7 @@ type RatesCurrencyResponse struct {
 func makeRatesCurrencyEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(RatesCurrencyRequest)
-		a, error := s.Rates(req.Currency , req.Date)
+		a, error := s.Rates(ctx, req.Currency, req.Date)
 		return a, error
 	}
 }
@@ -68,14 +143,84 @@ func makeRatesCurrencyEndpoint(s Service) endpoint.Endpoint {
 func makeLoadPaymentsEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(loadPaymentsRequest)
-		r := s.Load(req.AccountID)
+		r, err := s.Load(ctx, req.AccountID)
+		if err != nil {
+			return nil, err
+		}
 		return r, nil
 	}
 }
 
+type statementRequest struct {
+	AccountID account.ID
+	From, To  time.Time
+	Format    string
+}
+
+// statementResponse carries a statement to be encoded in the format it was asked in.
+type statementResponse struct {
+	*Statement
+	Format string
+}
+
+func makeStatementEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(statementRequest)
+		st, err := s.Statement(ctx, req.AccountID, req.From, req.To)
+		if err != nil {
+			return nil, err
+		}
+		return statementResponse{Statement: st, Format: req.Format}, nil
+	}
+}
+
 func makeLoadAllPaymentsEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		r := s.LoadAll()
+		r := s.LoadAll(ctx)
 		return r, nil
 	}
 }
+
+type approvalRequest struct {
+	ID     uuid.UUID `json:"-"`
+	Reason string    `json:"reason" valid:"stringlength(0|1024)"`
+}
+
+func makeApprovePaymentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(approvalRequest)
+		err := s.Approve(ctx, req.ID)
+		return errorOnlyResponse{Err: err}, nil
+	}
+}
+
+func makeRejectPaymentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(approvalRequest)
+		err := s.Reject(ctx, req.ID, req.Reason)
+		return errorOnlyResponse{Err: err}, nil
+	}
+}
+
+type loadApprovalsRequest struct {
+	Status Status
+}
+
+func makeLoadApprovalsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(loadApprovalsRequest)
+		return s.Approvals(ctx, req.Status)
+	}
+}
+
+type reversePaymentRequest struct {
+	ID uuid.UUID
+}
+
+func makeReversePaymentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reversePaymentRequest)
+		rcpt, err := s.Reverse(ctx, req.ID)
+		return receiptResponse{Receipt: rcpt, Err: err}, nil
+	}
+}
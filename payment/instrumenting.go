@@ -0,0 +1,152 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+// Metrics of a payment service, the business ones next to request counts and latencies.
+type Metrics struct {
+	// RequestCount and RequestLatency are labelled by method and errs label of the outcome.
+	RequestCount   metrics.Counter
+	RequestLatency metrics.Histogram
+
+	// Payments counts accepted payment requests by kind and status of the receipt.
+	Payments metrics.Counter
+
+	// Volume sums amounts of executed payments by kind and currency of the source account.
+	Volume metrics.Counter
+}
+
+type instrumentingService struct {
+	metrics  Metrics
+	payments Repository
+	Service
+}
+
+// NewInstrumentingService returns an instance of an instrumenting Service.
+// Approvals are read from the repository to account for the volume of approved payments.
+func NewInstrumentingService(m Metrics, payments Repository, s Service) Service {
+	return &instrumentingService{
+		metrics:  m,
+		payments: payments,
+		Service:  s,
+	}
+}
+
+func (s *instrumentingService) observe(method string, begin time.Time, err error) {
+	lvs := []string{"method", method, "error", errs.Label(err)}
+	s.metrics.RequestCount.With(lvs...).Add(1)
+	s.metrics.RequestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+}
+
+// count accounts for an accepted payment request.
+func (s *instrumentingService) count(kind string, rcpt Receipt) {
+	s.metrics.Payments.With("kind", kind, "status", string(rcpt.Status)).Add(1)
+	if rcpt.Status == Executed {
+		amount, _ := rcpt.Amount.Float64()
+		s.metrics.Volume.With("kind", kind, "currency", string(rcpt.Currency)).Add(amount)
+	}
+}
+
+func (s *instrumentingService) New(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, toAccountID account.ID) (rcpt Receipt, err error) {
+	defer func(begin time.Time) { s.observe("new", begin, err) }(time.Now())
+	rcpt, err = s.Service.New(ctx, fromAccountID, amount, toAccountID)
+	if err == nil {
+		s.count("transfer", rcpt)
+	}
+	return rcpt, err
+}
+
+func (s *instrumentingService) Split(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, legs []Leg) (rcpt Receipt, err error) {
+	defer func(begin time.Time) { s.observe("split", begin, err) }(time.Now())
+	rcpt, err = s.Service.Split(ctx, fromAccountID, amount, legs)
+	if err == nil {
+		s.count("split", rcpt)
+	}
+	return rcpt, err
+}
+
+func (s *instrumentingService) Deposit(ctx context.Context, accountID account.ID, amount decimal.Decimal) (rcpt Receipt, err error) {
+	defer func(begin time.Time) { s.observe("deposit", begin, err) }(time.Now())
+	rcpt, err = s.Service.Deposit(ctx, accountID, amount)
+	if err == nil {
+		s.count("deposit", rcpt)
+	}
+	return rcpt, err
+}
+
+func (s *instrumentingService) Reverse(ctx context.Context, id uuid.UUID) (rcpt Receipt, err error) {
+	defer func(begin time.Time) { s.observe("reverse", begin, err) }(time.Now())
+	rcpt, err = s.Service.Reverse(ctx, id)
+	if err == nil {
+		s.count("reversal", rcpt)
+	}
+	return rcpt, err
+}
+
+func (s *instrumentingService) Approve(ctx context.Context, id uuid.UUID) (err error) {
+	defer func(begin time.Time) { s.observe("approve", begin, err) }(time.Now())
+	err = s.Service.Approve(ctx, id)
+	if err == nil {
+		if a, ferr := s.payments.FindApproval(id); ferr == nil {
+			amount, _ := a.Debit.Float64()
+			s.metrics.Volume.With("kind", string(a.Kind), "currency", string(a.Currency)).Add(amount)
+		}
+	}
+	return err
+}
+
+func (s *instrumentingService) Reject(ctx context.Context, id uuid.UUID, reason string) (err error) {
+	defer func(begin time.Time) { s.observe("reject", begin, err) }(time.Now())
+	return s.Service.Reject(ctx, id, reason)
+}
+
+func (s *instrumentingService) Approvals(ctx context.Context, status Status) (approvals []*Approval, err error) {
+	defer func(begin time.Time) { s.observe("approvals", begin, err) }(time.Now())
+	return s.Service.Approvals(ctx, status)
+}
+
+func (s *instrumentingService) Load(ctx context.Context, accountID account.ID) (payments []*Payment, err error) {
+	defer func(begin time.Time) { s.observe("load", begin, err) }(time.Now())
+	return s.Service.Load(ctx, accountID)
+}
+
+func (s *instrumentingService) Statement(ctx context.Context, accountID account.ID, from, to time.Time) (st *Statement, err error) {
+	defer func(begin time.Time) { s.observe("statement", begin, err) }(time.Now())
+	return s.Service.Statement(ctx, accountID, from, to)
+}
+
+func (s *instrumentingService) LoadAll(ctx context.Context) []*Payment {
+	defer func(begin time.Time) { s.observe("load_all", begin, nil) }(time.Now())
+	return s.Service.LoadAll(ctx)
+}
+
+func (s *instrumentingService) Rates(ctx context.Context, currency string, date string) (rate Rate, err error) {
+	defer func(begin time.Time) { s.observe("rates", begin, err) }(time.Now())
+	return s.Service.Rates(ctx, currency, date)
+}
+
+type instrumentingRates struct {
+	requestLatency metrics.Histogram
+	RateProvider
+}
+
+// NewInstrumentingRateProvider returns a RateProvider timing calls of the next one,
+// labelled by errs label of the outcome.
+func NewInstrumentingRateProvider(latency metrics.Histogram, next RateProvider) RateProvider {
+	return &instrumentingRates{requestLatency: latency, RateProvider: next}
+}
+
+func (p *instrumentingRates) Rate(ctx context.Context, currency string, date string) (rate Rate, err error) {
+	defer func(begin time.Time) {
+		p.requestLatency.With("error", errs.Label(err)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return p.RateProvider.Rate(ctx, currency, date)
+}
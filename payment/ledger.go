@@ -0,0 +1,151 @@
+package payment
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+)
+
+// Discrepancy is an inconsistency found in the ledger, in a payment group or an account.
+type Discrepancy struct {
+	Group   uuid.UUID  `json:"group,omitempty"`
+	Account account.ID `json:"account,omitempty"`
+	Problem string     `json:"problem"`
+}
+
+func (d Discrepancy) String() string {
+	if d.Account != "" {
+		return fmt.Sprintf("account %s: %s", d.Account, d.Problem)
+	}
+	return fmt.Sprintf("payment %s: %s", d.Group, d.Problem)
+}
+
+// VerifyLedger checks payments and the accounts they move money of for consistency:
+// amounts of legs are positive; deposits have a single incoming leg; transfers and splits have
+// one outgoing leg and incoming legs from its account; reversals refer to a payment, mirror
+// all of its legs and are the only one of it; balances are not negative.
+func VerifyLedger(payments []*Payment, accounts []*account.Account) []Discrepancy {
+	var found []Discrepancy
+	report := func(group uuid.UUID, format string, args ...interface{}) {
+		found = append(found, Discrepancy{Group: group, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	groups := make(map[uuid.UUID][]*Payment)
+	var order []uuid.UUID
+	for _, p := range payments {
+		if _, ok := groups[p.Group]; !ok {
+			order = append(order, p.Group)
+		}
+		groups[p.Group] = append(groups[p.Group], p)
+		if !p.Amount.IsPositive() {
+			report(p.Group, "leg of account %s has amount %s", p.Account, p.Amount)
+		}
+	}
+
+	reversals := make(map[uuid.UUID][]uuid.UUID)
+	for _, group := range order {
+		legs := groups[group]
+		if of := legs[0].ReversalOf; of != nil {
+			reversals[*of] = append(reversals[*of], group)
+			for _, leg := range legs[1:] {
+				if leg.ReversalOf == nil || *leg.ReversalOf != *of {
+					report(group, "legs reverse different payments")
+					break
+				}
+			}
+			continue
+		}
+		var outgoing, incoming []*Payment
+		for _, leg := range legs {
+			if leg.ReversalOf != nil {
+				report(group, "legs reverse different payments")
+			}
+			switch leg.Direction {
+			case Outgoing:
+				outgoing = append(outgoing, leg)
+			case Incoming:
+				incoming = append(incoming, leg)
+			default:
+				report(group, "leg of account %s has direction %q", leg.Account, leg.Direction)
+			}
+		}
+		switch {
+		case len(incoming) == 0:
+			report(group, "payment has no incoming leg")
+		case len(outgoing) == 0:
+			if len(incoming) > 1 || incoming[0].FromAccount != incoming[0].Account {
+				report(group, "payment has no outgoing leg")
+			}
+		case len(outgoing) > 1:
+			report(group, "payment has %d outgoing legs", len(outgoing))
+		default:
+			for _, leg := range incoming {
+				if leg.FromAccount != outgoing[0].Account {
+					report(group, "leg of account %s is credited from %s instead of %s", leg.Account, leg.FromAccount, outgoing[0].Account)
+				}
+			}
+		}
+	}
+
+	reversed := make([]uuid.UUID, 0, len(reversals))
+	for of := range reversals {
+		reversed = append(reversed, of)
+	}
+	sort.Slice(reversed, func(i, j int) bool { return reversed[i].String() < reversed[j].String() })
+	for _, of := range reversed {
+		by := reversals[of]
+		original, ok := groups[of]
+		if !ok {
+			for _, group := range by {
+				report(group, "reverses unknown payment %s", of)
+			}
+			continue
+		}
+		if len(by) > 1 {
+			report(of, "payment is reversed %d times", len(by))
+		}
+		for _, group := range by {
+			if !mirrors(groups[group], original) {
+				report(group, "legs do not mirror those of payment %s", of)
+			}
+		}
+	}
+
+	for _, a := range accounts {
+		if a.Balance.IsNegative() {
+			found = append(found, Discrepancy{Account: a.ID, Problem: fmt.Sprintf("balance is %s", a.Balance)})
+		}
+	}
+	return found
+}
+
+// mirrors reports whether reversal legs move the amounts of the original legs back.
+func mirrors(reversal, original []*Payment) bool {
+	if len(reversal) != len(original) {
+		return false
+	}
+	// Decimals are keyed by their text, which has no trailing zeros whatever the scale.
+	type move struct {
+		account   account.ID
+		direction Direction
+		amount    string
+	}
+	moves := make(map[move]int, len(original))
+	for _, leg := range original {
+		direction := Incoming
+		if leg.Direction == Incoming {
+			direction = Outgoing
+		}
+		moves[move{leg.Account, direction, leg.Amount.String()}]++
+	}
+	for _, leg := range reversal {
+		m := move{leg.Account, leg.Direction, leg.Amount.String()}
+		if moves[m] == 0 {
+			return false
+		}
+		moves[m]--
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/requestid"
+	"github.com/shopspring/decimal"
+)
+
+type loggingService struct {
+	logger log.Logger
+	Service
+}
+
+// NewLoggingService returns a new instance of a logging Service. Every call is logged
+// with the request id and the principal found in the context.
+func NewLoggingService(logger log.Logger, s Service) Service {
+	return &loggingService{logger, s}
+}
+
+func (s *loggingService) log(ctx context.Context, keyvals ...interface{}) {
+	p, _ := auth.FromContext(ctx)
+	_ = log.With(s.logger, "request_id", requestid.FromContext(ctx), "principal", p.ID).Log(keyvals...)
+}
+
+func (s *loggingService) New(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, toAccountID account.ID) (rcpt Receipt, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx,
+			"method", "new",
+			"from", fromAccountID,
+			"to", toAccountID,
+			"amount", amount,
+			"payment_id", rcpt.ID,
+			"status", rcpt.Status,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.New(ctx, fromAccountID, amount, toAccountID)
+}
+
+func (s *loggingService) Split(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, legs []Leg) (rcpt Receipt, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx,
+			"method", "split",
+			"from", fromAccountID,
+			"amount", amount,
+			"legs", len(legs),
+			"payment_id", rcpt.ID,
+			"status", rcpt.Status,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Split(ctx, fromAccountID, amount, legs)
+}
+
+func (s *loggingService) Deposit(ctx context.Context, accountID account.ID, amount decimal.Decimal) (rcpt Receipt, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx,
+			"method", "deposit",
+			"account_id", accountID,
+			"amount", amount,
+			"payment_id", rcpt.ID,
+			"status", rcpt.Status,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Deposit(ctx, accountID, amount)
+}
+
+func (s *loggingService) Reverse(ctx context.Context, id uuid.UUID) (rcpt Receipt, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx,
+			"method", "reverse",
+			"payment_id", id,
+			"reversal_id", rcpt.ID,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+	return s.Service.Reverse(ctx, id)
+}
+
+func (s *loggingService) Approve(ctx context.Context, id uuid.UUID) (err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "approve", "payment_id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Approve(ctx, id)
+}
+
+func (s *loggingService) Reject(ctx context.Context, id uuid.UUID, reason string) (err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "reject", "payment_id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Reject(ctx, id, reason)
+}
+
+func (s *loggingService) Approvals(ctx context.Context, status Status) (approvals []*Approval, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "approvals", "status", status, "count", len(approvals), "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Approvals(ctx, status)
+}
+
+func (s *loggingService) Load(ctx context.Context, accountID account.ID) (payments []*Payment, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "load", "account_id", accountID, "count", len(payments), "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Load(ctx, accountID)
+}
+
+func (s *loggingService) Statement(ctx context.Context, accountID account.ID, from, to time.Time) (st *Statement, err error) {
+	defer func(begin time.Time) {
+		count := 0
+		if st != nil {
+			count = len(st.Entries)
+		}
+		s.log(ctx, "method", "statement", "account_id", accountID, "from", from, "to", to, "count", count, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Statement(ctx, accountID, from, to)
+}
+
+func (s *loggingService) LoadAll(ctx context.Context) (payments []*Payment) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "load_all", "count", len(payments), "took", time.Since(begin))
+	}(time.Now())
+	return s.Service.LoadAll(ctx)
+}
+
+func (s *loggingService) Rates(ctx context.Context, currency string, date string) (rate Rate, err error) {
+	defer func(begin time.Time) {
+		s.log(ctx, "method", "rates", "currency", currency, "date", date, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return s.Service.Rates(ctx, currency, date)
+}
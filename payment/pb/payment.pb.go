@@ -0,0 +1,1388 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: payment.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Empty struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Empty) Reset()         { *m = Empty{} }
+func (m *Empty) String() string { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()    {}
+func (*Empty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{0}
+}
+
+func (m *Empty) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Empty.Unmarshal(m, b)
+}
+func (m *Empty) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Empty.Marshal(b, m, deterministic)
+}
+func (m *Empty) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Empty.Merge(m, src)
+}
+func (m *Empty) XXX_Size() int {
+	return xxx_messageInfo_Empty.Size(m)
+}
+func (m *Empty) XXX_DiscardUnknown() {
+	xxx_messageInfo_Empty.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Empty proto.InternalMessageInfo
+
+// Payment is a single leg of a money transfer.
+type Payment struct {
+	Group                string   `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Account              string   `protobuf:"bytes,2,opt,name=account,proto3" json:"account,omitempty"`
+	Amount               string   `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	ToAccount            string   `protobuf:"bytes,4,opt,name=to_account,json=toAccount,proto3" json:"to_account,omitempty"`
+	FromAccount          string   `protobuf:"bytes,5,opt,name=from_account,json=fromAccount,proto3" json:"from_account,omitempty"`
+	Direction            string   `protobuf:"bytes,6,opt,name=direction,proto3" json:"direction,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Payment) Reset()         { *m = Payment{} }
+func (m *Payment) String() string { return proto.CompactTextString(m) }
+func (*Payment) ProtoMessage()    {}
+func (*Payment) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{1}
+}
+
+func (m *Payment) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Payment.Unmarshal(m, b)
+}
+func (m *Payment) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Payment.Marshal(b, m, deterministic)
+}
+func (m *Payment) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Payment.Merge(m, src)
+}
+func (m *Payment) XXX_Size() int {
+	return xxx_messageInfo_Payment.Size(m)
+}
+func (m *Payment) XXX_DiscardUnknown() {
+	xxx_messageInfo_Payment.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Payment proto.InternalMessageInfo
+
+func (m *Payment) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *Payment) GetAccount() string {
+	if m != nil {
+		return m.Account
+	}
+	return ""
+}
+
+func (m *Payment) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+func (m *Payment) GetToAccount() string {
+	if m != nil {
+		return m.ToAccount
+	}
+	return ""
+}
+
+func (m *Payment) GetFromAccount() string {
+	if m != nil {
+		return m.FromAccount
+	}
+	return ""
+}
+
+func (m *Payment) GetDirection() string {
+	if m != nil {
+		return m.Direction
+	}
+	return ""
+}
+
+type NewPaymentRequest struct {
+	From                 string   `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	Amount               string   `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	To                   string   `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NewPaymentRequest) Reset()         { *m = NewPaymentRequest{} }
+func (m *NewPaymentRequest) String() string { return proto.CompactTextString(m) }
+func (*NewPaymentRequest) ProtoMessage()    {}
+func (*NewPaymentRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{2}
+}
+
+func (m *NewPaymentRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NewPaymentRequest.Unmarshal(m, b)
+}
+func (m *NewPaymentRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NewPaymentRequest.Marshal(b, m, deterministic)
+}
+func (m *NewPaymentRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NewPaymentRequest.Merge(m, src)
+}
+func (m *NewPaymentRequest) XXX_Size() int {
+	return xxx_messageInfo_NewPaymentRequest.Size(m)
+}
+func (m *NewPaymentRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NewPaymentRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NewPaymentRequest proto.InternalMessageInfo
+
+func (m *NewPaymentRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *NewPaymentRequest) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+func (m *NewPaymentRequest) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+type SplitLeg struct {
+	To                   string   `protobuf:"bytes,1,opt,name=to,proto3" json:"to,omitempty"`
+	Amount               string   `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Percent              string   `protobuf:"bytes,3,opt,name=percent,proto3" json:"percent,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SplitLeg) Reset()         { *m = SplitLeg{} }
+func (m *SplitLeg) String() string { return proto.CompactTextString(m) }
+func (*SplitLeg) ProtoMessage()    {}
+func (*SplitLeg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{3}
+}
+
+func (m *SplitLeg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SplitLeg.Unmarshal(m, b)
+}
+func (m *SplitLeg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SplitLeg.Marshal(b, m, deterministic)
+}
+func (m *SplitLeg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SplitLeg.Merge(m, src)
+}
+func (m *SplitLeg) XXX_Size() int {
+	return xxx_messageInfo_SplitLeg.Size(m)
+}
+func (m *SplitLeg) XXX_DiscardUnknown() {
+	xxx_messageInfo_SplitLeg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SplitLeg proto.InternalMessageInfo
+
+func (m *SplitLeg) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *SplitLeg) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+func (m *SplitLeg) GetPercent() string {
+	if m != nil {
+		return m.Percent
+	}
+	return ""
+}
+
+type SplitPaymentRequest struct {
+	From                 string      `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	Amount               string      `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Legs                 []*SplitLeg `protobuf:"bytes,3,rep,name=legs,proto3" json:"legs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *SplitPaymentRequest) Reset()         { *m = SplitPaymentRequest{} }
+func (m *SplitPaymentRequest) String() string { return proto.CompactTextString(m) }
+func (*SplitPaymentRequest) ProtoMessage()    {}
+func (*SplitPaymentRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{4}
+}
+
+func (m *SplitPaymentRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SplitPaymentRequest.Unmarshal(m, b)
+}
+func (m *SplitPaymentRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SplitPaymentRequest.Marshal(b, m, deterministic)
+}
+func (m *SplitPaymentRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SplitPaymentRequest.Merge(m, src)
+}
+func (m *SplitPaymentRequest) XXX_Size() int {
+	return xxx_messageInfo_SplitPaymentRequest.Size(m)
+}
+func (m *SplitPaymentRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SplitPaymentRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SplitPaymentRequest proto.InternalMessageInfo
+
+func (m *SplitPaymentRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *SplitPaymentRequest) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+func (m *SplitPaymentRequest) GetLegs() []*SplitLeg {
+	if m != nil {
+		return m.Legs
+	}
+	return nil
+}
+
+// PaymentReply tells the outcome of a payment request: executed, pending_approval or pending_review,
+// and the amount debited from the source account, or credited to the deposited one, in its currency.
+type PaymentReply struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status               string   `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Amount               string   `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency             string   `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PaymentReply) Reset()         { *m = PaymentReply{} }
+func (m *PaymentReply) String() string { return proto.CompactTextString(m) }
+func (*PaymentReply) ProtoMessage()    {}
+func (*PaymentReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{5}
+}
+
+func (m *PaymentReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PaymentReply.Unmarshal(m, b)
+}
+func (m *PaymentReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PaymentReply.Marshal(b, m, deterministic)
+}
+func (m *PaymentReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PaymentReply.Merge(m, src)
+}
+func (m *PaymentReply) XXX_Size() int {
+	return xxx_messageInfo_PaymentReply.Size(m)
+}
+func (m *PaymentReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PaymentReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PaymentReply proto.InternalMessageInfo
+
+func (m *PaymentReply) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *PaymentReply) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *PaymentReply) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+func (m *PaymentReply) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+type DepositRequest struct {
+	Account              string   `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Amount               string   `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DepositRequest) Reset()         { *m = DepositRequest{} }
+func (m *DepositRequest) String() string { return proto.CompactTextString(m) }
+func (*DepositRequest) ProtoMessage()    {}
+func (*DepositRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{6}
+}
+
+func (m *DepositRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DepositRequest.Unmarshal(m, b)
+}
+func (m *DepositRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DepositRequest.Marshal(b, m, deterministic)
+}
+func (m *DepositRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DepositRequest.Merge(m, src)
+}
+func (m *DepositRequest) XXX_Size() int {
+	return xxx_messageInfo_DepositRequest.Size(m)
+}
+func (m *DepositRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DepositRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DepositRequest proto.InternalMessageInfo
+
+func (m *DepositRequest) GetAccount() string {
+	if m != nil {
+		return m.Account
+	}
+	return ""
+}
+
+func (m *DepositRequest) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+type RatesRequest struct {
+	Currency             string   `protobuf:"bytes,1,opt,name=currency,proto3" json:"currency,omitempty"`
+	Date                 string   `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RatesRequest) Reset()         { *m = RatesRequest{} }
+func (m *RatesRequest) String() string { return proto.CompactTextString(m) }
+func (*RatesRequest) ProtoMessage()    {}
+func (*RatesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{7}
+}
+
+func (m *RatesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RatesRequest.Unmarshal(m, b)
+}
+func (m *RatesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RatesRequest.Marshal(b, m, deterministic)
+}
+func (m *RatesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RatesRequest.Merge(m, src)
+}
+func (m *RatesRequest) XXX_Size() int {
+	return xxx_messageInfo_RatesRequest.Size(m)
+}
+func (m *RatesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RatesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RatesRequest proto.InternalMessageInfo
+
+func (m *RatesRequest) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+func (m *RatesRequest) GetDate() string {
+	if m != nil {
+		return m.Date
+	}
+	return ""
+}
+
+type Rate struct {
+	Currency             string   `protobuf:"bytes,1,opt,name=currency,proto3" json:"currency,omitempty"`
+	Date                 string   `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	Rate                 float64  `protobuf:"fixed64,3,opt,name=rate,proto3" json:"rate,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Rate) Reset()         { *m = Rate{} }
+func (m *Rate) String() string { return proto.CompactTextString(m) }
+func (*Rate) ProtoMessage()    {}
+func (*Rate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{8}
+}
+
+func (m *Rate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Rate.Unmarshal(m, b)
+}
+func (m *Rate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Rate.Marshal(b, m, deterministic)
+}
+func (m *Rate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Rate.Merge(m, src)
+}
+func (m *Rate) XXX_Size() int {
+	return xxx_messageInfo_Rate.Size(m)
+}
+func (m *Rate) XXX_DiscardUnknown() {
+	xxx_messageInfo_Rate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Rate proto.InternalMessageInfo
+
+func (m *Rate) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+func (m *Rate) GetDate() string {
+	if m != nil {
+		return m.Date
+	}
+	return ""
+}
+
+func (m *Rate) GetRate() float64 {
+	if m != nil {
+		return m.Rate
+	}
+	return 0
+}
+
+type LoadPaymentsRequest struct {
+	Account              string   `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LoadPaymentsRequest) Reset()         { *m = LoadPaymentsRequest{} }
+func (m *LoadPaymentsRequest) String() string { return proto.CompactTextString(m) }
+func (*LoadPaymentsRequest) ProtoMessage()    {}
+func (*LoadPaymentsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{9}
+}
+
+func (m *LoadPaymentsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LoadPaymentsRequest.Unmarshal(m, b)
+}
+func (m *LoadPaymentsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LoadPaymentsRequest.Marshal(b, m, deterministic)
+}
+func (m *LoadPaymentsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LoadPaymentsRequest.Merge(m, src)
+}
+func (m *LoadPaymentsRequest) XXX_Size() int {
+	return xxx_messageInfo_LoadPaymentsRequest.Size(m)
+}
+func (m *LoadPaymentsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LoadPaymentsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LoadPaymentsRequest proto.InternalMessageInfo
+
+func (m *LoadPaymentsRequest) GetAccount() string {
+	if m != nil {
+		return m.Account
+	}
+	return ""
+}
+
+type PaymentsReply struct {
+	Payments             []*Payment `protobuf:"bytes,1,rep,name=payments,proto3" json:"payments,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *PaymentsReply) Reset()         { *m = PaymentsReply{} }
+func (m *PaymentsReply) String() string { return proto.CompactTextString(m) }
+func (*PaymentsReply) ProtoMessage()    {}
+func (*PaymentsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{10}
+}
+
+func (m *PaymentsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PaymentsReply.Unmarshal(m, b)
+}
+func (m *PaymentsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PaymentsReply.Marshal(b, m, deterministic)
+}
+func (m *PaymentsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PaymentsReply.Merge(m, src)
+}
+func (m *PaymentsReply) XXX_Size() int {
+	return xxx_messageInfo_PaymentsReply.Size(m)
+}
+func (m *PaymentsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PaymentsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PaymentsReply proto.InternalMessageInfo
+
+func (m *PaymentsReply) GetPayments() []*Payment {
+	if m != nil {
+		return m.Payments
+	}
+	return nil
+}
+
+type ApprovalRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Reason               string   `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ApprovalRequest) Reset()         { *m = ApprovalRequest{} }
+func (m *ApprovalRequest) String() string { return proto.CompactTextString(m) }
+func (*ApprovalRequest) ProtoMessage()    {}
+func (*ApprovalRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{11}
+}
+
+func (m *ApprovalRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ApprovalRequest.Unmarshal(m, b)
+}
+func (m *ApprovalRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ApprovalRequest.Marshal(b, m, deterministic)
+}
+func (m *ApprovalRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ApprovalRequest.Merge(m, src)
+}
+func (m *ApprovalRequest) XXX_Size() int {
+	return xxx_messageInfo_ApprovalRequest.Size(m)
+}
+func (m *ApprovalRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ApprovalRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ApprovalRequest proto.InternalMessageInfo
+
+func (m *ApprovalRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *ApprovalRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type ApprovalsRequest struct {
+	Status               string   `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ApprovalsRequest) Reset()         { *m = ApprovalsRequest{} }
+func (m *ApprovalsRequest) String() string { return proto.CompactTextString(m) }
+func (*ApprovalsRequest) ProtoMessage()    {}
+func (*ApprovalsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{12}
+}
+
+func (m *ApprovalsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ApprovalsRequest.Unmarshal(m, b)
+}
+func (m *ApprovalsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ApprovalsRequest.Marshal(b, m, deterministic)
+}
+func (m *ApprovalsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ApprovalsRequest.Merge(m, src)
+}
+func (m *ApprovalsRequest) XXX_Size() int {
+	return xxx_messageInfo_ApprovalsRequest.Size(m)
+}
+func (m *ApprovalsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ApprovalsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ApprovalsRequest proto.InternalMessageInfo
+
+func (m *ApprovalsRequest) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+// Finding is an outcome of a screening rule which sent a payment to review.
+type Finding struct {
+	Rule                 string   `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Decision             string   `protobuf:"bytes,2,opt,name=decision,proto3" json:"decision,omitempty"`
+	Reason               string   `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Finding) Reset()         { *m = Finding{} }
+func (m *Finding) String() string { return proto.CompactTextString(m) }
+func (*Finding) ProtoMessage()    {}
+func (*Finding) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{13}
+}
+
+func (m *Finding) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Finding.Unmarshal(m, b)
+}
+func (m *Finding) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Finding.Marshal(b, m, deterministic)
+}
+func (m *Finding) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Finding.Merge(m, src)
+}
+func (m *Finding) XXX_Size() int {
+	return xxx_messageInfo_Finding.Size(m)
+}
+func (m *Finding) XXX_DiscardUnknown() {
+	xxx_messageInfo_Finding.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Finding proto.InternalMessageInfo
+
+func (m *Finding) GetRule() string {
+	if m != nil {
+		return m.Rule
+	}
+	return ""
+}
+
+func (m *Finding) GetDecision() string {
+	if m != nil {
+		return m.Decision
+	}
+	return ""
+}
+
+func (m *Finding) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type Approval struct {
+	Id                   string      `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	From                 string      `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To                   string      `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Legs                 []*SplitLeg `protobuf:"bytes,4,rep,name=legs,proto3" json:"legs,omitempty"`
+	Amount               string      `protobuf:"bytes,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	Debit                string      `protobuf:"bytes,6,opt,name=debit,proto3" json:"debit,omitempty"`
+	Currency             string      `protobuf:"bytes,7,opt,name=currency,proto3" json:"currency,omitempty"`
+	Status               string      `protobuf:"bytes,8,opt,name=status,proto3" json:"status,omitempty"`
+	Maker                string      `protobuf:"bytes,9,opt,name=maker,proto3" json:"maker,omitempty"`
+	Checker              string      `protobuf:"bytes,10,opt,name=checker,proto3" json:"checker,omitempty"`
+	Reason               string      `protobuf:"bytes,11,opt,name=reason,proto3" json:"reason,omitempty"`
+	CreatedAt            string      `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt            string      `protobuf:"bytes,13,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Kind                 string      `protobuf:"bytes,14,opt,name=kind,proto3" json:"kind,omitempty"`
+	Findings             []*Finding  `protobuf:"bytes,15,rep,name=findings,proto3" json:"findings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *Approval) Reset()         { *m = Approval{} }
+func (m *Approval) String() string { return proto.CompactTextString(m) }
+func (*Approval) ProtoMessage()    {}
+func (*Approval) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{14}
+}
+
+func (m *Approval) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Approval.Unmarshal(m, b)
+}
+func (m *Approval) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Approval.Marshal(b, m, deterministic)
+}
+func (m *Approval) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Approval.Merge(m, src)
+}
+func (m *Approval) XXX_Size() int {
+	return xxx_messageInfo_Approval.Size(m)
+}
+func (m *Approval) XXX_DiscardUnknown() {
+	xxx_messageInfo_Approval.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Approval proto.InternalMessageInfo
+
+func (m *Approval) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Approval) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *Approval) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *Approval) GetLegs() []*SplitLeg {
+	if m != nil {
+		return m.Legs
+	}
+	return nil
+}
+
+func (m *Approval) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+func (m *Approval) GetDebit() string {
+	if m != nil {
+		return m.Debit
+	}
+	return ""
+}
+
+func (m *Approval) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+func (m *Approval) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *Approval) GetMaker() string {
+	if m != nil {
+		return m.Maker
+	}
+	return ""
+}
+
+func (m *Approval) GetChecker() string {
+	if m != nil {
+		return m.Checker
+	}
+	return ""
+}
+
+func (m *Approval) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *Approval) GetCreatedAt() string {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return ""
+}
+
+func (m *Approval) GetExpiresAt() string {
+	if m != nil {
+		return m.ExpiresAt
+	}
+	return ""
+}
+
+func (m *Approval) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *Approval) GetFindings() []*Finding {
+	if m != nil {
+		return m.Findings
+	}
+	return nil
+}
+
+type ApprovalsReply struct {
+	Approvals            []*Approval `protobuf:"bytes,1,rep,name=approvals,proto3" json:"approvals,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *ApprovalsReply) Reset()         { *m = ApprovalsReply{} }
+func (m *ApprovalsReply) String() string { return proto.CompactTextString(m) }
+func (*ApprovalsReply) ProtoMessage()    {}
+func (*ApprovalsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6362648dfa63d410, []int{15}
+}
+
+func (m *ApprovalsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ApprovalsReply.Unmarshal(m, b)
+}
+func (m *ApprovalsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ApprovalsReply.Marshal(b, m, deterministic)
+}
+func (m *ApprovalsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ApprovalsReply.Merge(m, src)
+}
+func (m *ApprovalsReply) XXX_Size() int {
+	return xxx_messageInfo_ApprovalsReply.Size(m)
+}
+func (m *ApprovalsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ApprovalsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ApprovalsReply proto.InternalMessageInfo
+
+func (m *ApprovalsReply) GetApprovals() []*Approval {
+	if m != nil {
+		return m.Approvals
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Empty)(nil), "payment.v1.Empty")
+	proto.RegisterType((*Payment)(nil), "payment.v1.Payment")
+	proto.RegisterType((*NewPaymentRequest)(nil), "payment.v1.NewPaymentRequest")
+	proto.RegisterType((*SplitLeg)(nil), "payment.v1.SplitLeg")
+	proto.RegisterType((*SplitPaymentRequest)(nil), "payment.v1.SplitPaymentRequest")
+	proto.RegisterType((*PaymentReply)(nil), "payment.v1.PaymentReply")
+	proto.RegisterType((*DepositRequest)(nil), "payment.v1.DepositRequest")
+	proto.RegisterType((*RatesRequest)(nil), "payment.v1.RatesRequest")
+	proto.RegisterType((*Rate)(nil), "payment.v1.Rate")
+	proto.RegisterType((*LoadPaymentsRequest)(nil), "payment.v1.LoadPaymentsRequest")
+	proto.RegisterType((*PaymentsReply)(nil), "payment.v1.PaymentsReply")
+	proto.RegisterType((*ApprovalRequest)(nil), "payment.v1.ApprovalRequest")
+	proto.RegisterType((*ApprovalsRequest)(nil), "payment.v1.ApprovalsRequest")
+	proto.RegisterType((*Finding)(nil), "payment.v1.Finding")
+	proto.RegisterType((*Approval)(nil), "payment.v1.Approval")
+	proto.RegisterType((*ApprovalsReply)(nil), "payment.v1.ApprovalsReply")
+}
+
+func init() { proto.RegisterFile("payment.proto", fileDescriptor_6362648dfa63d410) }
+
+var fileDescriptor_6362648dfa63d410 = []byte{
+	// 767 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xcb, 0x4e, 0xdb, 0x4c,
+	0x14, 0xc6, 0x89, 0x1d, 0x27, 0x87, 0x10, 0x60, 0x88, 0x7e, 0xf9, 0x4f, 0x41, 0xa5, 0xb3, 0x42,
+	0x5d, 0x80, 0x4a, 0xd5, 0x05, 0xad, 0x54, 0x11, 0x44, 0x5b, 0xa9, 0x42, 0xb4, 0x0d, 0xbb, 0x6e,
+	0x90, 0xb1, 0x0f, 0xa9, 0xc1, 0xb1, 0x5d, 0x7b, 0x02, 0xcd, 0x83, 0xf4, 0x41, 0xfa, 0x30, 0x7d,
+	0x9f, 0x6a, 0xc6, 0x33, 0x93, 0x31, 0x49, 0x40, 0xb4, 0xbb, 0x39, 0xf7, 0xcb, 0xf7, 0xe5, 0xc4,
+	0xb0, 0x92, 0xf9, 0x93, 0x11, 0x26, 0x6c, 0x37, 0xcb, 0x53, 0x96, 0x12, 0x50, 0xe2, 0xcd, 0x0b,
+	0xea, 0x82, 0xf3, 0x6e, 0x94, 0xb1, 0x09, 0xfd, 0x65, 0x81, 0xfb, 0xb9, 0xd4, 0x93, 0x2e, 0x38,
+	0xc3, 0x3c, 0x1d, 0x67, 0x9e, 0xb5, 0x6d, 0xed, 0xb4, 0x06, 0xa5, 0x40, 0x3c, 0x70, 0xfd, 0x20,
+	0x48, 0xc7, 0x09, 0xf3, 0x6a, 0x42, 0xaf, 0x44, 0xf2, 0x1f, 0x34, 0xfc, 0x91, 0x30, 0xd4, 0x85,
+	0x41, 0x4a, 0x64, 0x0b, 0x80, 0xa5, 0xe7, 0x2a, 0xc8, 0x16, 0xb6, 0x16, 0x4b, 0xfb, 0x32, 0xec,
+	0x19, 0xb4, 0x2f, 0xf3, 0x74, 0xa4, 0x1d, 0x1c, 0xe1, 0xb0, 0xcc, 0x75, 0xca, 0x65, 0x13, 0x5a,
+	0x61, 0x94, 0x63, 0xc0, 0xa2, 0x34, 0xf1, 0x1a, 0x65, 0x02, 0xad, 0xa0, 0x9f, 0x60, 0xfd, 0x14,
+	0x6f, 0x65, 0xd7, 0x03, 0xfc, 0x3e, 0xc6, 0x82, 0x11, 0x02, 0x36, 0xcf, 0x20, 0x7b, 0x17, 0x6f,
+	0xa3, 0xc1, 0x5a, 0xa5, 0xc1, 0x0e, 0xd4, 0x58, 0x2a, 0x9b, 0xae, 0xb1, 0x94, 0x9e, 0x40, 0xf3,
+	0x2c, 0x8b, 0x23, 0x76, 0x82, 0x43, 0x69, 0xb3, 0x94, 0x6d, 0x61, 0x0e, 0x0f, 0xdc, 0x0c, 0xf3,
+	0x00, 0xf5, 0xf4, 0x4a, 0xa4, 0xd7, 0xb0, 0x21, 0xb2, 0xfd, 0x43, 0x83, 0x3b, 0x60, 0xc7, 0x38,
+	0x2c, 0xbc, 0xfa, 0x76, 0x7d, 0x67, 0x79, 0xbf, 0xbb, 0x3b, 0x45, 0x6e, 0x57, 0x35, 0x3a, 0x10,
+	0x1e, 0xf4, 0x0a, 0xda, 0xba, 0x4e, 0x16, 0x4f, 0x78, 0xfb, 0x51, 0xa8, 0xda, 0x8f, 0x42, 0x5e,
+	0xa1, 0x60, 0x3e, 0x1b, 0x17, 0xaa, 0x42, 0x29, 0x2d, 0xc4, 0xae, 0x07, 0xcd, 0x60, 0x9c, 0xe7,
+	0x98, 0x04, 0x13, 0x89, 0x9c, 0x96, 0xe9, 0x11, 0x74, 0x8e, 0x31, 0x4b, 0x8b, 0x48, 0xcf, 0x64,
+	0x70, 0xc3, 0x5a, 0xc4, 0x8d, 0xca, 0x64, 0xf4, 0x2d, 0xb4, 0x07, 0x3e, 0xc3, 0x42, 0x65, 0x30,
+	0xeb, 0x59, 0xd5, 0x7a, 0x7c, 0x63, 0xa1, 0xcf, 0x50, 0x66, 0x10, 0x6f, 0xfa, 0x11, 0x6c, 0x1e,
+	0xff, 0xd8, 0x38, 0xae, 0xcb, 0xb9, 0x8e, 0x4f, 0x6b, 0x0d, 0xc4, 0x9b, 0xee, 0xc1, 0xc6, 0x49,
+	0xea, 0x87, 0x72, 0x7f, 0xc5, 0x83, 0x43, 0xd1, 0x43, 0x58, 0x99, 0x3a, 0xf3, 0x6d, 0xef, 0x41,
+	0x53, 0x42, 0x53, 0x78, 0x96, 0xc0, 0x6a, 0xc3, 0xc4, 0x4a, 0x21, 0xa3, 0x9d, 0xe8, 0x01, 0xac,
+	0xf6, 0xb3, 0x2c, 0x4f, 0x6f, 0xfc, 0x58, 0x95, 0x9b, 0x83, 0x58, 0x8e, 0x7e, 0x91, 0x26, 0x6a,
+	0x73, 0xa5, 0x44, 0x9f, 0xc3, 0x9a, 0x0a, 0xd5, 0xad, 0x4e, 0xd1, 0xb5, 0x4c, 0x74, 0xe9, 0x17,
+	0x70, 0xdf, 0x47, 0x49, 0x18, 0x25, 0x43, 0x31, 0xf8, 0x38, 0x46, 0x45, 0x3b, 0xfe, 0xe6, 0xcb,
+	0x0b, 0x31, 0x88, 0x8a, 0x48, 0x17, 0xd1, 0xb2, 0x51, 0xbe, 0x5e, 0x29, 0xff, 0xb3, 0x0e, 0x4d,
+	0x55, 0x7f, 0xa6, 0x67, 0xc5, 0xed, 0x9a, 0xc1, 0xed, 0x3b, 0x3f, 0x32, 0xcd, 0x69, 0xfb, 0x21,
+	0x4e, 0x1b, 0xdc, 0x71, 0x2a, 0xdc, 0xec, 0x82, 0x13, 0xe2, 0x45, 0xc4, 0xe4, 0x45, 0x28, 0x85,
+	0x0a, 0x13, 0xdc, 0x3b, 0x4c, 0x98, 0xee, 0xa7, 0x59, 0x61, 0x7f, 0x17, 0x9c, 0x91, 0x7f, 0x8d,
+	0xb9, 0xd7, 0x2a, 0x33, 0x09, 0x81, 0x03, 0x1f, 0x7c, 0xc3, 0x80, 0xeb, 0xa1, 0x04, 0x5e, 0x8a,
+	0xc6, 0x52, 0x96, 0xcd, 0xa5, 0xf0, 0x4b, 0x17, 0xe4, 0xe8, 0x33, 0x0c, 0xcf, 0x7d, 0xe6, 0xb5,
+	0x85, 0xad, 0x25, 0x35, 0x7d, 0x71, 0x08, 0xf1, 0x47, 0x16, 0xe5, 0x58, 0x70, 0xf3, 0x4a, 0x69,
+	0x96, 0x9a, 0xbe, 0xb8, 0x08, 0xd7, 0x51, 0x12, 0x7a, 0x9d, 0x72, 0x6b, 0xfc, 0xcd, 0x19, 0x75,
+	0x59, 0x22, 0x57, 0x78, 0xab, 0xb3, 0x8c, 0x92, 0xa8, 0x0e, 0xb4, 0x13, 0x3d, 0x86, 0x8e, 0x41,
+	0x0b, 0x4e, 0xca, 0x7d, 0x68, 0xf9, 0x4a, 0xe3, 0x59, 0xb3, 0xdb, 0xd6, 0x04, 0x9c, 0xba, 0xed,
+	0xff, 0xb6, 0xa1, 0x23, 0xd9, 0x7a, 0x86, 0xf9, 0x4d, 0x14, 0x20, 0x39, 0x84, 0xfa, 0x29, 0xde,
+	0x92, 0x2d, 0x33, 0x74, 0xe6, 0xec, 0xf6, 0xbc, 0x79, 0x7c, 0xe7, 0x6d, 0xd0, 0x25, 0x72, 0x0c,
+	0x8e, 0x40, 0x96, 0x3c, 0x9d, 0x01, 0xfb, 0x11, 0x59, 0xfa, 0xe0, 0xca, 0xab, 0x43, 0x7a, 0xa6,
+	0x5b, 0xf5, 0x14, 0xdd, 0x9b, 0xe2, 0x15, 0x38, 0xe2, 0xe8, 0x90, 0x8a, 0x93, 0x79, 0x87, 0x7a,
+	0x6b, 0x77, 0x2d, 0xa2, 0x7f, 0x9b, 0xdf, 0x87, 0x6a, 0xfb, 0x73, 0x2e, 0x46, 0xef, 0xff, 0x39,
+	0xb5, 0x0b, 0x55, 0xfc, 0x00, 0x5c, 0x1e, 0xd3, 0x8f, 0x63, 0xb2, 0x6e, 0xfa, 0x89, 0xff, 0xdf,
+	0xfb, 0x43, 0xdf, 0x80, 0x5b, 0x82, 0x85, 0xe4, 0xc9, 0x5c, 0x04, 0x65, 0xfd, 0xd9, 0xbc, 0x74,
+	0x89, 0xbc, 0x86, 0xc6, 0x00, 0xaf, 0x30, 0x60, 0x7f, 0x11, 0xfb, 0x01, 0x5a, 0x9a, 0x54, 0x64,
+	0x73, 0x5e, 0xb8, 0x9e, 0xbd, 0xb7, 0xc0, 0x2a, 0x26, 0x38, 0xb2, 0xbf, 0xd6, 0xb2, 0x8b, 0x8b,
+	0x86, 0xf8, 0x00, 0x79, 0xf9, 0x67, 0x00, 0xa8, 0x5d, 0x64, 0x55, 0x91, 0x08, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// PaymentServiceClient is the client API for PaymentService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PaymentServiceClient interface {
+	// New registers a new payment between two accounts.
+	// Payments above the approval threshold are held pending approval, those sent to review
+	// by screening are held pending review, and those blocked by screening are refused.
+	New(ctx context.Context, in *NewPaymentRequest, opts ...grpc.CallOption) (*PaymentReply, error)
+	// Split debits one account once and credits every leg target within one payment.
+	Split(ctx context.Context, in *SplitPaymentRequest, opts ...grpc.CallOption) (*PaymentReply, error)
+	// Deposit adds money to an account.
+	Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*PaymentReply, error)
+	// Rates returns the USD based rate of a currency on a date.
+	Rates(ctx context.Context, in *RatesRequest, opts ...grpc.CallOption) (*Rate, error)
+	// Load returns payments list for an account.
+	Load(ctx context.Context, in *LoadPaymentsRequest, opts ...grpc.CallOption) (*PaymentsReply, error)
+	// LoadAll returns all payments registered in the system.
+	LoadAll(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PaymentsReply, error)
+	// Approve executes a payment pending approval or review; it must be made by another principal.
+	Approve(ctx context.Context, in *ApprovalRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Reject declines a payment pending approval or review.
+	Reject(ctx context.Context, in *ApprovalRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Approvals returns payment approvals in the given status, pending_approval by default.
+	Approvals(ctx context.Context, in *ApprovalsRequest, opts ...grpc.CallOption) (*ApprovalsReply, error)
+}
+
+type paymentServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewPaymentServiceClient(cc *grpc.ClientConn) PaymentServiceClient {
+	return &paymentServiceClient{cc}
+}
+
+func (c *paymentServiceClient) New(ctx context.Context, in *NewPaymentRequest, opts ...grpc.CallOption) (*PaymentReply, error) {
+	out := new(PaymentReply)
+	err := c.cc.Invoke(ctx, "/payment.v1.PaymentService/New", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Split(ctx context.Context, in *SplitPaymentRequest, opts ...grpc.CallOption) (*PaymentReply, error) {
+	out := new(PaymentReply)
+	err := c.cc.Invoke(ctx, "/payment.v1.PaymentService/Split", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*PaymentReply, error) {
+	out := new(PaymentReply)
+	err := c.cc.Invoke(ctx, "/payment.v1.PaymentService/Deposit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Rates(ctx context.Context, in *RatesRequest, opts ...grpc.CallOption) (*Rate, error) {
+	out := new(Rate)
+	err := c.cc.Invoke(ctx, "/payment.v1.PaymentService/Rates", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Load(ctx context.Context, in *LoadPaymentsRequest, opts ...grpc.CallOption) (*PaymentsReply, error) {
+	out := new(PaymentsReply)
+	err := c.cc.Invoke(ctx, "/payment.v1.PaymentService/Load", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) LoadAll(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PaymentsReply, error) {
+	out := new(PaymentsReply)
+	err := c.cc.Invoke(ctx, "/payment.v1.PaymentService/LoadAll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Approve(ctx context.Context, in *ApprovalRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/payment.v1.PaymentService/Approve", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Reject(ctx context.Context, in *ApprovalRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/payment.v1.PaymentService/Reject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Approvals(ctx context.Context, in *ApprovalsRequest, opts ...grpc.CallOption) (*ApprovalsReply, error) {
+	out := new(ApprovalsReply)
+	err := c.cc.Invoke(ctx, "/payment.v1.PaymentService/Approvals", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PaymentServiceServer is the server API for PaymentService service.
+type PaymentServiceServer interface {
+	// New registers a new payment between two accounts.
+	// Payments above the approval threshold are held pending approval, those sent to review
+	// by screening are held pending review, and those blocked by screening are refused.
+	New(context.Context, *NewPaymentRequest) (*PaymentReply, error)
+	// Split debits one account once and credits every leg target within one payment.
+	Split(context.Context, *SplitPaymentRequest) (*PaymentReply, error)
+	// Deposit adds money to an account.
+	Deposit(context.Context, *DepositRequest) (*PaymentReply, error)
+	// Rates returns the USD based rate of a currency on a date.
+	Rates(context.Context, *RatesRequest) (*Rate, error)
+	// Load returns payments list for an account.
+	Load(context.Context, *LoadPaymentsRequest) (*PaymentsReply, error)
+	// LoadAll returns all payments registered in the system.
+	LoadAll(context.Context, *Empty) (*PaymentsReply, error)
+	// Approve executes a payment pending approval or review; it must be made by another principal.
+	Approve(context.Context, *ApprovalRequest) (*Empty, error)
+	// Reject declines a payment pending approval or review.
+	Reject(context.Context, *ApprovalRequest) (*Empty, error)
+	// Approvals returns payment approvals in the given status, pending_approval by default.
+	Approvals(context.Context, *ApprovalsRequest) (*ApprovalsReply, error)
+}
+
+// UnimplementedPaymentServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedPaymentServiceServer struct {
+}
+
+func (*UnimplementedPaymentServiceServer) New(ctx context.Context, req *NewPaymentRequest) (*PaymentReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method New not implemented")
+}
+func (*UnimplementedPaymentServiceServer) Split(ctx context.Context, req *SplitPaymentRequest) (*PaymentReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Split not implemented")
+}
+func (*UnimplementedPaymentServiceServer) Deposit(ctx context.Context, req *DepositRequest) (*PaymentReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deposit not implemented")
+}
+func (*UnimplementedPaymentServiceServer) Rates(ctx context.Context, req *RatesRequest) (*Rate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rates not implemented")
+}
+func (*UnimplementedPaymentServiceServer) Load(ctx context.Context, req *LoadPaymentsRequest) (*PaymentsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Load not implemented")
+}
+func (*UnimplementedPaymentServiceServer) LoadAll(ctx context.Context, req *Empty) (*PaymentsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoadAll not implemented")
+}
+func (*UnimplementedPaymentServiceServer) Approve(ctx context.Context, req *ApprovalRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Approve not implemented")
+}
+func (*UnimplementedPaymentServiceServer) Reject(ctx context.Context, req *ApprovalRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reject not implemented")
+}
+func (*UnimplementedPaymentServiceServer) Approvals(ctx context.Context, req *ApprovalsRequest) (*ApprovalsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Approvals not implemented")
+}
+
+func RegisterPaymentServiceServer(s *grpc.Server, srv PaymentServiceServer) {
+	s.RegisterService(&_PaymentService_serviceDesc, srv)
+}
+
+func _PaymentService_New_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).New(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.v1.PaymentService/New",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).New(ctx, req.(*NewPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Split_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SplitPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Split(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.v1.PaymentService/Split",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Split(ctx, req.(*SplitPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Deposit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DepositRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Deposit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.v1.PaymentService/Deposit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Deposit(ctx, req.(*DepositRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Rates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Rates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.v1.PaymentService/Rates",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Rates(ctx, req.(*RatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Load_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadPaymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Load(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.v1.PaymentService/Load",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Load(ctx, req.(*LoadPaymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_LoadAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).LoadAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.v1.PaymentService/LoadAll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).LoadAll(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Approve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApprovalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Approve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.v1.PaymentService/Approve",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Approve(ctx, req.(*ApprovalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Reject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApprovalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Reject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.v1.PaymentService/Reject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Reject(ctx, req.(*ApprovalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Approvals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApprovalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Approvals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.v1.PaymentService/Approvals",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Approvals(ctx, req.(*ApprovalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _PaymentService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "payment.v1.PaymentService",
+	HandlerType: (*PaymentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "New",
+			Handler:    _PaymentService_New_Handler,
+		},
+		{
+			MethodName: "Split",
+			Handler:    _PaymentService_Split_Handler,
+		},
+		{
+			MethodName: "Deposit",
+			Handler:    _PaymentService_Deposit_Handler,
+		},
+		{
+			MethodName: "Rates",
+			Handler:    _PaymentService_Rates_Handler,
+		},
+		{
+			MethodName: "Load",
+			Handler:    _PaymentService_Load_Handler,
+		},
+		{
+			MethodName: "LoadAll",
+			Handler:    _PaymentService_LoadAll_Handler,
+		},
+		{
+			MethodName: "Approve",
+			Handler:    _PaymentService_Approve_Handler,
+		},
+		{
+			MethodName: "Reject",
+			Handler:    _PaymentService_Reject_Handler,
+		},
+		{
+			MethodName: "Approvals",
+			Handler:    _PaymentService_Approvals_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "payment.proto",
+}
@@ -0,0 +1,210 @@
+package payment
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	"github.com/ilyareist/task1/tracing"
+)
+
+// Latest is the date of the most recent rates.
+const Latest = "latest"
+
+// RateProvider provides USD based exchange rates.
+type RateProvider interface {
+	// Rate returns the rate of currency against USD on the date, YYYY-MM-DD or Latest.
+	Rate(ctx context.Context, currency string, date string) (Rate, error)
+}
+
+// RateStore keeps exchange rates imported by operators.
+type RateStore interface {
+	// StoreRates stores rates, replacing stored ones of the same currency and date.
+	StoreRates(rates ...Rate) error
+
+	// FindRate returns the stored rate of currency on the date, the most recent one for Latest.
+	FindRate(currency string, date string) (Rate, error)
+}
+
+type storedRates struct {
+	store RateStore
+	next  RateProvider
+}
+
+// NewStoredRateProvider returns a provider serving rates of past dates from the store before
+// asking the next provider, and serving the most recent stored rate when the next provider
+// fails to tell the latest one.
+func NewStoredRateProvider(store RateStore, next RateProvider) RateProvider {
+	return &storedRates{store: store, next: next}
+}
+
+// Rate returns the stored or the provided rate of currency against USD on the date.
+func (p *storedRates) Rate(ctx context.Context, currency string, date string) (Rate, error) {
+	if date != Latest {
+		if rate, err := p.store.FindRate(currency, date); err == nil {
+			return rate, nil
+		}
+		return p.next.Rate(ctx, currency, date)
+	}
+	rate, err := p.next.Rate(ctx, currency, date)
+	if err == nil {
+		return rate, nil
+	}
+	if stored, serr := p.store.FindRate(currency, Latest); serr == nil {
+		return stored, nil
+	}
+	return Rate{}, err
+}
+
+// ReadRates reads rates from CSV records of currency, date and rate, such as "EUR,2019-05-01,0.89".
+// A header record naming the columns is skipped. Currencies are three letter codes, dates are
+// YYYY-MM-DD and rates are positive; records failing that are reported by line.
+func ReadRates(r io.Reader) ([]Rate, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+	var rates []Rate
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(record[0], "currency") {
+			continue
+		}
+		rate, err := parseRate(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		rates = append(rates, rate)
+	}
+}
+
+// parseRate parses a CSV record of a rate.
+func parseRate(record []string) (Rate, error) {
+	currency := strings.ToUpper(record[0])
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return Rate{}, fmt.Errorf("invalid currency %q", record[0])
+	}
+	if _, err := time.Parse("2006-01-02", record[1]); err != nil {
+		return Rate{}, fmt.Errorf("invalid date %q", record[1])
+	}
+	rate, err := strconv.ParseFloat(record[2], 64)
+	if err != nil || rate <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q", record[2])
+	}
+	return Rate{Currency: currency, Date: record[1], Rate: rate}, nil
+}
+
+type httpRates struct {
+	url    string
+	client *http.Client
+}
+
+// NewRateProvider returns a provider fetching rates from an exchangeratesapi.io compatible API.
+// Requests are traced and carry the trace context to the API.
+func NewRateProvider(baseURL string, timeout time.Duration) RateProvider {
+	return &httpRates{url: baseURL, client: &http.Client{
+		Timeout:   timeout,
+		Transport: tracing.NewTransport(http.DefaultTransport),
+	}}
+}
+
+// Rate fetches the rate of currency against USD on the date.
+func (p *httpRates) Rate(ctx context.Context, currency string, date string) (Rate, error) {
+	q := url.Values{"base": {"USD"}, "symbols": {currency}}
+	req, err := http.NewRequest("GET", p.url+url.PathEscape(date)+"?"+q.Encode(), nil)
+	if err != nil {
+		return Rate{}, err
+	}
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return Rate{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Rate{}, fmt.Errorf("rates: %s", resp.Status)
+	}
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Rate{}, err
+	}
+	rate, ok := body.Rates[currency]
+	if !ok {
+		return Rate{}, fmt.Errorf("rates: no rate of %s", currency)
+	}
+	return Rate{Currency: currency, Date: date, Rate: rate}, nil
+}
+
+type rateKey struct {
+	currency, date string
+}
+
+type cachedRate struct {
+	rate    Rate
+	expires time.Time
+}
+
+type rateCache struct {
+	next   RateProvider
+	ttl    time.Duration
+	hits   metrics.Counter
+	misses metrics.Counter
+
+	mtx   sync.Mutex
+	rates map[rateKey]cachedRate
+}
+
+// NewRateCache returns a provider caching rates of the next one. Latest rates are kept for ttl,
+// rates on past dates do not change and are kept for good. Hits and misses are counted, if given.
+func NewRateCache(next RateProvider, ttl time.Duration, hits, misses metrics.Counter) RateProvider {
+	if hits == nil {
+		hits = discard.NewCounter()
+	}
+	if misses == nil {
+		misses = discard.NewCounter()
+	}
+	return &rateCache{next: next, ttl: ttl, hits: hits, misses: misses, rates: make(map[rateKey]cachedRate)}
+}
+
+// Rate returns the cached rate, fetching it from the next provider when missing or expired.
+func (c *rateCache) Rate(ctx context.Context, currency string, date string) (Rate, error) {
+	key := rateKey{currency, date}
+	now := time.Now()
+	c.mtx.Lock()
+	cached, ok := c.rates[key]
+	c.mtx.Unlock()
+	if ok && (cached.expires.IsZero() || now.Before(cached.expires)) {
+		c.hits.Add(1)
+		return cached.rate, nil
+	}
+	c.misses.Add(1)
+
+	rate, err := c.next.Rate(ctx, currency, date)
+	if err != nil {
+		return Rate{}, err
+	}
+	cached = cachedRate{rate: rate}
+	if date == Latest {
+		cached.expires = now.Add(c.ttl)
+	}
+	c.mtx.Lock()
+	c.rates[key] = cached
+	c.mtx.Unlock()
+	return rate, nil
+}
@@ -0,0 +1,84 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/outbox"
+)
+
+// reversalEvent is a payload of outbox events describing a reversal with all its legs.
+type reversalEvent struct {
+	ID       uuid.UUID  `json:"id"`
+	Reverses uuid.UUID  `json:"reverses"`
+	Legs     []*Payment `json:"legs"`
+}
+
+// Reverse undoes an executed payment by a new one mirroring its legs: money credited by the
+// payment is debited back and money debited is credited back, in the amounts of the legs, so no
+// rate is applied. The reversal is a payment group of its own referring to the reversed one.
+// Accounts credited by the payment must still hold the money. Payments are reversed once,
+// reversals are never reversed; frozen accounts do not stop a reversal. The receipt tells the
+// amount returned to the payer, or taken back from the deposited account.
+func (s *service) Reverse(ctx context.Context, id uuid.UUID) (Receipt, error) {
+	if _, err := auth.Require(ctx, auth.RoleAdmin); err != nil {
+		return Receipt{}, err
+	}
+	legs, err := s.payments.FindGroup(id)
+	if err != nil {
+		return Receipt{}, err
+	}
+	if legs[0].ReversalOf != nil {
+		return Receipt{}, errs.ErrPaymentReversed
+	}
+	payer, err := s.accounts.Find(legs[0].Account)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	group := uuid.New()
+	now := time.Now().UTC()
+	var debited, credited []*Payment
+	for _, leg := range legs {
+		p := &Payment{
+			ID:         uuid.New(),
+			Group:      group,
+			Account:    leg.Account,
+			Amount:     leg.Amount,
+			CreatedAt:  now,
+			ReversalOf: &id,
+		}
+		if leg.Direction == Outgoing {
+			p.Direction, p.FromAccount = Incoming, leg.ToAccount
+			credited = append(credited, p)
+			continue
+		}
+		p.Direction, p.ToAccount = Outgoing, leg.FromAccount
+		a, err := s.accounts.Find(leg.Account)
+		if err != nil {
+			return Receipt{}, err
+		}
+		if a.Balance.LessThan(leg.Amount) {
+			return Receipt{}, errs.ErrInsufficientMoney
+		}
+		debited = append(debited, p)
+	}
+	payments := append(debited, credited...)
+
+	event, err := outbox.NewEvent(outbox.PaymentReversed, reversalEvent{ID: group, Reverses: id, Legs: payments})
+	if err != nil {
+		return Receipt{}, err
+	}
+	if err := s.payments.StoreReversal(id, []*outbox.Event{event}, payments...); err != nil {
+		if errors.Is(err, errs.ErrPaymentReversed) || errors.Is(err, errs.ErrInsufficientMoney) {
+			return Receipt{}, err
+		}
+		return Receipt{}, errs.ErrStorePayments
+	}
+	s.notify(payments...)
+	return Receipt{ID: group, Status: Executed, Amount: legs[0].Amount, Currency: payer.Currency}, nil
+}
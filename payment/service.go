@@ -2,14 +2,17 @@
 package payment
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/activity"
+	"github.com/ilyareist/task1/auth"
 	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/outbox"
+	"github.com/ilyareist/task1/screening"
 	"github.com/shopspring/decimal"
-	"io/ioutil"
-	"net/http"
+	"time"
 )
 
 // Direction of payment regarding account.
@@ -22,183 +25,493 @@ const (
 )
 
 // Payment holding a money transfer between two accounts in the system.
+// Every leg of one logical payment shares the same Group.
 type Payment struct {
 	ID          uuid.UUID       `json:"-" sql:"id,pk,type:varchar(36)"`
+	Group       uuid.UUID       `json:"group" sql:"group_id,type:varchar(36)"`
 	Account     account.ID      `json:"account" sql:"type:varchar(255)" pg:"fk:base_account_id"`
 	Amount      decimal.Decimal `json:"amount" sql:"amount,notnull,type:'decimal(16,4)'"`
 	ToAccount   account.ID      `json:"to_account,omitempty" sql:"to_account,type:varchar(255)" pg:"fk:to_account_id"`
 	FromAccount account.ID      `json:"from_account,omitempty" sql:"from_account,type:varchar(255)" pg:"fk:from_account_id"`
 	Direction   Direction       `json:"direction" sql:"direction,notnull,type:varchar(16)"`
 	Deleted     bool            `json:"-" sql:"deleted,notnull"`
+	CreatedAt   time.Time       `json:"created_at" sql:"created_at"`
+	ReversalOf  *uuid.UUID      `json:"reversal_of,omitempty" sql:"reversal_of,type:varchar(36)"`
 }
 
+// Leg is a single target of a split payment. Exactly one of Amount or Percent is set.
+type Leg struct {
+	To      account.ID      `json:"to"`
+	Amount  decimal.Decimal `json:"amount"`
+	Percent decimal.Decimal `json:"percent"`
+}
+
+// paymentEvent is a payload of outbox events describing a payment with all its legs.
+type paymentEvent struct {
+	ID   uuid.UUID  `json:"id"`
+	From account.ID `json:"from,omitempty"`
+	Legs []*Payment `json:"legs"`
+}
+
+// splitPrecision is the number of decimal places split shares are rounded down to.
+const splitPrecision = 2
+
+// Rate is an exchange rate of a currency against USD on a date. Rates imported by operators
+// are stored, one per currency and date.
 type Rate struct {
-	Currency string  `json:"currency" sql:"type:varchar(255)"`
-	Date     string  `json:"date" sql:"type:varchar(255)"`
-	Rate     float64 `json:"rate" sql:"type:float"`
+	TableName struct{} `json:"-" sql:"rates"`
+	Currency  string   `json:"currency" sql:"currency,pk,type:varchar(255)"`
+	Date      string   `json:"date" sql:"date,pk,type:varchar(255)"`
+	Rate      float64  `json:"rate" sql:"rate,notnull,type:float"`
 }
 
 // Service is the interface that provides payment methods.
+// Every method acts on behalf of the principal authenticated in the context.
+// Payments are screened before they are stored: blocked ones are refused,
+// and those sent to review are held until an operator decides on them.
 type Service interface {
 	// New registers a new payment in the system.
-	New(fromAccountID account.ID, amount decimal.Decimal, toAccountID account.ID) error
+	// Payments above the approval threshold are held pending approval instead.
+	New(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, toAccountID account.ID) (Receipt, error)
+
+	// Split debits one account once and credits every leg target within one payment.
+	// When legs are given in percentages, amount is the total to be split.
+	// Payments above the approval threshold are held pending approval instead.
+	Split(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, legs []Leg) (Receipt, error)
+
+	// Approve executes a payment pending approval or review. It must be approved by a principal
+	// other than the one who made it.
+	Approve(ctx context.Context, id uuid.UUID) error
+
+	// Reject declines a payment pending approval or review.
+	Reject(ctx context.Context, id uuid.UUID, reason string) error
+
+	// Approvals returns payment approvals in the given status.
+	Approvals(ctx context.Context, status Status) ([]*Approval, error)
 
 	// Load returns payments list for an account.
-	Load(accountID account.ID) []*Payment
+	Load(ctx context.Context, accountID account.ID) ([]*Payment, error)
+
+	// Statement returns the statement of an account over [from, to): opening balance, payments
+	// with running balance, totals and closing balance. A zero from opens the period with the
+	// account, a zero to closes it now.
+	Statement(ctx context.Context, accountID account.ID, from, to time.Time) (*Statement, error)
 
-	// LoadAll returns all payments, registered in the system.
-	LoadAll() []*Payment
+	// LoadAll returns all payments, registered in the system, which the caller may read.
+	LoadAll(ctx context.Context) []*Payment
 
 	// Show rate on the specific date
-	Rates(currency string, date string) (Rate, error)
+	Rates(ctx context.Context, currency string, date string) (Rate, error)
 
-	// Update an account returns a read model of an account.
-	Deposit(accountID account.ID, amount decimal.Decimal) error
+	// Deposit credits an account with the amount in USD.
+	Deposit(ctx context.Context, accountID account.ID, amount decimal.Decimal) (Receipt, error)
+
+	// Reverse undoes an executed payment by a new one moving every leg back.
+	Reverse(ctx context.Context, id uuid.UUID) (Receipt, error)
 }
 
 type service struct {
 	accounts account.Repository
 	payments Repository
+	activity activity.Publisher
+	rates    RateProvider
+	policy   ApprovalPolicy
+	screener screening.Screener
 }
 
 // New registers a new payment in the system.
-func (s *service) New(fromAccountID account.ID, amount decimal.Decimal, toAccountID account.ID) error {
+func (s *service) New(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, toAccountID account.ID) (Receipt, error) {
+	if err := account.Authorize(ctx, s.accounts, fromAccountID, auth.RoleAdmin, auth.RoleOperator); err != nil {
+		return Receipt{}, err
+	}
+	group := uuid.New()
+	payments, parties, err := s.transfer(ctx, group, fromAccountID, amount, toAccountID)
+	if err != nil {
+		return Receipt{}, err
+	}
+	return s.settle(ctx, &Approval{
+		ID:       group,
+		Kind:     screening.Transfer,
+		From:     fromAccountID,
+		To:       toAccountID,
+		Amount:   amount,
+		Debit:    payments[0].Amount,
+		Currency: parties[0].Currency,
+	}, parties, payments)
+}
+
+// transfer checks a payment between two accounts and returns its outgoing and incoming legs
+// together with the source and the target accounts.
+func (s *service) transfer(ctx context.Context, group uuid.UUID, fromAccountID account.ID, amount decimal.Decimal, toAccountID account.ID) ([]*Payment, []*account.Account, error) {
 	if fromAccountID == toAccountID {
-		return errs.ErrAccountsAreEqual
+		return nil, nil, errs.ErrAccountsAreEqual
 	}
 	from, err := s.accounts.Find(fromAccountID)
 	if err != nil {
-		return errs.ErrUnknownSourceAccount
+		return nil, nil, errs.ErrUnknownSourceAccount
 	}
-	fmt.Println(from.Currency)
-
-	var fromAmount decimal.Decimal
-	if from.Currency != "USD" {
-		date := "latest"
-		currency := string(from.Currency)
-		rate, _ := s.Rates(currency, date)
-		fromAmount = amount.Mul(decimal.NewFromFloat(rate.Rate))
-	} else {
-		fromAmount = amount
+	if from.Frozen {
+		return nil, nil, errs.ErrAccountFrozen
 	}
 
+	fromAmount, err := s.convert(ctx, from.Currency, amount)
+	if err != nil {
+		return nil, nil, err
+	}
 	if from.Balance.LessThan(fromAmount) {
-		return errs.ErrInsufficientMoney
+		return nil, nil, errs.ErrInsufficientMoney
 	}
 
-
 	to, err := s.accounts.Find(toAccountID)
-	//fmt.Println(to.Currency)
 	if err != nil {
-		return errs.ErrUnknownTargetAccount
+		return nil, nil, errs.ErrUnknownTargetAccount
 	}
-
-	var toAmount decimal.Decimal
-	if to.Currency != "USD" {
-		date := "latest"
-		currency := string(to.Currency)
-		rate, _ := s.Rates(currency, date)
-		toAmount = amount.Mul(decimal.NewFromFloat(rate.Rate))
-	} else {
-		toAmount = amount
+	if to.Frozen {
+		return nil, nil, errs.ErrAccountFrozen
+	}
+	toAmount, err := s.convert(ctx, to.Currency, amount)
+	if err != nil {
+		return nil, nil, err
 	}
 
-
-	outgoingPayment := Payment{
+	now := time.Now().UTC()
+	outgoingPayment := &Payment{
 		ID:        uuid.New(),
+		Group:     group,
 		Account:   fromAccountID,
 		Amount:    fromAmount,
 		ToAccount: toAccountID,
 		Direction: Outgoing,
+		CreatedAt: now,
 	}
-	incomingPayment := Payment{
+	incomingPayment := &Payment{
 		ID:          uuid.New(),
+		Group:       group,
 		Account:     toAccountID,
 		Amount:      toAmount,
 		FromAccount: fromAccountID,
 		Direction:   Incoming,
+		CreatedAt:   now,
+	}
+	return []*Payment{outgoingPayment, incomingPayment}, []*account.Account{from, to}, nil
+}
+
+// Deposit credits an account with the amount in USD.
+func (s *service) Deposit(ctx context.Context, accountID account.ID, amount decimal.Decimal) (Receipt, error) {
+	if _, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator); err != nil {
+		return Receipt{}, err
 	}
-	err = s.payments.Store(&outgoingPayment, &incomingPayment)
+	group := uuid.New()
+	payments, parties, err := s.deposit(ctx, group, accountID, amount)
 	if err != nil {
-		return errs.ErrStorePayments
+		return Receipt{}, err
 	}
-	return nil
+	return s.settle(ctx, &Approval{
+		ID:       group,
+		Kind:     screening.Deposit,
+		From:     accountID,
+		Amount:   amount,
+		Debit:    payments[0].Amount,
+		Currency: parties[0].Currency,
+	}, parties, payments)
 }
 
-func (s *service) Deposit(accountID account.ID, amount decimal.Decimal) error {
-
-	account, err := s.accounts.Find(accountID)
+// deposit checks a deposit and returns its only incoming leg together with the credited account.
+func (s *service) deposit(ctx context.Context, group uuid.UUID, accountID account.ID, amount decimal.Decimal) ([]*Payment, []*account.Account, error) {
+	to, err := s.accounts.Find(accountID)
 	if err != nil {
-		return errs.ErrUnknownSourceAccount
+		return nil, nil, errs.ErrUnknownSourceAccount
 	}
-	var amountUSD decimal.Decimal
-	fmt.Println(account.Currency)
-	if account.Currency != "USD" {
-		date := "latest"
-		currency := string(account.Currency)
-		rate, _ := s.Rates(currency, date)
-		amountUSD = amount.Mul(decimal.NewFromFloat(rate.Rate))
-	} else {
-		amountUSD = amount
+	if to.Frozen {
+		return nil, nil, errs.ErrAccountFrozen
 	}
-
-	incomingPayment := Payment{
-		ID:          uuid.New(),
+	toAmount, err := s.convert(ctx, to.Currency, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	incomingPayment := &Payment{
+		ID:          group,
+		Group:       group,
 		Account:     accountID,
-		Amount:      amountUSD,
+		Amount:      toAmount,
 		FromAccount: accountID,
 		Direction:   Incoming,
+		CreatedAt:   time.Now().UTC(),
+	}
+	return []*Payment{incomingPayment}, []*account.Account{to}, nil
+}
+
+// Split debits one account once and credits every leg target within one payment.
+func (s *service) Split(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, legs []Leg) (Receipt, error) {
+	if err := account.Authorize(ctx, s.accounts, fromAccountID, auth.RoleAdmin, auth.RoleOperator); err != nil {
+		return Receipt{}, err
 	}
-	err = s.payments.Store(&incomingPayment)
+	group := uuid.New()
+	payments, parties, err := s.split(ctx, group, fromAccountID, amount, legs)
 	if err != nil {
-		return errs.ErrStorePayments
+		return Receipt{}, err
 	}
-	return nil
+	return s.settle(ctx, &Approval{
+		ID:       group,
+		Kind:     screening.Split,
+		From:     fromAccountID,
+		Legs:     legs,
+		Amount:   amount,
+		Debit:    payments[0].Amount,
+		Currency: parties[0].Currency,
+	}, parties, payments)
 }
 
-// Load returns payments list for an account.
-func (s *service) Load(accountID account.ID) []*Payment {
-	return s.payments.Find(accountID)
+// split checks a split payment and returns its outgoing leg followed by incoming ones,
+// together with the source account followed by the leg targets.
+func (s *service) split(ctx context.Context, group uuid.UUID, fromAccountID account.ID, amount decimal.Decimal, legs []Leg) ([]*Payment, []*account.Account, error) {
+	shares, total, err := splitShares(amount, legs)
+	if err != nil {
+		return nil, nil, err
+	}
+	from, err := s.accounts.Find(fromAccountID)
+	if err != nil {
+		return nil, nil, errs.ErrUnknownSourceAccount
+	}
+	if from.Frozen {
+		return nil, nil, errs.ErrAccountFrozen
+	}
+	fromAmount, err := s.convert(ctx, from.Currency, total)
+	if err != nil {
+		return nil, nil, err
+	}
+	if from.Balance.LessThan(fromAmount) {
+		return nil, nil, errs.ErrInsufficientMoney
+	}
+
+	now := time.Now().UTC()
+	payments := []*Payment{{
+		ID:        uuid.New(),
+		Group:     group,
+		Account:   fromAccountID,
+		Amount:    fromAmount,
+		Direction: Outgoing,
+		CreatedAt: now,
+	}}
+	parties := []*account.Account{from}
+	seen := make(map[account.ID]bool, len(legs))
+	for i, leg := range legs {
+		if leg.To == fromAccountID {
+			return nil, nil, errs.ErrAccountsAreEqual
+		}
+		if seen[leg.To] {
+			return nil, nil, errs.ErrInvalidSplit
+		}
+		seen[leg.To] = true
+
+		to, err := s.accounts.Find(leg.To)
+		if err != nil {
+			return nil, nil, errs.ErrUnknownTargetAccount
+		}
+		if to.Frozen {
+			return nil, nil, errs.ErrAccountFrozen
+		}
+		toAmount, err := s.convert(ctx, to.Currency, shares[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		parties = append(parties, to)
+		payments = append(payments, &Payment{
+			ID:          uuid.New(),
+			Group:       group,
+			Account:     leg.To,
+			Amount:      toAmount,
+			FromAccount: fromAccountID,
+			Direction:   Incoming,
+			CreatedAt:   now,
+		})
+	}
+	return payments, parties, nil
 }
 
-// LoadAll returns all payments, registered in the system.
-func (s *service) LoadAll() []*Payment {
-	return s.payments.FindAll()
+// settle screens a checked payment, then stores it or holds it as described by the approval.
+// Parties are the debited, or deposited, account followed by the credited ones.
+func (s *service) settle(ctx context.Context, a *Approval, parties []*account.Account, payments []*Payment) (Receipt, error) {
+	p, _ := auth.FromContext(ctx)
+	result, err := s.screener.Screen(ctx, &screening.Subject{
+		Reference:      a.ID,
+		Kind:           a.Kind,
+		Account:        parties[0],
+		Counterparties: parties[1:],
+		Amount:         a.Debit,
+		Principal:      p.ID,
+	})
+	if err != nil {
+		return Receipt{}, errs.ErrScreening
+	}
+	switch {
+	case result.Decision == screening.Block:
+		return Receipt{}, errs.ErrPaymentBlocked
+	case result.Decision == screening.Review:
+		a.Findings = result.Findings
+		return s.hold(ctx, a, PendingReview)
+	case a.Kind != screening.Deposit && s.policy.requires(a.Currency, a.Debit):
+		return s.hold(ctx, a, PendingApproval)
+	}
+	events, err := paymentEvents(a.Kind, payments)
+	if err != nil {
+		return Receipt{}, err
+	}
+	if err := s.payments.Store(events, payments...); err != nil {
+		if errors.Is(err, errs.ErrInsufficientMoney) {
+			return Receipt{}, err
+		}
+		return Receipt{}, errs.ErrStorePayments
+	}
+	s.notify(payments...)
+	return a.receipt(Executed), nil
 }
 
-// Convert returns amount in a given currency
-func (s *service) Rates(currency string, date string) (Rate, error) {
-	var currentRate Rate
-	var f map[string]interface{}
-	url := "https://api.exchangeratesapi.io/"
+// paymentEvents returns outbox events announcing payment legs, led by the outgoing one.
+func paymentEvents(kind screening.Kind, payments []*Payment) ([]*outbox.Event, error) {
+	typ, payload := outbox.PaymentCreated, paymentEvent{
+		ID:   payments[0].Group,
+		From: payments[0].Account,
+		Legs: payments,
+	}
+	if kind == screening.Deposit {
+		typ, payload.From = outbox.DepositCompleted, ""
+	}
+	event, err := outbox.NewEvent(typ, payload)
+	if err != nil {
+		return nil, err
+	}
+	return []*outbox.Event{event}, nil
+}
+
+// splitShares resolves every leg into an absolute amount and returns the total to debit.
+// Legs must use either amounts or percentages summing to 100, never a mix of both.
+// Percentage shares are rounded down to splitPrecision and the rounding remainder goes
+// to the first leg, so the shares always add up to the total exactly.
+func splitShares(amount decimal.Decimal, legs []Leg) ([]decimal.Decimal, decimal.Decimal, error) {
+	if len(legs) == 0 {
+		return nil, decimal.Zero, errs.ErrInvalidSplit
+	}
+	byPercent := !legs[0].Percent.IsZero()
+	shares := make([]decimal.Decimal, len(legs))
+	sum := decimal.Zero
+	for i, leg := range legs {
+		if leg.Amount.IsZero() == leg.Percent.IsZero() || !leg.Percent.IsZero() != byPercent {
+			return nil, decimal.Zero, errs.ErrInvalidSplit
+		}
+		if leg.Amount.IsNegative() || leg.Percent.IsNegative() {
+			return nil, decimal.Zero, errs.ErrInvalidArgument
+		}
+		if byPercent {
+			shares[i] = amount.Mul(leg.Percent).Div(decimal.New(100, 0)).Truncate(splitPrecision)
+			sum = sum.Add(leg.Percent)
+		} else {
+			shares[i] = leg.Amount
+			sum = sum.Add(leg.Amount)
+		}
+	}
+
+	if !byPercent {
+		if !amount.IsZero() && !amount.Equal(sum) {
+			return nil, decimal.Zero, errs.ErrInvalidSplit
+		}
+		return shares, sum, nil
+	}
+
+	if !amount.IsPositive() || !sum.Equal(decimal.New(100, 0)) {
+		return nil, decimal.Zero, errs.ErrInvalidSplit
+	}
+	allocated := decimal.Zero
+	for _, share := range shares {
+		allocated = allocated.Add(share)
+	}
+	shares[0] = shares[0].Add(amount.Sub(allocated))
+	return shares, amount, nil
+}
+
+// Load returns payments list for an account.
+func (s *service) Load(ctx context.Context, accountID account.ID) ([]*Payment, error) {
+	err := account.Authorize(ctx, s.accounts, accountID, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor)
+	if err != nil {
+		return nil, err
+	}
+	return s.payments.Find(accountID), nil
+}
 
-	response, err := http.Get(url + date + "?base=USD&&symbols=" + currency)
-	body, _ := ioutil.ReadAll(response.Body)
+// LoadAll returns all payments, registered in the system, which the caller may read.
+// Customers get payments of accounts they own.
+func (s *service) LoadAll(ctx context.Context) []*Payment {
+	p, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor, auth.RoleCustomer)
+	if err != nil {
+		return nil
+	}
+	if p.HasRole(auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor) {
+		return s.payments.FindAll()
+	}
+	var payments []*Payment
+	for _, a := range s.accounts.FindOwned(p.ID) {
+		payments = append(payments, s.payments.Find(a.ID)...)
+	}
+	return payments
+}
 
-	if err := json.Unmarshal([]byte(body), &f); err != nil {
-		return currentRate, err
+// Convert returns amount in a given currency
+func (s *service) Rates(ctx context.Context, currency string, date string) (Rate, error) {
+	if _, err := auth.Require(ctx); err != nil {
+		return Rate{}, err
 	}
+	return s.rates.Rate(ctx, currency, date)
+}
 
-	k, _ := f["rates"].(map[string]interface{})[currency].(float64)
+// notify announces committed payments and resulting balances of their accounts.
+func (s *service) notify(payments ...*Payment) {
+	for _, p := range payments {
+		if e, err := activity.NewEvent(p.Account, activity.PaymentType, p); err == nil {
+			s.activity.Publish(e)
+		}
+		a, err := s.accounts.Find(p.Account)
+		if err != nil {
+			continue
+		}
+		balance := struct {
+			Balance  decimal.Decimal  `json:"balance"`
+			Currency account.Currency `json:"currency"`
+		}{a.Balance, a.Currency}
+		if e, err := activity.NewEvent(p.Account, activity.BalanceType, balance); err == nil {
+			s.activity.Publish(e)
+		}
+	}
+}
 
-	currentRate.Currency = currency
-	currentRate.Date = date
-	currentRate.Rate = k
-	return currentRate, err
+// convert returns USD amount expressed in the given account currency by the latest rate.
+func (s *service) convert(ctx context.Context, currency account.Currency, amount decimal.Decimal) (decimal.Decimal, error) {
+	if currency == account.CurrencyUSD {
+		return amount, nil
+	}
+	rate, err := s.rates.Rate(ctx, string(currency), Latest)
+	if err != nil {
+		return decimal.Zero, errs.ErrRateUnavailable
+	}
+	return amount.Mul(decimal.NewFromFloat(rate.Rate)), nil
 }
 
 // NewService creates a payment service with necessary dependencies.
-func NewService(payments Repository, accounts account.Repository) Service {
+func NewService(payments Repository, accounts account.Repository, publisher activity.Publisher, rates RateProvider, policy ApprovalPolicy, screener screening.Screener) Service {
 	return &service{
 		payments: payments,
 		accounts: accounts,
+		activity: publisher,
+		rates:    rates,
+		policy:   policy,
+		screener: screener,
 	}
 }
 
 // Repository interface for payment storing and operations.
 type Repository interface {
-	// Store payments in the repository.
-	Store(payment ...*Payment) error
+	// Store payments in the repository together with outbox events describing them.
+	// It fails with errs.ErrInsufficientMoney when a debited account no longer holds the money.
+	Store(events []*outbox.Event, payment ...*Payment) error
 
 	// Find payments list for an account.
 	Find(id account.ID) []*Payment
@@ -209,4 +522,35 @@ type Repository interface {
 
 	// MarkDeleted is mark as deleted specified payment in the system
 	MarkDeleted(id uuid.UUID) error
+
+	// FindGroup returns the legs of a payment, the outgoing ones first.
+	FindGroup(group uuid.UUID) ([]*Payment, error)
+
+	// FindLedger returns an account together with every payment leg its balance is made of,
+	// in the order they were made, as of one moment.
+	FindLedger(id account.ID) (*account.Account, []*Payment, error)
+
+	// StoreReversal stores payments reversing the payment group together with outbox events,
+	// provided the group has not been reversed yet and the debited accounts hold the money.
+	StoreReversal(group uuid.UUID, events []*outbox.Event, payment ...*Payment) error
+
+	// StoreApproval stores a new payment approval.
+	StoreApproval(a *Approval) error
+
+	// FindApproval returns a payment approval with specified id.
+	FindApproval(id uuid.UUID) (*Approval, error)
+
+	// FindApprovals returns payment approvals in the given status.
+	FindApprovals(status Status) []*Approval
+
+	// UpdateApproval stores the decision on an approval, provided it is still in the given status.
+	UpdateApproval(a *Approval, status Status) error
+
+	// ExecuteApproval stores the decision on an approval, provided it is still in the given status,
+	// together with payments and events, all in one transaction. Like Store, it fails with
+	// errs.ErrInsufficientMoney when a debited account no longer holds the money.
+	ExecuteApproval(a *Approval, status Status, events []*outbox.Event, payment ...*Payment) error
+
+	// ExpireApprovals marks approvals pending approval or review longer than allowed as expired.
+	ExpireApprovals(now time.Time) (int, error)
 }
@@ -0,0 +1,86 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func TestSplitSharesAmounts(t *testing.T) {
+	legs := []Leg{{To: "a", Amount: d("10.50")}, {To: "b", Amount: d("4.5")}}
+	shares, total, err := splitShares(decimal.Zero, legs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !total.Equal(d("15")) || !shares[0].Equal(d("10.5")) || !shares[1].Equal(d("4.5")) {
+		t.Errorf("got shares %v total %v", shares, total)
+	}
+	if _, _, err := splitShares(d("16"), legs); !errors.Is(err, errs.ErrInvalidSplit) {
+		t.Errorf("amount other than the sum of legs: got %v, want %v", err, errs.ErrInvalidSplit)
+	}
+}
+
+func TestSplitSharesRemainder(t *testing.T) {
+	tests := []struct {
+		amount   string
+		percents []string
+		want     []string
+	}{
+		{"100", []string{"50", "50"}, []string{"50", "50"}},
+		{"100", []string{"33.33", "33.33", "33.34"}, []string{"33.33", "33.33", "33.34"}},
+		{"10", []string{"33.33", "33.33", "33.34"}, []string{"3.34", "3.33", "3.33"}},
+		{"0.05", []string{"50", "50"}, []string{"0.03", "0.02"}},
+		{"1", []string{"1", "99"}, []string{"0.01", "0.99"}},
+		{"0.01", []string{"10", "90"}, []string{"0.01", "0"}},
+	}
+	for _, tt := range tests {
+		legs := make([]Leg, len(tt.percents))
+		for i, p := range tt.percents {
+			legs[i] = Leg{To: "x", Percent: d(p)}
+		}
+		shares, total, err := splitShares(d(tt.amount), legs)
+		if err != nil {
+			t.Errorf("%s split %v: %v", tt.amount, tt.percents, err)
+			continue
+		}
+		if !total.Equal(d(tt.amount)) {
+			t.Errorf("%s split %v: total %v", tt.amount, tt.percents, total)
+		}
+		sum := decimal.Zero
+		for i, share := range shares {
+			sum = sum.Add(share)
+			if !share.Equal(d(tt.want[i])) {
+				t.Errorf("%s split %v: share %d is %v, want %s", tt.amount, tt.percents, i, share, tt.want[i])
+			}
+		}
+		if !sum.Equal(total) {
+			t.Errorf("%s split %v: shares add up to %v", tt.amount, tt.percents, sum)
+		}
+	}
+}
+
+func TestSplitSharesInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount string
+		legs   []Leg
+		want   error
+	}{
+		{"no legs", "10", nil, errs.ErrInvalidSplit},
+		{"mixed", "10", []Leg{{Percent: d("50")}, {Amount: d("5")}}, errs.ErrInvalidSplit},
+		{"both set", "10", []Leg{{Percent: d("100"), Amount: d("10")}}, errs.ErrInvalidSplit},
+		{"neither set", "10", []Leg{{}}, errs.ErrInvalidSplit},
+		{"short of 100", "10", []Leg{{Percent: d("50")}, {Percent: d("49")}}, errs.ErrInvalidSplit},
+		{"no amount", "0", []Leg{{Percent: d("100")}}, errs.ErrInvalidSplit},
+		{"negative", "0", []Leg{{Amount: d("-1")}}, errs.ErrInvalidArgument},
+	}
+	for _, tt := range tests {
+		if _, _, err := splitShares(d(tt.amount), tt.legs); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package payment
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+// Statement of an account over a period: its balance when the period opens, every payment leg
+// made in the period with the balance after it, and its balance when the period closes.
+type Statement struct {
+	Account  account.ID       `json:"account"`
+	Currency account.Currency `json:"currency"`
+	// From is when the period opens, nil when it opens with the account.
+	From    *time.Time      `json:"from,omitempty"`
+	To      time.Time       `json:"to"`
+	Opening decimal.Decimal `json:"opening_balance"`
+	In      decimal.Decimal `json:"total_in"`
+	Out     decimal.Decimal `json:"total_out"`
+	Closing decimal.Decimal `json:"closing_balance"`
+	Entries []Entry         `json:"entries"`
+}
+
+// Entry is a payment leg on a statement together with the balance of the account after it.
+type Entry struct {
+	Payment   uuid.UUID `json:"payment"`
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	// Counterparty is the account money came from or went to, empty for deposits and splits.
+	Counterparty account.ID      `json:"counterparty,omitempty"`
+	ReversalOf   *uuid.UUID      `json:"reversal_of,omitempty"`
+	Amount       decimal.Decimal `json:"amount"`
+	Balance      decimal.Decimal `json:"balance"`
+}
+
+// Description tells what the entry is in words.
+func (e Entry) Description() string {
+	switch {
+	case e.ReversalOf != nil:
+		return "reversal of " + e.ReversalOf.String()
+	case e.Direction == Incoming && e.Counterparty == "":
+		return "deposit"
+	case e.Direction == Incoming:
+		return "from " + string(e.Counterparty)
+	case e.Counterparty == "":
+		return "split payment"
+	default:
+		return "to " + string(e.Counterparty)
+	}
+}
+
+// NewStatement returns the statement of the account over [from, to) made of legs, all payment
+// legs of the account in the order they were made, as returned by Repository.FindLedger.
+// Balances are worked back from the balance of the account, so that a statement closing after
+// the last leg closes with the balance of the account exactly. A zero from opens the period with
+// the account.
+func NewStatement(a *account.Account, legs []*Payment, from, to time.Time) *Statement {
+	st := &Statement{
+		Account:  a.ID,
+		Currency: a.Currency,
+		To:       to,
+		Entries:  []Entry{},
+	}
+	if !from.IsZero() {
+		st.From = &from
+	}
+	// The account opened with its balance less every leg.
+	balance := a.Balance
+	for _, leg := range legs {
+		balance = balance.Sub(signed(leg))
+	}
+	st.Opening = balance
+	for _, leg := range legs {
+		if !leg.CreatedAt.Before(to) {
+			break
+		}
+		balance = balance.Add(signed(leg))
+		if leg.CreatedAt.Before(from) {
+			st.Opening = balance
+			continue
+		}
+		if leg.Direction == Incoming {
+			st.In = st.In.Add(leg.Amount)
+		} else {
+			st.Out = st.Out.Add(leg.Amount)
+		}
+		st.Entries = append(st.Entries, entry(a.ID, leg, balance))
+	}
+	st.Closing = balance
+	return st
+}
+
+// signed returns the amount a leg adds to the balance of its account.
+func signed(p *Payment) decimal.Decimal {
+	if p.Direction == Outgoing {
+		return p.Amount.Neg()
+	}
+	return p.Amount
+}
+
+// entry returns the statement entry of a leg of the account.
+func entry(id account.ID, p *Payment, balance decimal.Decimal) Entry {
+	counterparty := p.FromAccount
+	if p.Direction == Outgoing {
+		counterparty = p.ToAccount
+	}
+	// Deposits, and their reversals, name the account itself.
+	if counterparty == id {
+		counterparty = ""
+	}
+	return Entry{
+		Payment:      p.Group,
+		Time:         p.CreatedAt,
+		Direction:    p.Direction,
+		Counterparty: counterparty,
+		ReversalOf:   p.ReversalOf,
+		Amount:       p.Amount,
+		Balance:      balance,
+	}
+}
+
+// WriteCSV writes the statement as CSV records of date, payment, description, money in,
+// money out and balance, between records of the opening balance, the totals and the closing
+// balance.
+func (st *Statement) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	opened := ""
+	if st.From != nil {
+		opened = stamp(*st.From, time.RFC3339)
+	}
+	records := [][]string{
+		{"date", "payment", "description", "in", "out", "balance"},
+		{opened, "", "opening balance", "", "", money(st.Opening)},
+	}
+	for _, e := range st.Entries {
+		in, out := money(e.Amount), ""
+		if e.Direction == Outgoing {
+			in, out = "", in
+		}
+		records = append(records, []string{stamp(e.Time, time.RFC3339), e.Payment.String(), e.Description(), in, out, money(e.Balance)})
+	}
+	closed := st.To.UTC().Format(time.RFC3339)
+	records = append(records,
+		[]string{"", "", "totals", money(st.In), money(st.Out), ""},
+		[]string{closed, "", "closing balance", "", "", money(st.Closing)},
+	)
+	if err := cw.WriteAll(records); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// ParseBound parses a bound of a statement period, zero when it is empty. Bounds are RFC 3339
+// times or dates, YYYY-MM-DD, standing for midnight UTC opening the day, or closing it when
+// the bound ends the period.
+func ParseBound(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, errs.ErrInvalidArgument
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// stamp formats a time by the layout, leaving it blank when it is not known.
+func stamp(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(layout)
+}
+
+// money formats an amount with two decimal places, or all it has when it has more.
+func money(d decimal.Decimal) string {
+	if d.Equal(d.Round(2)) {
+		return d.StringFixed(2)
+	}
+	return d.String()
+}
+
+// Statement returns the statement of an account over [from, to), worked out of the payments
+// its balance is made of. A zero from opens the period with the account, a zero to closes it
+// now, with the balance of the account.
+func (s *service) Statement(ctx context.Context, accountID account.ID, from, to time.Time) (*Statement, error) {
+	err := account.Authorize(ctx, s.accounts, accountID, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor)
+	if err != nil {
+		return nil, err
+	}
+	if !to.IsZero() && !from.Before(to) {
+		return nil, errs.ErrInvalidArgument
+	}
+	a, legs, err := s.payments.FindLedger(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if to.IsZero() {
+		// Legs are read by now, so every one of them falls in the period.
+		to = time.Now().UTC()
+	}
+	return NewStatement(a, legs, from, to), nil
+}
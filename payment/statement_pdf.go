@@ -0,0 +1,103 @@
+package payment
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// statementColumns are the titles, widths in millimetres and alignments of statement PDF columns,
+// which fill the width of an A4 page between margins of 10mm.
+var statementColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"Date", 32, "L"},
+	{"Description", 83, "L"},
+	{"In", 25, "R"},
+	{"Out", 25, "R"},
+	{"Balance", 25, "R"},
+}
+
+// WritePDF writes the statement as an A4 PDF document: a table of entries between rows of the
+// opening balance, the totals and the closing balance, headed on every page.
+func (st *Statement) WritePDF(w io.Writer) error {
+	const layout, rowHeight = "2006-01-02 15:04", 6.0
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetTitle("Statement of account "+string(st.Account), true)
+	pdf.SetCreationDate(st.To)
+
+	head := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		for _, c := range statementColumns {
+			pdf.CellFormat(c.width, rowHeight, c.title, "B", 0, c.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+	row := func(bold bool, cells ...string) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, 9)
+		for i, c := range statementColumns {
+			pdf.CellFormat(c.width, rowHeight, fit(pdf, cells[i], c.width-2), "", 0, c.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.SetHeaderFunc(func() {
+		if pdf.PageNo() > 1 {
+			head()
+		}
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 10, string(st.Account)+" - page "+strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 10, fit(pdf, "Statement of account "+string(st.Account), 190), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	period := "Up to " + st.To.UTC().Format(layout) + " UTC"
+	if st.From != nil {
+		period = st.From.UTC().Format(layout) + " to " + st.To.UTC().Format(layout) + " UTC"
+	}
+	pdf.CellFormat(0, 6, period, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Currency: "+string(st.Currency), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	head()
+	opened := ""
+	if st.From != nil {
+		opened = st.From.UTC().Format(layout)
+	}
+	row(true, opened, "Opening balance", "", "", money(st.Opening))
+	for _, e := range st.Entries {
+		in, out := money(e.Amount), ""
+		if e.Direction == Outgoing {
+			in, out = "", in
+		}
+		row(false, stamp(e.Time, layout), e.Description(), in, out, money(e.Balance))
+	}
+	row(true, "", "Totals", money(st.In), money(st.Out), "")
+	row(true, st.To.UTC().Format(layout), "Closing balance", "", "", money(st.Closing))
+	return pdf.Output(w)
+}
+
+// fit shortens text to the width in the current font, marking the cut with an ellipsis.
+func fit(pdf *gofpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
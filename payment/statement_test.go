@@ -0,0 +1,110 @@
+package payment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+)
+
+func day(n int) time.Time { return time.Date(2019, 10, n, 0, 0, 0, 0, time.UTC) }
+
+// ledger returns an account holding 150 USD and its legs: a deposit of 100, 20 paid to alice,
+// 50 received from bob and the payment to alice reversed.
+func ledger() (*account.Account, []*Payment) {
+	toAlice := uuid.New()
+	legs := []*Payment{
+		{Group: uuid.New(), Account: "john", FromAccount: "john", ToAccount: "john", Direction: Incoming, Amount: d("100"), CreatedAt: day(1)},
+		{Group: toAlice, Account: "john", FromAccount: "john", ToAccount: "alice", Direction: Outgoing, Amount: d("20"), CreatedAt: day(2)},
+		{Group: uuid.New(), Account: "john", FromAccount: "bob", ToAccount: "john", Direction: Incoming, Amount: d("50"), CreatedAt: day(3)},
+		{Group: uuid.New(), Account: "john", FromAccount: "alice", ToAccount: "john", Direction: Incoming, Amount: d("20"), CreatedAt: day(4), ReversalOf: &toAlice},
+	}
+	return &account.Account{ID: "john", Currency: account.CurrencyUSD, Balance: d("150")}, legs
+}
+
+func TestNewStatement(t *testing.T) {
+	tests := []struct {
+		name                      string
+		from, to                  time.Time
+		opening, in, out, closing string
+		balances                  []string
+	}{
+		{"whole life", time.Time{}, day(10), "0", "170", "20", "150", []string{"100", "80", "130", "150"}},
+		{"bounds half open", day(2), day(4), "100", "50", "20", "130", []string{"80", "130"}},
+		{"within a day", day(2).Add(time.Hour), day(3).Add(time.Hour), "80", "50", "0", "130", []string{"130"}},
+		{"before the first leg", day(0), day(1), "0", "0", "0", "0", nil},
+		{"after the last leg", day(5), day(6), "150", "0", "0", "150", nil},
+	}
+	for _, tt := range tests {
+		a, legs := ledger()
+		st := NewStatement(a, legs, tt.from, tt.to)
+		got := []string{st.Opening.String(), st.In.String(), st.Out.String(), st.Closing.String()}
+		want := []string{tt.opening, tt.in, tt.out, tt.closing}
+		for i, field := range []string{"opening", "in", "out", "closing"} {
+			if !d(got[i]).Equal(d(want[i])) {
+				t.Errorf("%s: %s %s, want %s", tt.name, field, got[i], want[i])
+			}
+		}
+		// The statement reconciles: the opening balance with the money in and out is the closing
+		// balance, which is the balance after the last entry.
+		if !st.Opening.Add(st.In).Sub(st.Out).Equal(st.Closing) {
+			t.Errorf("%s: %v + %v - %v is not %v", tt.name, st.Opening, st.In, st.Out, st.Closing)
+		}
+		if n := len(st.Entries); n > 0 && !st.Entries[n-1].Balance.Equal(st.Closing) {
+			t.Errorf("%s: last balance %v, closing %v", tt.name, st.Entries[n-1].Balance, st.Closing)
+		}
+		if len(st.Entries) != len(tt.balances) {
+			t.Errorf("%s: got %d entries, want %d", tt.name, len(st.Entries), len(tt.balances))
+			continue
+		}
+		for i, e := range st.Entries {
+			if !e.Balance.Equal(d(tt.balances[i])) {
+				t.Errorf("%s: entry %d balance %v, want %s", tt.name, i, e.Balance, tt.balances[i])
+			}
+			if e.Time.Before(tt.from) || !e.Time.Before(tt.to) {
+				t.Errorf("%s: entry %d at %v is out of the period", tt.name, i, e.Time)
+			}
+		}
+		if (st.From == nil) != tt.from.IsZero() {
+			t.Errorf("%s: from %v", tt.name, st.From)
+		}
+	}
+}
+
+func TestStatementDescriptions(t *testing.T) {
+	a, legs := ledger()
+	legs = append(legs, &Payment{Group: uuid.New(), Account: "john", FromAccount: "john", Direction: Outgoing, Amount: d("10"), CreatedAt: day(5)})
+	a.Balance = d("140")
+	st := NewStatement(a, legs, time.Time{}, day(10))
+	want := []string{"deposit", "to alice", "from bob", "reversal of " + legs[1].Group.String(), "split payment"}
+	for i, e := range st.Entries {
+		if got := e.Description(); got != want[i] {
+			t.Errorf("entry %d: got %q, want %q", i, got, want[i])
+		}
+	}
+	if !st.Closing.Equal(a.Balance) {
+		t.Errorf("closing %v, want the account balance %v", st.Closing, a.Balance)
+	}
+}
+
+func TestParseBound(t *testing.T) {
+	tests := []struct {
+		s    string
+		end  bool
+		want time.Time
+		ok   bool
+	}{
+		{"", false, time.Time{}, true},
+		{"2019-10-02", false, day(2), true},
+		{"2019-10-02", true, day(3), true},
+		{"2019-10-02T03:00:00+03:00", true, day(2), true},
+		{"02.10.2019", false, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseBound(tt.s, tt.end)
+		if (err == nil) != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("ParseBound(%q, %v) = %v, %v", tt.s, tt.end, got, err)
+		}
+	}
+}
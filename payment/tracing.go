@@ -0,0 +1,97 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/tracing"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type tracingService struct {
+	Service
+}
+
+// NewTracingService returns an instance of a tracing Service. Every call runs in a span
+// named after the method.
+func NewTracingService(s Service) Service {
+	return &tracingService{s}
+}
+
+func (s *tracingService) New(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, toAccountID account.ID) (r Receipt, err error) {
+	ctx, span := tracing.Start(ctx, "payment.New",
+		attribute.String("account.from", string(fromAccountID)),
+		attribute.String("account.to", string(toAccountID)),
+	)
+	defer func() { tracing.End(span, err) }()
+	return s.Service.New(ctx, fromAccountID, amount, toAccountID)
+}
+
+func (s *tracingService) Split(ctx context.Context, fromAccountID account.ID, amount decimal.Decimal, legs []Leg) (r Receipt, err error) {
+	ctx, span := tracing.Start(ctx, "payment.Split",
+		attribute.String("account.from", string(fromAccountID)),
+		attribute.Int("payment.legs", len(legs)),
+	)
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Split(ctx, fromAccountID, amount, legs)
+}
+
+func (s *tracingService) Reverse(ctx context.Context, id uuid.UUID) (r Receipt, err error) {
+	ctx, span := tracing.Start(ctx, "payment.Reverse", attribute.String("payment.id", id.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Reverse(ctx, id)
+}
+
+func (s *tracingService) Approve(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "payment.Approve", attribute.String("approval.id", id.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Approve(ctx, id)
+}
+
+func (s *tracingService) Reject(ctx context.Context, id uuid.UUID, reason string) (err error) {
+	ctx, span := tracing.Start(ctx, "payment.Reject", attribute.String("approval.id", id.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Reject(ctx, id, reason)
+}
+
+func (s *tracingService) Approvals(ctx context.Context, status Status) (approvals []*Approval, err error) {
+	ctx, span := tracing.Start(ctx, "payment.Approvals", attribute.String("approval.status", string(status)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Approvals(ctx, status)
+}
+
+func (s *tracingService) Load(ctx context.Context, accountID account.ID) (payments []*Payment, err error) {
+	ctx, span := tracing.Start(ctx, "payment.Load", attribute.String("account.id", string(accountID)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Load(ctx, accountID)
+}
+
+func (s *tracingService) Statement(ctx context.Context, accountID account.ID, from, to time.Time) (st *Statement, err error) {
+	ctx, span := tracing.Start(ctx, "payment.Statement", attribute.String("account.id", string(accountID)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Statement(ctx, accountID, from, to)
+}
+
+func (s *tracingService) LoadAll(ctx context.Context) []*Payment {
+	ctx, span := tracing.Start(ctx, "payment.LoadAll")
+	defer span.End()
+	return s.Service.LoadAll(ctx)
+}
+
+func (s *tracingService) Rates(ctx context.Context, currency string, date string) (r Rate, err error) {
+	ctx, span := tracing.Start(ctx, "payment.Rates",
+		attribute.String("rate.currency", currency),
+		attribute.String("rate.date", date),
+	)
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Rates(ctx, currency, date)
+}
+
+func (s *tracingService) Deposit(ctx context.Context, accountID account.ID, amount decimal.Decimal) (r Receipt, err error) {
+	ctx, span := tracing.Start(ctx, "payment.Deposit", attribute.String("account.id", string(accountID)))
+	defer func() { tracing.End(span, err) }()
+	return s.Service.Deposit(ctx, accountID, amount)
+}
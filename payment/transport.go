@@ -1,14 +1,20 @@
 package payment
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 
-	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
 	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/auth"
 	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/openapi"
+	"github.com/ilyareist/task1/tracing"
+	"github.com/ilyareist/task1/validate"
 
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/transport"
@@ -16,90 +22,183 @@ import (
 	"github.com/gorilla/mux"
 )
 
-// MakeHandler returns a handler for the payment service.
-func MakeHandler(s Service, logger kitlog.Logger) http.Handler {
-	opts := []kithttp.ServerOption{
-		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
-		kithttp.ServerErrorEncoder(errs.EncodeError),
-	}
+// MakeHandler returns a handler for the payment service endpoints.
+func MakeHandler(eps Endpoints, logger kitlog.Logger) http.Handler {
+	opts := serverOptions(logger)
 
 	newPaymentHandler := kithttp.NewServer(
-		makeNewPaymentEndpoint(s),
+		eps.NewPaymentEndpoint,
 		decodeNewPaymentRequest,
 		errs.EncodeResponse,
 		opts...,
 	)
 
+	splitPaymentHandler := kithttp.NewServer(
+		eps.SplitPaymentEndpoint,
+		decodeSplitPaymentRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
 	newDepositHandler := kithttp.NewServer(
-		makeDepositEndpoint(s),
+		eps.DepositEndpoint,
 		decodeDepositRequest,
 		errs.EncodeResponse,
 		opts...,
 	)
 	ratesPaymentHandler := kithttp.NewServer(
-		makeRatesCurrencyEndpoint(s),
+		eps.RatesCurrencyEndpoint,
 		decodeRatesPaymentRequest,
 		errs.EncodeResponse,
 		opts...,
 	)
 
 	loadPaymentsHandler := kithttp.NewServer(
-		makeLoadPaymentsEndpoint(s),
+		eps.LoadPaymentsEndpoint,
 		decodeLoadPaymentsRequest,
 		errs.EncodeResponse,
 		opts...,
 	)
 
 	loadAllPaymentsHandler := kithttp.NewServer(
-		makeLoadAllPaymentsEndpoint(s),
+		eps.LoadAllPaymentsEndpoint,
 		decodeLoadAllPaymentsRequest,
 		errs.EncodeResponse,
 		opts...,
 	)
 
+	approvePaymentHandler := kithttp.NewServer(
+		eps.ApprovePaymentEndpoint,
+		decodeApprovalRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	rejectPaymentHandler := kithttp.NewServer(
+		eps.RejectPaymentEndpoint,
+		decodeApprovalRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	loadApprovalsHandler := kithttp.NewServer(
+		eps.LoadApprovalsEndpoint,
+		decodeLoadApprovalsRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	loadReviewsHandler := kithttp.NewServer(
+		eps.LoadApprovalsEndpoint,
+		decodeLoadReviewsRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	reversePaymentHandler := kithttp.NewServer(
+		eps.ReversePaymentEndpoint,
+		decodeReversePaymentRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
 	router := mux.NewRouter()
 
 	router.Handle("/api/payments/v1/payments/rates", ratesPaymentHandler).Methods("POST")
 	router.Handle("/api/payments/v1/payments", newPaymentHandler).Methods("POST")
+	router.Handle("/api/payments/v1/payments/split", splitPaymentHandler).Methods("POST")
 	router.Handle("/api/payments/v1/payments/deposit", newDepositHandler).Methods("POST")
 	router.Handle("/api/payments/v1/payments", loadAllPaymentsHandler).Methods("GET")
 	router.Handle("/api/payments/v1/payments/{id}", loadPaymentsHandler).Methods("GET")
+	router.Handle("/api/payments/v1/payments/{id}/approve", approvePaymentHandler).Methods("POST")
+	router.Handle("/api/payments/v1/payments/{id}/reject", rejectPaymentHandler).Methods("POST")
+	router.Handle("/api/payments/v1/payments/{id}/reverse", reversePaymentHandler).Methods("POST")
+	router.Handle("/api/payments/v1/approvals", loadApprovalsHandler).Methods("GET")
+	router.Handle("/api/payments/v1/reviews", loadReviewsHandler).Methods("GET")
+
+	return router
+}
+
+// MakeStatementHandler returns a handler for account statements, which are served with the
+// accounts API, passing every other request to next.
+func MakeStatementHandler(eps Endpoints, logger kitlog.Logger, next http.Handler) http.Handler {
+	statementHandler := kithttp.NewServer(
+		eps.StatementEndpoint,
+		decodeStatementRequest,
+		encodeStatementResponse,
+		serverOptions(logger)...,
+	)
+
+	router := mux.NewRouter()
+	router.Handle("/api/accounts/v1/accounts/{id}/statement", statementHandler).Methods("GET")
+	router.NotFoundHandler = next
 
 	return router
 }
 
+func serverOptions(logger kitlog.Logger) []kithttp.ServerOption {
+	return []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(errs.EncodeError),
+		kithttp.ServerBefore(tracing.HTTPToContext, auth.HTTPToContext),
+		kithttp.ServerFinalizer(tracing.HTTPFinalizer),
+	}
+}
+
+// Operations describe the routes of MakeHandler and MakeStatementHandler for the OpenAPI specification.
+func Operations() []openapi.Operation {
+	const tag = "payments"
+	status := openapi.Query("status", "Approval status, pending_approval by default")
+	period := []openapi.Parameter{
+		openapi.Query("from", "Start of the period, RFC 3339 or YYYY-MM-DD; the account opening by default"),
+		openapi.Query("to", "End of the period, exclusive, RFC 3339 or YYYY-MM-DD for the whole day; now by default"),
+		openapi.Query("format", "json, csv or pdf; by the Accept header by default"),
+	}
+	return []openapi.Operation{
+		{Method: "POST", Path: "/api/payments/v1/payments/rates", Tag: tag, Summary: "Get the exchange rate of a currency on a date", Request: RatesCurrencyRequest{}, Response: Rate{}},
+		{Method: "POST", Path: "/api/payments/v1/payments", Tag: tag, Summary: "Make a payment", Request: newPaymentRequest{}, Response: receiptResponse{}},
+		{Method: "POST", Path: "/api/payments/v1/payments/split", Tag: tag, Summary: "Split a payment", Request: splitPaymentRequest{}, Response: receiptResponse{}},
+		{Method: "POST", Path: "/api/payments/v1/payments/deposit", Tag: tag, Summary: "Make a deposit", Request: newDepositRequest{}, Response: receiptResponse{}},
+		{Method: "GET", Path: "/api/payments/v1/payments", Tag: tag, Summary: "List payments", Response: []*Payment{}},
+		{Method: "GET", Path: "/api/payments/v1/payments/{id}", Tag: tag, Summary: "List payments of an account", Response: []*Payment{}},
+		{Method: "POST", Path: "/api/payments/v1/payments/{id}/approve", Tag: tag, Summary: "Approve a held payment"},
+		{Method: "POST", Path: "/api/payments/v1/payments/{id}/reject", Tag: tag, Summary: "Reject a held payment", Request: approvalRequest{}},
+		{Method: "POST", Path: "/api/payments/v1/payments/{id}/reverse", Tag: tag, Summary: "Reverse an executed payment", Response: receiptResponse{}},
+		{Method: "GET", Path: "/api/payments/v1/approvals", Tag: tag, Summary: "List payment approvals", Query: []openapi.Parameter{status}, Response: []*Approval{}},
+		{Method: "GET", Path: "/api/payments/v1/reviews", Tag: tag, Summary: "List payments held for review", Response: []*Approval{}},
+		{Method: "GET", Path: "/api/accounts/v1/accounts/{id}/statement", Tag: "accounts", Summary: "Get the statement of an account", Query: period, Response: Statement{}, Formats: []string{csvType, pdfType}},
+	}
+}
+
 func decodeNewPaymentRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	var body newPaymentRequest
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+	if err := validate.DecodeJSON(r, &body); err != nil {
 		return nil, err
 	}
-	if _, err := govalidator.ValidateStruct(body); err != nil {
-		return nil, errs.ValidationError{Err: err}
+	return body, nil
+}
+
+func decodeSplitPaymentRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var body splitPaymentRequest
+	if err := validate.DecodeJSON(r, &body); err != nil {
+		return nil, err
 	}
 	return body, nil
 }
 
 func decodeDepositRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	var body newDepositRequest
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+	if err := validate.DecodeJSON(r, &body); err != nil {
 		return nil, err
 	}
-	if _, err := govalidator.ValidateStruct(body); err != nil {
-		return nil, errs.ValidationError{Err: err}
-	}
 	return body, nil
 }
 
 func decodeRatesPaymentRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	var body RatesCurrencyRequest
-	fmt.Println(r)
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+	if err := validate.DecodeJSON(r, &body); err != nil {
 		return nil, err
 	}
-	if _, err := govalidator.ValidateStruct(body); err != nil {
-		return nil, errs.ValidationError{Err: err}
-	}
-	fmt.Println(body.Currency)
 	return body, nil
 }
 
@@ -112,6 +211,117 @@ func decodeLoadPaymentsRequest(_ context.Context, r *http.Request) (interface{},
 	return loadPaymentsRequest{AccountID: account.ID(id)}, nil
 }
 
+// Statement formats, as asked by the format parameter or the Accept header.
+const (
+	jsonFormat = "json"
+	csvFormat  = "csv"
+	pdfFormat  = "pdf"
+
+	csvType = "text/csv"
+	pdfType = "application/pdf"
+)
+
+func decodeStatementRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	q := r.URL.Query()
+	req := statementRequest{AccountID: account.ID(id), Format: q.Get("format")}
+	var err error
+	if req.From, err = ParseBound(q.Get("from"), false); err != nil {
+		return nil, err
+	}
+	if req.To, err = ParseBound(q.Get("to"), true); err != nil {
+		return nil, err
+	}
+	switch accept := r.Header.Get("Accept"); {
+	case req.Format == jsonFormat, req.Format == csvFormat, req.Format == pdfFormat:
+	case req.Format != "":
+		return nil, errs.ErrInvalidArgument
+	case strings.Contains(accept, csvType):
+		req.Format = csvFormat
+	case strings.Contains(accept, pdfType):
+		req.Format = pdfFormat
+	default:
+		req.Format = jsonFormat
+	}
+	return req, nil
+}
+
+// encodeStatementResponse writes the statement as JSON, or as a CSV or PDF attachment.
+func encodeStatementResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp := response.(statementResponse)
+	filename := "statement-" + string(resp.Account)
+	switch resp.Format {
+	case csvFormat:
+		w.Header().Set("Content-Type", csvType+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		return resp.WriteCSV(w)
+	case pdfFormat:
+		// The document is made in full first, so that a failure is still reported as one.
+		var buf bytes.Buffer
+		if err := resp.WritePDF(&buf); err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", pdfType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.pdf"`)
+		_, err := buf.WriteTo(w)
+		return err
+	}
+	return errs.EncodeResponse(ctx, w, resp.Statement)
+}
+
 func decodeLoadAllPaymentsRequest(_ context.Context, _ *http.Request) (interface{}, error) {
 	return nil, nil
 }
+
+func decodeApprovalRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errs.ErrInvalidArgument
+	}
+	// The body with a reason is optional.
+	var body approvalRequest
+	if err := validate.DecodeJSON(r, &body); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	body.ID = uid
+	return body, nil
+}
+
+func decodeReversePaymentRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errs.ErrBadRoute
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errs.ErrInvalidArgument
+	}
+	return reversePaymentRequest{ID: uid}, nil
+}
+
+func decodeLoadApprovalsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	status := Status(r.URL.Query().Get("status"))
+	switch status {
+	case "":
+		status = PendingApproval
+	case PendingApproval, PendingReview, Executed, Rejected, Expired, Failed:
+	default:
+		return nil, errs.ErrInvalidArgument
+	}
+	return loadApprovalsRequest{Status: status}, nil
+}
+
+// decodeLoadReviewsRequest serves the review queue: payments held by screening.
+func decodeLoadReviewsRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return loadApprovalsRequest{Status: PendingReview}, nil
+}
@@ -0,0 +1,335 @@
+package payment
+
+//go:generate protoc --go_out=plugins=grpc:. pb/payment.proto
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/payment/pb"
+	"github.com/ilyareist/task1/validate"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+)
+
+type grpcServer struct {
+	newPayment kitgrpc.Handler
+	split      kitgrpc.Handler
+	deposit    kitgrpc.Handler
+	rates      kitgrpc.Handler
+	load       kitgrpc.Handler
+	loadAll    kitgrpc.Handler
+	approve    kitgrpc.Handler
+	reject     kitgrpc.Handler
+	approvals  kitgrpc.Handler
+}
+
+// NewGRPCServer makes the payment service endpoints available as a gRPC PaymentServiceServer.
+func NewGRPCServer(eps Endpoints, logger kitlog.Logger) pb.PaymentServiceServer {
+	opts := []kitgrpc.ServerOption{
+		kitgrpc.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kitgrpc.ServerBefore(auth.GRPCToContext),
+	}
+
+	return &grpcServer{
+		newPayment: kitgrpc.NewServer(
+			eps.NewPaymentEndpoint,
+			decodeGRPCNewPaymentRequest,
+			encodeGRPCReceiptResponse,
+			opts...,
+		),
+		split: kitgrpc.NewServer(
+			eps.SplitPaymentEndpoint,
+			decodeGRPCSplitPaymentRequest,
+			encodeGRPCReceiptResponse,
+			opts...,
+		),
+		deposit: kitgrpc.NewServer(
+			eps.DepositEndpoint,
+			decodeGRPCDepositRequest,
+			encodeGRPCReceiptResponse,
+			opts...,
+		),
+		rates: kitgrpc.NewServer(
+			eps.RatesCurrencyEndpoint,
+			decodeGRPCRatesRequest,
+			encodeGRPCRateResponse,
+			opts...,
+		),
+		load: kitgrpc.NewServer(
+			eps.LoadPaymentsEndpoint,
+			decodeGRPCLoadPaymentsRequest,
+			encodeGRPCPaymentsResponse,
+			opts...,
+		),
+		loadAll: kitgrpc.NewServer(
+			eps.LoadAllPaymentsEndpoint,
+			decodeGRPCEmptyRequest,
+			encodeGRPCPaymentsResponse,
+			opts...,
+		),
+		approve: kitgrpc.NewServer(
+			eps.ApprovePaymentEndpoint,
+			decodeGRPCApprovalRequest,
+			encodeGRPCErrorOnlyResponse,
+			opts...,
+		),
+		reject: kitgrpc.NewServer(
+			eps.RejectPaymentEndpoint,
+			decodeGRPCApprovalRequest,
+			encodeGRPCErrorOnlyResponse,
+			opts...,
+		),
+		approvals: kitgrpc.NewServer(
+			eps.LoadApprovalsEndpoint,
+			decodeGRPCApprovalsRequest,
+			encodeGRPCApprovalsResponse,
+			opts...,
+		),
+	}
+}
+
+func (s *grpcServer) New(ctx context.Context, req *pb.NewPaymentRequest) (*pb.PaymentReply, error) {
+	_, rep, err := s.newPayment.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.PaymentReply), nil
+}
+
+func (s *grpcServer) Split(ctx context.Context, req *pb.SplitPaymentRequest) (*pb.PaymentReply, error) {
+	_, rep, err := s.split.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.PaymentReply), nil
+}
+
+func (s *grpcServer) Deposit(ctx context.Context, req *pb.DepositRequest) (*pb.PaymentReply, error) {
+	_, rep, err := s.deposit.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.PaymentReply), nil
+}
+
+func (s *grpcServer) Rates(ctx context.Context, req *pb.RatesRequest) (*pb.Rate, error) {
+	_, rep, err := s.rates.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.Rate), nil
+}
+
+func (s *grpcServer) Load(ctx context.Context, req *pb.LoadPaymentsRequest) (*pb.PaymentsReply, error) {
+	_, rep, err := s.load.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.PaymentsReply), nil
+}
+
+func (s *grpcServer) LoadAll(ctx context.Context, req *pb.Empty) (*pb.PaymentsReply, error) {
+	_, rep, err := s.loadAll.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.PaymentsReply), nil
+}
+
+func (s *grpcServer) Approve(ctx context.Context, req *pb.ApprovalRequest) (*pb.Empty, error) {
+	_, rep, err := s.approve.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.Empty), nil
+}
+
+func (s *grpcServer) Reject(ctx context.Context, req *pb.ApprovalRequest) (*pb.Empty, error) {
+	_, rep, err := s.reject.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.Empty), nil
+}
+
+func (s *grpcServer) Approvals(ctx context.Context, req *pb.ApprovalsRequest) (*pb.ApprovalsReply, error) {
+	_, rep, err := s.approvals.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, errs.GRPCError(err)
+	}
+	return rep.(*pb.ApprovalsReply), nil
+}
+
+func decodeGRPCNewPaymentRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.NewPaymentRequest)
+	var fields validate.Fields
+	body := newPaymentRequest{
+		FromAccountID: account.ID(req.From),
+		Amount:        fields.Decimal(req.Amount, "amount"),
+		ToAccountID:   account.ID(req.To),
+	}
+	if err := fields.Struct(body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func decodeGRPCSplitPaymentRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SplitPaymentRequest)
+	var fields validate.Fields
+	body := splitPaymentRequest{
+		FromAccountID: account.ID(req.From),
+		Amount:        fields.Decimal(req.Amount, "amount"),
+		Legs:          make([]splitLeg, 0, len(req.Legs)),
+	}
+	for i, l := range req.Legs {
+		leg := strconv.Itoa(i)
+		body.Legs = append(body.Legs, splitLeg{
+			To:      account.ID(l.To),
+			Amount:  fields.Decimal(l.Amount, "legs", leg, "amount"),
+			Percent: fields.Decimal(l.Percent, "legs", leg, "percent"),
+		})
+	}
+	if err := fields.Struct(body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func decodeGRPCDepositRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DepositRequest)
+	var fields validate.Fields
+	body := newDepositRequest{
+		AccountID: account.ID(req.Account),
+		Amount:    fields.Decimal(req.Amount, "amount"),
+	}
+	if err := fields.Struct(body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func decodeGRPCRatesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RatesRequest)
+	return RatesCurrencyRequest{Currency: req.Currency, Date: req.Date}, nil
+}
+
+func decodeGRPCLoadPaymentsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.LoadPaymentsRequest)
+	if req.Account == "" {
+		return nil, errs.ErrInvalidArgument
+	}
+	return loadPaymentsRequest{AccountID: account.ID(req.Account)}, nil
+}
+
+func decodeGRPCApprovalRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ApprovalRequest)
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, errs.ErrInvalidArgument
+	}
+	body := approvalRequest{ID: id, Reason: req.Reason}
+	if err := validate.Struct(body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func decodeGRPCApprovalsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ApprovalsRequest)
+	status := Status(req.Status)
+	switch status {
+	case "":
+		status = PendingApproval
+	case PendingApproval, PendingReview, Executed, Rejected, Expired, Failed:
+	default:
+		return nil, errs.ErrInvalidArgument
+	}
+	return loadApprovalsRequest{Status: status}, nil
+}
+
+func decodeGRPCEmptyRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func encodeGRPCErrorOnlyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	if err := response.(errorOnlyResponse).Err; err != nil {
+		return nil, err
+	}
+	return &pb.Empty{}, nil
+}
+
+func encodeGRPCReceiptResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(receiptResponse)
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+	return &pb.PaymentReply{
+		Id:       resp.ID.String(),
+		Status:   string(resp.Status),
+		Amount:   resp.Amount.String(),
+		Currency: string(resp.Currency),
+	}, nil
+}
+
+func encodeGRPCRateResponse(_ context.Context, response interface{}) (interface{}, error) {
+	rate := response.(Rate)
+	return &pb.Rate{Currency: rate.Currency, Date: rate.Date, Rate: rate.Rate}, nil
+}
+
+func encodeGRPCPaymentsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	payments := response.([]*Payment)
+	reply := &pb.PaymentsReply{Payments: make([]*pb.Payment, 0, len(payments))}
+	for _, p := range payments {
+		reply.Payments = append(reply.Payments, &pb.Payment{
+			Group:       p.Group.String(),
+			Account:     string(p.Account),
+			Amount:      p.Amount.String(),
+			ToAccount:   string(p.ToAccount),
+			FromAccount: string(p.FromAccount),
+			Direction:   string(p.Direction),
+		})
+	}
+	return reply, nil
+}
+
+func encodeGRPCApprovalsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	approvals := response.([]*Approval)
+	reply := &pb.ApprovalsReply{Approvals: make([]*pb.Approval, 0, len(approvals))}
+	for _, a := range approvals {
+		legs := make([]*pb.SplitLeg, 0, len(a.Legs))
+		for _, l := range a.Legs {
+			legs = append(legs, &pb.SplitLeg{To: string(l.To), Amount: l.Amount.String(), Percent: l.Percent.String()})
+		}
+		findings := make([]*pb.Finding, 0, len(a.Findings))
+		for _, f := range a.Findings {
+			findings = append(findings, &pb.Finding{Rule: f.Rule, Decision: string(f.Decision), Reason: f.Reason})
+		}
+		reply.Approvals = append(reply.Approvals, &pb.Approval{
+			Id:        a.ID.String(),
+			From:      string(a.From),
+			To:        string(a.To),
+			Legs:      legs,
+			Amount:    a.Amount.String(),
+			Debit:     a.Debit.String(),
+			Currency:  string(a.Currency),
+			Status:    string(a.Status),
+			Maker:     a.Maker,
+			Checker:   a.Checker,
+			Reason:    a.Reason,
+			CreatedAt: a.CreatedAt.Format(time.RFC3339),
+			ExpiresAt: a.ExpiresAt.Format(time.RFC3339),
+			Kind:      string(a.Kind),
+			Findings:  findings,
+		})
+	}
+	return reply, nil
+}
@@ -0,0 +1,99 @@
+package payment
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/payment/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialGRPC serves s over an in-memory gRPC connection, to calls made on behalf of an operator,
+// and returns a client of it.
+func dialGRPC(t *testing.T, s Service) (pb.PaymentServiceClient, func()) {
+	operator := func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			return next(auth.NewContext(ctx, auth.Principal{ID: "operator", Roles: []auth.Role{auth.RoleOperator}}), request)
+		}
+	}
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterPaymentServiceServer(srv, NewGRPCServer(MakeEndpoints(s, operator), log.NewNopLogger()))
+	go func() { _ = srv.Serve(ln) }()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return ln.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pb.NewPaymentServiceClient(conn), func() {
+		conn.Close()
+		srv.Stop()
+	}
+}
+
+// reason returns the gRPC code of err and the errs code it carries.
+func reason(err error) (codes.Code, string) {
+	st := status.Convert(err)
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return st.Code(), info.Reason
+		}
+	}
+	return st.Code(), ""
+}
+
+func TestGRPCRoundTrip(t *testing.T) {
+	repo := &payments{approvals: map[uuid.UUID]*Approval{}}
+	client, done := dialGRPC(t, newTestService(repo))
+	defer done()
+
+	got, err := client.New(context.Background(), &pb.NewPaymentRequest{From: "alice", Amount: "100.25", To: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uuid.Parse(got.Id); err != nil || got.Status != string(Executed) || got.Amount != "100.25" || got.Currency != "USD" {
+		t.Errorf("got %v", got)
+	}
+	if len(repo.stored) != 2 || repo.stored[0].Group.String() != got.Id {
+		t.Errorf("stored %+v", repo.stored)
+	}
+}
+
+func TestGRPCErrors(t *testing.T) {
+	repo := &payments{approvals: map[uuid.UUID]*Approval{}}
+	client, done := dialGRPC(t, newTestService(repo))
+	defer done()
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		req    *pb.NewPaymentRequest
+		code   codes.Code
+		reason errs.Code
+	}{
+		{"overdrawn", &pb.NewPaymentRequest{From: "alice", Amount: "1001", To: "bob"}, codes.FailedPrecondition, errs.CodeInsufficientMoney},
+		{"unknown target account", &pb.NewPaymentRequest{From: "alice", Amount: "1", To: "carol"}, codes.NotFound, errs.CodeUnknownTargetAccount},
+		{"invalid amount", &pb.NewPaymentRequest{From: "alice", Amount: "ten", To: "bob"}, codes.InvalidArgument, errs.CodeValidation},
+	}
+	for _, tt := range tests {
+		_, err := client.New(ctx, tt.req)
+		if code, r := reason(err); code != tt.code || r != string(tt.reason) {
+			t.Errorf("%s: got %v, %q, want %v, %q", tt.name, code, r, tt.code, tt.reason)
+		}
+	}
+	if len(repo.stored) != 0 {
+		t.Errorf("stored %+v", repo.stored)
+	}
+}
@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics"
+	"github.com/ilyareist/task1/errs"
+)
+
+// Class tells requests charged to different buckets apart.
+type Class string
+
+// Classes of requests.
+const (
+	Read  Class = "read"
+	Money Class = "money"
+)
+
+// Limits are the rules of a class of requests for every API key and for every client IP.
+type Limits struct {
+	Key Rule
+	IP  Rule
+}
+
+// Policy is what limits apply to which requests.
+type Policy struct {
+	Read  Limits
+	Money Limits
+	// Classify tells money-moving requests from reads; without it every request is a read.
+	Classify func(r *http.Request) Class
+	// TrustProxy takes the client IP from the X-Forwarded-For header set by the proxy
+	// in front of the service rather than from the connection.
+	TrustProxy bool
+	// Client identifies the caller of a request by credentials it checked, and is empty when
+	// they are missing or invalid; without it only IP buckets are charged.
+	Client func(r *http.Request) string
+}
+
+func (p Policy) limits(c Class) Limits {
+	if c == Money {
+		return p.Money
+	}
+	return p.Read
+}
+
+// charge is a bucket a request takes a token from, under its rule.
+type charge struct {
+	key  string
+	rule Rule
+}
+
+// NewMiddleware returns an HTTP middleware which charges every request to the bucket of its
+// client IP and, once the IP has a token left and the credentials of the request are checked,
+// to the bucket of its API key, token or certificate, answering 429 with Retry-After when either
+// is empty. A token taken from the bucket of the IP of a request its key rejects is given back.
+// Requests with missing or invalid credentials are charged to their IP only, so that nobody but
+// the holder of a key spends its budget. Requests are let through when the limiter fails, which
+// is logged.
+func NewMiddleware(limiter Limiter, policy Policy, rejected metrics.Counter, logger log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			class := Read
+			if policy.Classify != nil {
+				class = policy.Classify(r)
+			}
+			limits := policy.limits(class)
+
+			// take charges b, telling whether the request may go on and when it may not,
+			// how long until it might.
+			take := func(b charge) (bool, time.Duration) {
+				if !b.rule.Enabled() {
+					return false, 0
+				}
+				wait, err := limiter.Take(r.Context(), string(class)+":"+b.key, b.rule)
+				if err != nil {
+					_ = logger.Log("class", class, "bucket", b.key, "err", err)
+					return false, 0
+				}
+				return true, wait
+			}
+			reject := func(wait time.Duration) {
+				rejected.With("class", string(class)).Add(1)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				errs.EncodeError(r.Context(), errs.ErrRateLimited, w)
+			}
+
+			ip := charge{"ip:" + clientIP(r, policy.TrustProxy), limits.IP}
+			tookIP, wait := take(ip)
+			if wait > 0 {
+				reject(wait)
+				return
+			}
+			if policy.Client == nil || !limits.Key.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if client := policy.Client(r); client != "" {
+				if _, wait := take(charge{client, limits.Key}); wait > 0 {
+					if tookIP {
+						if err := limiter.Refund(r.Context(), string(class)+":"+ip.key, ip.rule); err != nil {
+							_ = logger.Log("class", class, "bucket", ip.key, "err", err)
+						}
+					}
+					reject(wait)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the address of the client, which is the last one in X-Forwarded-For
+// when the proxy appending it is trusted.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			hops := strings.Split(fwd, ",")
+			if ip := net.ParseIP(strings.TrimSpace(hops[len(hops)-1])); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
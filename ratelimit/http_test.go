@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/discard"
+)
+
+func TestMiddlewareRefunds(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := &memoryLimiter{buckets: make(map[string]*memoryBucket), now: func() time.Time { return now }}
+	// Only the token "abc" is valid.
+	client := func(r *http.Request) string {
+		if r.Header.Get("Authorization") == "Bearer abc" {
+			return "token:abc"
+		}
+		return ""
+	}
+	policy := Policy{Read: Limits{Key: Rule{Rate: 0.001, Burst: 1}, IP: Rule{Rate: 0.001, Burst: 3}}, Client: client}
+	h := NewMiddleware(limiter, policy, discard.NewCounter(), log.NewNopLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/accounts/v1/accounts", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	// The token is good for one request, the IP for three.
+	steps := []struct {
+		token string
+		want  int
+	}{
+		{"abc", http.StatusOK},
+		{"abc", http.StatusTooManyRequests},
+		{"abc", http.StatusTooManyRequests},
+		// Requests rejected by the bucket of the token took nothing from the bucket of the IP.
+		{"", http.StatusOK},
+		// Invalid tokens are charged to the IP only.
+		{"forged", http.StatusOK},
+		{"", http.StatusTooManyRequests},
+	}
+	for i, s := range steps {
+		w := serve(s.token)
+		if w.Code != s.want {
+			t.Fatalf("request %d: got status %d, want %d", i, w.Code, s.want)
+		}
+		if s.want == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("request %d: no Retry-After", i)
+		}
+	}
+}
+
+func TestMiddlewareChecksCredentials(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := &memoryLimiter{buckets: make(map[string]*memoryBucket), now: func() time.Time { return now }}
+	policy := Policy{
+		Read:   Limits{Key: Rule{Rate: 0.001, Burst: 1}},
+		Client: func(r *http.Request) string { return "" },
+	}
+	h := NewMiddleware(limiter, policy, discard.NewCounter(), log.NewNopLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Requests forging the id of a key do not spend its budget.
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest("GET", "/api/accounts/v1/accounts", nil)
+		r.Header.Set("X-API-Key", "victim.forged")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %d", i, w.Code)
+		}
+	}
+	if len(limiter.buckets) != 0 {
+		t.Errorf("charged %d buckets", len(limiter.buckets))
+	}
+}
+
+func TestRuleUnmarshalText(t *testing.T) {
+	tests := []struct {
+		text string
+		want Rule
+		err  bool
+	}{
+		{"10/s", Rule{Rate: 10, Burst: 10}, false},
+		{"600/1m", Rule{Rate: 10, Burst: 600}, false},
+		{"5/s:20", Rule{Rate: 5, Burst: 20}, false},
+		{"off", Rule{}, false},
+		// Buckets of less than a token would reject every request.
+		{"0.5/s", Rule{Rate: 0.5, Burst: 1}, false},
+		{"1/2s", Rule{Rate: 0.5, Burst: 1}, false},
+		{"1/s:0.5", Rule{}, true},
+		{"0/s", Rule{}, true},
+		{"1/0s", Rule{}, true},
+		{"10", Rule{}, true},
+	}
+	for _, tt := range tests {
+		var r Rule
+		err := r.UnmarshalText([]byte(tt.text))
+		if (err != nil) != tt.err || r != tt.want {
+			t.Errorf("%q: got %+v, %v", tt.text, r, err)
+		}
+	}
+}
+
+func TestBucket(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := Rule{Rate: 1, Burst: 2}
+	var b Bucket
+	for i := 0; i < 2; i++ {
+		if wait := b.Take(now, r); wait != 0 {
+			t.Fatalf("take %d: wait %v", i, wait)
+		}
+	}
+	if wait := b.Take(now, r); wait != time.Second {
+		t.Errorf("empty bucket: wait %v, want 1s", wait)
+	}
+	b.Refund(r)
+	b.Refund(r)
+	b.Refund(r)
+	if b.Tokens != r.Burst {
+		t.Errorf("refunded to %v tokens, want burst %v", b.Tokens, r.Burst)
+	}
+	if wait := b.Take(now.Add(time.Minute), r); wait != 0 || b.Tokens != r.Burst-1 {
+		t.Errorf("refilled bucket: wait %v, %v tokens", wait, b.Tokens)
+	}
+}
@@ -0,0 +1,168 @@
+// Package ratelimit throttles clients of the service with token buckets, kept in memory of
+// one instance or shared by all of them in the database.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rule is a token bucket: requests take a token each, Rate tokens are added every second up to Burst.
+// The zero Rule is no limit at all.
+type Rule struct {
+	Rate  float64
+	Burst float64
+}
+
+// Enabled reports whether the rule limits anything.
+func (r Rule) Enabled() bool { return r.Rate > 0 }
+
+// String formats the rule the way UnmarshalText parses it.
+func (r Rule) String() string {
+	if !r.Enabled() {
+		return "off"
+	}
+	return strconv.FormatFloat(r.Rate, 'f', -1, 64) + "/s:" + strconv.FormatFloat(r.Burst, 'f', -1, 64)
+}
+
+// UnmarshalText parses a rule written as <tokens>/<period>[:<burst>], e.g. 10/s, 600/1m or 5/s:20.
+// The burst is the number of tokens, at least 1, unless given; "off" and the empty string disable the rule.
+func (r *Rule) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if s == "" || s == "off" {
+		*r = Rule{}
+		return nil
+	}
+	spec, burst := s, ""
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		spec, burst = s[:i], s[i+1:]
+	}
+	i := strings.IndexByte(spec, '/')
+	if i < 0 {
+		return fmt.Errorf("rate limit %q must be <tokens>/<period>[:<burst>]", s)
+	}
+	tokens, err := strconv.ParseFloat(spec[:i], 64)
+	if err != nil || tokens <= 0 {
+		return fmt.Errorf("rate limit %q must have a positive number of tokens", s)
+	}
+	period := spec[i+1:]
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("rate limit %q must have a positive period such as s, 1m or 10s", s)
+	}
+	rule := Rule{Rate: tokens / d.Seconds(), Burst: math.Max(1, tokens)}
+	if burst != "" {
+		if rule.Burst, err = strconv.ParseFloat(burst, 64); err != nil || rule.Burst < 1 {
+			return fmt.Errorf("rate limit %q must have a burst of at least 1", s)
+		}
+	}
+	*r = rule
+	return nil
+}
+
+// Bucket is the state of a token bucket, as shared limiters store it.
+type Bucket struct {
+	TableName struct{}  `sql:"rate_limits"`
+	Key       string    `sql:"key,pk,type:varchar(255)"`
+	Tokens    float64   `sql:"tokens,notnull,type:float"`
+	UpdatedAt time.Time `sql:"updated_at,notnull"`
+}
+
+// Take refills the bucket for the time passed since its last update and takes a token from it.
+// When the bucket is short of one, nothing is taken and Take returns the time until it is not.
+// A bucket never updated is full.
+func (b *Bucket) Take(now time.Time, r Rule) time.Duration {
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = r.Burst
+	} else if elapsed := now.Sub(b.UpdatedAt); elapsed > 0 {
+		b.Tokens = math.Min(r.Burst, b.Tokens+elapsed.Seconds()*r.Rate)
+	}
+	if now.After(b.UpdatedAt) {
+		b.UpdatedAt = now
+	}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.Tokens) / r.Rate * float64(time.Second)))
+}
+
+// Refund gives a token taken from the bucket back, up to the burst.
+func (b *Bucket) Refund(r Rule) {
+	b.Tokens = math.Min(r.Burst, b.Tokens+1)
+}
+
+// Idle reports whether the bucket has refilled by now, so that forgetting it changes nothing.
+func (b *Bucket) Idle(now time.Time, r Rule) bool {
+	return b.Tokens+now.Sub(b.UpdatedAt).Seconds()*r.Rate >= r.Burst
+}
+
+// Limiter takes tokens from buckets identified by keys.
+type Limiter interface {
+	// Take takes a token from the bucket with the key, returning the time to wait
+	// for one when there is none, zero when the token is taken.
+	Take(ctx context.Context, key string, r Rule) (time.Duration, error)
+
+	// Refund gives a token taken from the bucket with the key back, as when another bucket
+	// the request is charged to turns it down.
+	Refund(ctx context.Context, key string, r Rule) error
+}
+
+// sweepInterval is how often limiters forget buckets which refilled.
+const sweepInterval = time.Minute
+
+type memoryLimiter struct {
+	mtx     sync.Mutex
+	buckets map[string]*memoryBucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+type memoryBucket struct {
+	Bucket
+	rule Rule
+}
+
+// NewMemoryLimiter returns a limiter keeping buckets in memory, so that every instance of the
+// service enforces limits of its own.
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{buckets: make(map[string]*memoryBucket), now: time.Now}
+}
+
+func (l *memoryLimiter) Take(_ context.Context, key string, r Rule) (time.Duration, error) {
+	now := l.now()
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if now.Sub(l.swept) >= sweepInterval {
+		for k, b := range l.buckets {
+			if b.Idle(now, b.rule) {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &memoryBucket{}
+		l.buckets[key] = b
+	}
+	b.rule = r
+	return b.Take(now, r), nil
+}
+
+func (l *memoryLimiter) Refund(_ context.Context, key string, r Rule) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if b, ok := l.buckets[key]; ok {
+		b.Refund(r)
+	}
+	return nil
+}
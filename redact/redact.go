@@ -0,0 +1,56 @@
+// Package redact keeps secrets and personal data out of logs.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	kitlog "github.com/go-kit/kit/log"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+// DefaultKeys are log keys whose values are redacted unless others are given.
+var DefaultKeys = []string{
+	"secret", "password", "token", "authorization", "api_key", "key",
+	"name", "email", "phone", "address", "date_of_birth",
+}
+
+type logger struct {
+	next kitlog.Logger
+	keys map[string]bool
+}
+
+// NewLogger returns a logger masking values logged under sensitive keys, compared case-insensitively.
+func NewLogger(next kitlog.Logger, keys ...string) kitlog.Logger {
+	if len(keys) == 0 {
+		keys = DefaultKeys
+	}
+	l := &logger{next: next, keys: make(map[string]bool, len(keys))}
+	for _, k := range keys {
+		l.keys[strings.ToLower(k)] = true
+	}
+	return l
+}
+
+// Log masks values of sensitive keys and passes the record on.
+func (l *logger) Log(keyvals ...interface{}) error {
+	redacted := make([]interface{}, len(keyvals))
+	copy(redacted, keyvals)
+	for i := 0; i+1 < len(redacted); i += 2 {
+		if l.keys[strings.ToLower(fmt.Sprint(redacted[i]))] && redacted[i+1] != nil {
+			redacted[i+1] = Mask
+		}
+	}
+	return l.next.Log(redacted...)
+}
+
+// literal matches SQL string literals, with quotes escaped by doubling.
+var literal = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// SQL masks string literals of a formatted query, which may hold any data.
+func SQL(query string) string {
+	return literal.ReplaceAllString(query, "'"+Mask+"'")
+}
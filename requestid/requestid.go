@@ -0,0 +1,70 @@
+// Package requestid threads ids of served requests through contexts, so that logs,
+// audit entries and responses belonging to one request can be matched.
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Header carries request ids over HTTP; gRPC uses its lower case form as metadata key.
+const Header = "X-Request-ID"
+
+// maxLength bounds ids accepted from callers.
+const maxLength = 128
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the request id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request id carried by ctx, empty when there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Handler tags every request with the id given by the caller in the header, or a generated one,
+// and returns it in the response header.
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := accept(r.Header.Get(Header))
+		w.Header().Set(Header, id)
+		h.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// UnaryServerInterceptor does for gRPC calls what Handler does for HTTP requests, with
+// the metadata, before handing the call over to next.
+func UnaryServerInterceptor(next grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if vs := md.Get(Header); len(vs) > 0 {
+				id = vs[0]
+			}
+		}
+		id = accept(id)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(Header, id))
+		return next(NewContext(ctx, id), req, info, handler)
+	}
+}
+
+// accept returns the id given by a caller when it is safe to log, a new one otherwise.
+func accept(id string) string {
+	if id == "" || len(id) > maxLength {
+		return uuid.New().String()
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == ':') {
+			return uuid.New().String()
+		}
+	}
+	return id
+}
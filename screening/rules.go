@@ -0,0 +1,184 @@
+package screening
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/customer"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+// Velocity sends to review payments from accounts which debited too often or too much within a window.
+type Velocity struct {
+	History History
+	Window  time.Duration
+	// MaxCount is the number of debits allowed within the window, unlimited when zero.
+	MaxCount int
+	// MaxAmount is the sum allowed to be debited within the window, the payment included; unlimited when zero.
+	MaxAmount decimal.Decimal
+}
+
+// Name identifies the rule in findings.
+func (r *Velocity) Name() string { return "velocity" }
+
+// Evaluate returns the decision of the rule on the payment.
+func (r *Velocity) Evaluate(_ context.Context, s *Subject) (Decision, string, error) {
+	if s.Kind == Deposit {
+		return Allow, "", nil
+	}
+	count, sum, err := r.History.DebitsSince(s.Account.ID, s.Time.Add(-r.Window))
+	if err != nil {
+		return Allow, "", err
+	}
+	if r.MaxCount > 0 && count >= r.MaxCount {
+		return Review, fmt.Sprintf("%d payments within %s", count+1, r.Window), nil
+	}
+	if total := sum.Add(s.Amount); r.MaxAmount.IsPositive() && total.GreaterThan(r.MaxAmount) {
+		return Review, fmt.Sprintf("%s %s debited within %s", total, s.Account.Currency, r.Window), nil
+	}
+	return Allow, "", nil
+}
+
+// UnusualAmount sends to review payments much larger than usual for the account.
+type UnusualAmount struct {
+	History History
+	// Factor is how many times the payment may exceed the average of recent ones.
+	Factor decimal.Decimal
+	// Samples is the number of recent payments averaged; accounts with fewer payments are not judged.
+	Samples int
+}
+
+// Name identifies the rule in findings.
+func (r *UnusualAmount) Name() string { return "unusual_amount" }
+
+// Evaluate returns the decision of the rule on the payment.
+func (r *UnusualAmount) Evaluate(_ context.Context, s *Subject) (Decision, string, error) {
+	if s.Kind == Deposit || r.Samples <= 0 {
+		return Allow, "", nil
+	}
+	amounts, err := r.History.LastDebits(s.Account.ID, r.Samples)
+	if err != nil || len(amounts) < r.Samples {
+		return Allow, "", err
+	}
+	sum := decimal.Zero
+	for _, a := range amounts {
+		sum = sum.Add(a)
+	}
+	avg := sum.Div(decimal.New(int64(len(amounts)), 0))
+	if s.Amount.GreaterThan(avg.Mul(r.Factor)) {
+		return Review, fmt.Sprintf("amount exceeds %s times the average of %s", r.Factor, avg.StringFixed(2)), nil
+	}
+	return Allow, "", nil
+}
+
+// NewCounterparty sends to review large first payments to an account never paid before.
+type NewCounterparty struct {
+	History History
+	// MinAmount is the amount from which first payments are reviewed.
+	MinAmount decimal.Decimal
+}
+
+// Name identifies the rule in findings.
+func (r *NewCounterparty) Name() string { return "new_counterparty" }
+
+// Evaluate returns the decision of the rule on the payment.
+func (r *NewCounterparty) Evaluate(_ context.Context, s *Subject) (Decision, string, error) {
+	if s.Amount.LessThan(r.MinAmount) {
+		return Allow, "", nil
+	}
+	for _, c := range s.Counterparties {
+		paid, err := r.History.HasPaid(s.Account.ID, c.ID)
+		if err != nil {
+			return Allow, "", err
+		}
+		if !paid {
+			return Review, fmt.Sprintf("first payment to %s", c.ID), nil
+		}
+	}
+	return Allow, "", nil
+}
+
+// CountryBlocklist blocks payments involving accounts in blocked countries.
+type CountryBlocklist struct {
+	Countries []account.Country
+}
+
+// Name identifies the rule in findings.
+func (r *CountryBlocklist) Name() string { return "country_blocklist" }
+
+// Evaluate returns the decision of the rule on the payment.
+func (r *CountryBlocklist) Evaluate(_ context.Context, s *Subject) (Decision, string, error) {
+	for _, a := range append([]*account.Account{s.Account}, s.Counterparties...) {
+		for _, c := range r.Countries {
+			if strings.EqualFold(string(a.Country), string(c)) {
+				return Block, fmt.Sprintf("account %s is in blocked country %s", a.ID, a.Country), nil
+			}
+		}
+	}
+	return Allow, "", nil
+}
+
+// Sanctions blocks payments involving listed account ids or customer names.
+type Sanctions struct {
+	Customers customer.Repository
+	entries   map[string]bool
+}
+
+// LoadSanctions reads a sanctions list from a file with one account id or person name per line.
+// Blank lines and lines starting with # are skipped; matching ignores case and extra spaces.
+func LoadSanctions(path string, customers customer.Repository) (*Sanctions, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := &Sanctions{Customers: customers, entries: make(map[string]bool)}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r.entries[normalize(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Name identifies the rule in findings.
+func (r *Sanctions) Name() string { return "sanctions" }
+
+// Evaluate returns the decision of the rule on the payment.
+func (r *Sanctions) Evaluate(_ context.Context, s *Subject) (Decision, string, error) {
+	for _, a := range append([]*account.Account{s.Account}, s.Counterparties...) {
+		if r.entries[normalize(string(a.ID))] {
+			return Block, fmt.Sprintf("account %s is sanctioned", a.ID), nil
+		}
+		c, err := r.Customers.Find(a.Customer)
+		if errors.Is(err, errs.ErrUnknownCustomer) {
+			// Legacy accounts without customer are matched by id only.
+			continue
+		}
+		if err != nil {
+			return Allow, "", err
+		}
+		if r.entries[normalize(c.Name)] {
+			return Block, fmt.Sprintf("holder of account %s is sanctioned", a.ID), nil
+		}
+	}
+	return Allow, "", nil
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
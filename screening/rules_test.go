@@ -0,0 +1,71 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/customer"
+	"github.com/ilyareist/task1/errs"
+)
+
+// customers is a customer.Repository finding customers in memory, failing with wrapped errors.
+type customers struct {
+	customer.Repository
+	found map[uuid.UUID]*customer.Customer
+}
+
+func (r customers) Find(id uuid.UUID) (*customer.Customer, error) {
+	c, ok := r.found[id]
+	if !ok {
+		return nil, fmt.Errorf("find customer %s: %w", id, errs.ErrUnknownCustomer)
+	}
+	return c, nil
+}
+
+func TestSanctions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sanctions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sanctions.txt")
+	list := "# sanctioned\nEvil Corp\n\n  john   DOE \nbadacc\n"
+	if err := ioutil.WriteFile(path, []byte(list), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	john := &customer.Customer{ID: uuid.New(), Name: "John Doe"}
+	jane := &customer.Customer{ID: uuid.New(), Name: "Jane Roe"}
+	r, err := LoadSanctions(path, customers{found: map[uuid.UUID]*customer.Customer{john.ID: john, jane.ID: jane}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		from  *account.Account
+		to    *account.Account
+		block bool
+	}{
+		{"clean", &account.Account{ID: "a", Customer: jane.ID}, &account.Account{ID: "b", Customer: jane.ID}, false},
+		{"sanctioned holder", &account.Account{ID: "a", Customer: jane.ID}, &account.Account{ID: "b", Customer: john.ID}, true},
+		{"sanctioned account", &account.Account{ID: "BADACC", Customer: jane.ID}, &account.Account{ID: "b", Customer: jane.ID}, true},
+		{"legacy account without customer", &account.Account{ID: "a"}, &account.Account{ID: "b", Customer: jane.ID}, false},
+	}
+	for _, tt := range tests {
+		decision, _, err := r.Evaluate(context.Background(), &Subject{Account: tt.from, Counterparties: []*account.Account{tt.to}})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if (decision == Block) != tt.block {
+			t.Errorf("%s: got %v", tt.name, decision)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package screening provides rule-based fraud and AML screening of payments before they are stored.
+package screening
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/account"
+	"github.com/shopspring/decimal"
+)
+
+// Decision on a screened payment. Decisions are ordered by severity.
+type Decision string
+
+const (
+	Allow  Decision = "allow"
+	Review Decision = "review"
+	Block  Decision = "block"
+)
+
+// severity orders decisions, so that the most severe one of all rules wins.
+var severity = map[Decision]int{Allow: 0, Review: 1, Block: 2}
+
+// Kind of a screened payment.
+type Kind string
+
+const (
+	Transfer Kind = "transfer"
+	Split    Kind = "split"
+	Deposit  Kind = "deposit"
+)
+
+// Subject is a payment to be screened. Account is debited, or credited for deposits;
+// Counterparties are credited by transfers and splits. Amount is in Account currency.
+type Subject struct {
+	Reference      uuid.UUID
+	Kind           Kind
+	Account        *account.Account
+	Counterparties []*account.Account
+	Amount         decimal.Decimal
+	Principal      string
+	Time           time.Time
+}
+
+// Finding is an outcome of one rule which did not allow a payment.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason"`
+}
+
+// Rule inspects payments. Rules are independent, a pipeline runs all of them.
+type Rule interface {
+	// Name identifies the rule in findings.
+	Name() string
+
+	// Evaluate returns the decision of the rule on the payment, and the reason when it is not Allow.
+	Evaluate(ctx context.Context, s *Subject) (Decision, string, error)
+}
+
+// Screening is a logged decision on a payment, with findings of all rules which did not allow it.
+type Screening struct {
+	ID             uuid.UUID        `json:"id" sql:"id,pk,type:varchar(36)"`
+	Reference      uuid.UUID        `json:"reference" sql:"reference,notnull,type:varchar(36)"`
+	Kind           Kind             `json:"kind" sql:"kind,notnull,type:varchar(16)"`
+	Account        account.ID       `json:"account" sql:"account,notnull,type:varchar(255)"`
+	Counterparties []account.ID     `json:"counterparties,omitempty" sql:"counterparties,array"`
+	Amount         decimal.Decimal  `json:"amount" sql:"amount,notnull,type:'decimal(16,4)'"`
+	Currency       account.Currency `json:"currency" sql:"currency,notnull,type:varchar(3)"`
+	Decision       Decision         `json:"decision" sql:"decision,notnull,type:varchar(16)"`
+	Findings       []Finding        `json:"findings,omitempty" sql:"findings"`
+	Principal      string           `json:"principal" sql:"principal,type:varchar(255)"`
+	CreatedAt      time.Time        `json:"created_at" sql:"created_at,notnull"`
+}
+
+// Screener decides whether payments may proceed.
+type Screener interface {
+	// Screen runs the payment through screening, logs and returns the decision.
+	Screen(ctx context.Context, s *Subject) (*Screening, error)
+}
+
+// Pipeline is a Screener running every rule on every payment. The most severe decision wins;
+// a rule failing to evaluate sends the payment to review rather than letting it through.
+type Pipeline struct {
+	rules []Rule
+	log   Repository
+}
+
+// NewPipeline returns a pipeline of rules, logging decisions to the repository.
+func NewPipeline(log Repository, rules ...Rule) *Pipeline {
+	return &Pipeline{rules: rules, log: log}
+}
+
+// Screen runs the payment through every rule, logs and returns the decision.
+func (p *Pipeline) Screen(ctx context.Context, s *Subject) (*Screening, error) {
+	if s.Time.IsZero() {
+		s.Time = time.Now().UTC()
+	}
+	result := &Screening{
+		ID:        uuid.New(),
+		Reference: s.Reference,
+		Kind:      s.Kind,
+		Account:   s.Account.ID,
+		Amount:    s.Amount,
+		Currency:  s.Account.Currency,
+		Decision:  Allow,
+		Principal: s.Principal,
+		CreatedAt: s.Time,
+	}
+	for _, c := range s.Counterparties {
+		result.Counterparties = append(result.Counterparties, c.ID)
+	}
+	for _, r := range p.rules {
+		d, reason, err := r.Evaluate(ctx, s)
+		if err != nil {
+			d, reason = Review, fmt.Sprintf("rule failed: %v", err)
+		}
+		if d == Allow {
+			continue
+		}
+		result.Findings = append(result.Findings, Finding{Rule: r.Name(), Decision: d, Reason: reason})
+		if severity[d] > severity[result.Decision] {
+			result.Decision = d
+		}
+	}
+	if err := p.log.Store(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Filter selects logged decisions. Zero fields match everything.
+type Filter struct {
+	Decision Decision
+	Account  account.ID
+	Since    time.Time
+	Limit    int
+}
+
+// Repository interface for the decision log.
+type Repository interface {
+	// Store logs a decision.
+	Store(s *Screening) error
+
+	// Find returns logged decisions matching the filter, newest first.
+	Find(f Filter) []*Screening
+}
+
+// History provides past payments of accounts to rules.
+type History interface {
+	// DebitsSince returns the number and the sum of payments debited from the account since the time.
+	DebitsSince(id account.ID, since time.Time) (int, decimal.Decimal, error)
+
+	// LastDebits returns amounts of up to n latest payments debited from the account.
+	LastDebits(id account.ID, n int) ([]decimal.Decimal, error)
+
+	// HasPaid reports whether the account has paid to the counterparty before.
+	HasPaid(from, to account.ID) (bool, error)
+}
@@ -0,0 +1,38 @@
+package screening
+
+import (
+	"context"
+
+	"github.com/ilyareist/task1/auth"
+)
+
+// maxDecisions caps the number of logged decisions returned at once.
+const maxDecisions = 1000
+
+// Service is the interface that provides access to the decision log.
+type Service interface {
+	// Decisions returns logged screening decisions matching the filter, newest first.
+	Decisions(ctx context.Context, f Filter) ([]*Screening, error)
+}
+
+type service struct {
+	log Repository
+}
+
+// Decisions returns logged screening decisions matching the filter, newest first.
+func (s *service) Decisions(ctx context.Context, f Filter) ([]*Screening, error) {
+	if _, err := auth.Require(ctx, auth.RoleAdmin, auth.RoleOperator, auth.RoleAuditor); err != nil {
+		return nil, err
+	}
+	if f.Limit <= 0 || f.Limit > maxDecisions {
+		f.Limit = maxDecisions
+	}
+	return s.log.Find(f), nil
+}
+
+// NewService creates a decision log service with necessary dependencies.
+func NewService(log Repository) Service {
+	return &service{
+		log: log,
+	}
+}
@@ -0,0 +1,85 @@
+package screening
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ilyareist/task1/account"
+	"github.com/ilyareist/task1/auth"
+	"github.com/ilyareist/task1/errs"
+	"github.com/ilyareist/task1/openapi"
+	"github.com/ilyareist/task1/tracing"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a handler for the decision log endpoints.
+func MakeHandler(eps Endpoints, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(errs.EncodeError),
+		kithttp.ServerBefore(tracing.HTTPToContext, auth.HTTPToContext),
+		kithttp.ServerFinalizer(tracing.HTTPFinalizer),
+	}
+
+	loadDecisionsHandler := kithttp.NewServer(
+		eps.LoadDecisionsEndpoint,
+		decodeLoadDecisionsRequest,
+		errs.EncodeResponse,
+		opts...,
+	)
+
+	router := mux.NewRouter()
+
+	router.Handle("/api/screening/v1/decisions", loadDecisionsHandler).Methods("GET")
+
+	return router
+}
+
+// Operations describe the routes of MakeHandler for the OpenAPI specification.
+func Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: "GET", Path: "/api/screening/v1/decisions", Tag: "screening", Summary: "List screening decisions",
+			Query: []openapi.Parameter{
+				openapi.Query("decision", "allow, review or block"),
+				openapi.Query("account", "Account screened payments debit or credit"),
+				openapi.Query("since", "RFC 3339 time of the earliest decision"),
+				openapi.Query("limit", "Maximum number of decisions"),
+			},
+			Response: []*Screening{},
+		},
+	}
+}
+
+func decodeLoadDecisionsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	f := Filter{
+		Decision: Decision(q.Get("decision")),
+		Account:  account.ID(q.Get("account")),
+	}
+	switch f.Decision {
+	case "", Allow, Review, Block:
+	default:
+		return nil, errs.ErrInvalidArgument
+	}
+	if since := q.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, errs.ErrInvalidArgument
+		}
+		f.Since = t
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return nil, errs.ErrInvalidArgument
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
@@ -0,0 +1,152 @@
+// Package tlsreload serves TLS with certificates read from files, which are read again when they
+// change, so that certificates may be renewed without restarting the service.
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Client authentication modes.
+const (
+	ClientOptional = "optional"
+	ClientRequire  = "require"
+)
+
+// Reloader holds the server certificate and the CAs client certificates are verified with,
+// as last read from their files.
+type Reloader struct {
+	certFile, keyFile, caFile string
+
+	mtx   sync.RWMutex
+	cert  *tls.Certificate
+	pool  *x509.CertPool
+	stamp string
+}
+
+// New reads the certificate and key files, and the file of CA certificates if it is named.
+func New(certFile, keyFile, caFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the files again if any changed since they were read, reporting whether they did.
+// Certificates in use are kept when the files fail to load, e.g. while they are being replaced.
+func (r *Reloader) Reload() (bool, error) {
+	stamp, err := r.files()
+	if err != nil {
+		return false, err
+	}
+	r.mtx.RLock()
+	same := stamp == r.stamp
+	r.mtx.RUnlock()
+	if same {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, err
+	}
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := ioutil.ReadFile(r.caFile)
+		if err != nil {
+			return false, err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("%s has no PEM encoded certificates", r.caFile)
+		}
+	}
+
+	r.mtx.Lock()
+	r.cert, r.pool, r.stamp = &cert, pool, stamp
+	r.mtx.Unlock()
+	return true, nil
+}
+
+// files returns the sizes and modification times of the files.
+func (r *Reloader) files() (string, error) {
+	var stamp strings.Builder
+	for _, name := range []string{r.certFile, r.keyFile, r.caFile} {
+		if name == "" {
+			continue
+		}
+		fi, err := os.Stat(name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&stamp, "%s:%d:%d;", name, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return stamp.String(), nil
+}
+
+// Run checks the files for changes every interval until ctx is cancelled.
+func (r *Reloader) Run(ctx context.Context, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		reloaded, err := r.Reload()
+		if err != nil {
+			_ = logger.Log("msg", "reload certificates", "error", err)
+			continue
+		}
+		if reloaded {
+			_ = logger.Log("msg", "certificates reloaded", "cert", r.certFile)
+		}
+	}
+}
+
+// Config returns the server configuration. When the Reloader has CAs, client certificates are
+// verified with them, and required in ClientRequire mode.
+func (r *Reloader) Config(clientAuth string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			r.mtx.RLock()
+			defer r.mtx.RUnlock()
+			return r.cert, nil
+		},
+	}
+	if r.caFile == "" {
+		return config, nil
+	}
+	switch clientAuth {
+	case ClientOptional:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientRequire:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, errors.New("client authentication must be optional or require, got " + clientAuth)
+	}
+	// Handshakes are configured anew, to verify clients with the CAs last loaded.
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := config.Clone()
+		c.GetConfigForClient = nil
+		r.mtx.RLock()
+		c.ClientCAs = r.pool
+		r.mtx.RUnlock()
+		return c, nil
+	}
+	return config, nil
+}
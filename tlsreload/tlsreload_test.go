@@ -0,0 +1,310 @@
+package tlsreload
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// issuer is a CA issuing certificates for tests.
+type issuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+var serial int64
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newCA(t *testing.T, name string) *issuer {
+	key := newKey(t)
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &issuer{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a leaf certificate for the server at localhost or for a client, in PEM.
+func (ca *issuer) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	key := newKey(t)
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name, Organization: []string{"Acme"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	if usage == x509.ExtKeyUsageServerAuth {
+		tmpl.DNSNames = []string{"localhost"}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (ca *issuer) client(t *testing.T, name string) tls.Certificate {
+	certPEM, keyPEM := ca.issue(t, name, x509.ExtKeyUsageClientAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// write writes the file with a modification time of its own, so that every write is a change.
+func write(t *testing.T, path string, data []byte) {
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	serial++
+	mtime := time.Now().Add(time.Duration(serial) * time.Second)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// files holds the server certificate, its key and the client CAs in a temporary directory.
+type files struct {
+	dir, cert, key, ca string
+}
+
+func newFiles(t *testing.T) *files {
+	dir, err := ioutil.TempDir("", "tlsreload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &files{dir: dir, cert: filepath.Join(dir, "cert.pem"), key: filepath.Join(dir, "key.pem"), ca: filepath.Join(dir, "ca.pem")}
+}
+
+func (f *files) server(t *testing.T, ca *issuer, name string) {
+	certPEM, keyPEM := ca.issue(t, name, x509.ExtKeyUsageServerAuth)
+	write(t, f.cert, certPEM)
+	write(t, f.key, keyPEM)
+}
+
+// handshake connects a client to a server of the config, returning the state of the server
+// side and its handshake error.
+func handshake(t *testing.T, config *tls.Config, client *tls.Config) (server, peer tls.ConnectionState, err error) {
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	type result struct {
+		state tls.ConnectionState
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer conn.Close()
+		c := conn.(*tls.Conn)
+		err = c.Handshake()
+		done <- result{state: c.ConnectionState(), err: err}
+	}()
+
+	client = client.Clone()
+	client.ServerName = "localhost"
+	conn, dialErr := tls.Dial("tcp", ln.Addr().String(), client)
+	if dialErr == nil {
+		peer = conn.ConnectionState()
+		defer conn.Close()
+	}
+	r := <-done
+	if r.err == nil && dialErr != nil {
+		r.err = dialErr
+	}
+	return r.state, peer, r.err
+}
+
+func TestReload(t *testing.T) {
+	ca := newCA(t, "Test CA")
+	f := newFiles(t)
+	defer os.RemoveAll(f.dir)
+	f.server(t, ca, "first")
+
+	r, err := New(f.cert, f.key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := r.Config(ClientRequire)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &tls.Config{RootCAs: roots}
+
+	served := func() string {
+		_, peer, err := handshake(t, config, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return peer.PeerCertificates[0].Subject.CommonName
+	}
+	if name := served(); name != "first" {
+		t.Fatalf("served %q", name)
+	}
+	if reloaded, err := r.Reload(); reloaded || err != nil {
+		t.Errorf("unchanged files: reloaded %v, %v", reloaded, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		r.Run(ctx, 10*time.Millisecond, log.NewNopLogger())
+		close(stopped)
+	}()
+	defer func() { cancel(); <-stopped }()
+
+	f.server(t, ca, "second")
+	deadline := time.Now().Add(5 * time.Second)
+	for served() != "second" {
+		if time.Now().After(deadline) {
+			t.Fatal("renewed certificate is not served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A certificate half written does not replace the one in use.
+	write(t, f.cert, []byte("-----BEGIN CERTIFICATE-----\n"))
+	if _, err := r.Reload(); err == nil {
+		t.Error("broken certificate loaded")
+	}
+	if name := served(); name != "second" {
+		t.Errorf("served %q after a failed reload", name)
+	}
+}
+
+func TestClientAuth(t *testing.T) {
+	ca := newCA(t, "Test CA")
+	other := newCA(t, "Other CA")
+	f := newFiles(t)
+	defer os.RemoveAll(f.dir)
+	f.server(t, ca, "server")
+	write(t, f.ca, ca.pem)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	none := &tls.Config{RootCAs: roots}
+	trusted := &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{ca.client(t, "billing")}}
+	mallory := other.client(t, "mallory")
+	// Clients leave out certificates of CAs the server does not ask for, unless made to send them.
+	unasked := &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{mallory}}
+	untrusted := &tls.Config{RootCAs: roots, GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return &mallory, nil
+	}}
+
+	tests := []struct {
+		mode     string
+		caFile   string
+		name     string
+		client   *tls.Config
+		ok       bool
+		verified string
+	}{
+		{ClientOptional, f.ca, "no certificate", none, true, ""},
+		{ClientOptional, f.ca, "trusted", trusted, true, "billing"},
+		{ClientOptional, f.ca, "untrusted", untrusted, false, ""},
+		{ClientOptional, f.ca, "untrusted left out", unasked, true, ""},
+		{ClientRequire, f.ca, "no certificate", none, false, ""},
+		{ClientRequire, f.ca, "trusted", trusted, true, "billing"},
+		{ClientRequire, f.ca, "untrusted", untrusted, false, ""},
+		{ClientRequire, f.ca, "untrusted left out", unasked, false, ""},
+		// Without CAs client certificates are neither asked for nor verified.
+		{ClientRequire, "", "no certificate", none, true, ""},
+		{ClientRequire, "", "trusted", trusted, true, ""},
+	}
+	for _, tt := range tests {
+		r, err := New(f.cert, f.key, tt.caFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, err := r.Config(tt.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state, _, err := handshake(t, config, tt.client)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s, CAs %q, %s: got %v", tt.mode, tt.caFile, tt.name, err)
+			continue
+		}
+		var verified string
+		if len(state.VerifiedChains) > 0 {
+			verified = state.VerifiedChains[0][0].Subject.CommonName
+		}
+		if err == nil && verified != tt.verified {
+			t.Errorf("%s, CAs %q, %s: verified %q, want %q", tt.mode, tt.caFile, tt.name, verified, tt.verified)
+		}
+	}
+
+	r, err := New(f.cert, f.key, f.ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Config("sometimes"); err == nil {
+		t.Error("unknown client authentication mode accepted")
+	}
+
+	// Clients of a CA added to the file are accepted once it is reloaded.
+	config, err := r.Config(ClientRequire)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, f.ca, append(append([]byte{}, ca.pem...), other.pem...))
+	if reloaded, err := r.Reload(); !reloaded || err != nil {
+		t.Fatalf("reloaded %v, %v", reloaded, err)
+	}
+	if _, _, err := handshake(t, config, untrusted); err != nil {
+		t.Errorf("client of the added CA: %v", err)
+	}
+}
@@ -0,0 +1,356 @@
+// Package validate decodes and validates requests strictly, the same way for every transport:
+// unknown fields, oversized bodies and other content types are rejected, and every failing
+// field is reported at once.
+package validate
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+// MaxBodySize limits request bodies in bytes, larger ones are rejected with errs.ErrRequestTooLarge.
+var MaxBodySize int64 = 1 << 20
+
+// Money amounts are stored as decimal(16,4).
+const (
+	moneyScale         = 4
+	moneyIntegerDigits = 12
+)
+
+func init() {
+	govalidator.CustomTypeTagMap.Set("positive", func(i interface{}, _ interface{}) bool {
+		d, ok := i.(decimal.Decimal)
+		return ok && d.Sign() > 0
+	})
+	govalidator.CustomTypeTagMap.Set("nonnegative", func(i interface{}, _ interface{}) bool {
+		d, ok := i.(decimal.Decimal)
+		return ok && d.Sign() >= 0
+	})
+	govalidator.CustomTypeTagMap.Set("money", func(i interface{}, _ interface{}) bool {
+		d, ok := i.(decimal.Decimal)
+		return ok && fits(d, moneyScale, moneyIntegerDigits)
+	})
+}
+
+// fits reports whether d has at most scale fractional and integer digits.
+func fits(d decimal.Decimal, scale int32, integer int) bool {
+	if !d.Equal(d.Truncate(scale)) {
+		return false
+	}
+	return len(d.Abs().Truncate(0).String()) <= integer
+}
+
+var (
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	unmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textType    = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// DecodeJSON decodes the JSON body of r into v, a pointer to a struct, and validates it.
+// The body must be application/json and at most MaxBodySize long. Fields v does not have and
+// values of the wrong type are reported as validation errors of the fields, listing all of them.
+// An empty body is reported as errs.ErrMalformedRequest wrapping io.EOF.
+func DecodeJSON(r *http.Request, v interface{}) error {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, MaxBodySize+1))
+	if err != nil {
+		return errs.ErrMalformedRequest.Wrap(err)
+	}
+	if int64(len(body)) > MaxBodySize {
+		return errs.ErrRequestTooLarge
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return errs.ErrMalformedRequest.Wrap(io.EOF)
+	}
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		return errs.ErrUnsupportedMediaType
+	}
+
+	if !json.Valid(body) {
+		return errs.ErrMalformedRequest.Wrap(errors.New("body is not valid JSON"))
+	}
+	if fields := check(body, reflect.TypeOf(v), nil); len(fields) > 0 {
+		return errs.ValidationError{Err: fields}
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errs.ErrMalformedRequest.Wrap(err)
+	}
+	return Struct(v)
+}
+
+// Struct validates v against its valid tags, reporting every failing field.
+func Struct(v interface{}) error {
+	if failed := structErrors(reflect.ValueOf(v), nil); len(failed) > 0 {
+		return errs.ValidationError{Err: failed}
+	}
+	return nil
+}
+
+// structErrors validates the struct v at path. govalidator stops at the first invalid element
+// of slices and names fields of nested structs by Go names, so only errors of the fields of v
+// itself are taken from it and nested structs are validated here, named as in JSON.
+func structErrors(v reflect.Value, path []string) govalidator.Errors {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	var failed govalidator.Errors
+	if _, err := govalidator.ValidateStruct(v.Interface()); err != nil {
+		for _, e := range flatten(err) {
+			ge, ok := e.(govalidator.Error)
+			if !ok {
+				failed = append(failed, fieldError(path, e.Error()))
+				continue
+			}
+			if len(ge.Path) == 0 {
+				ge.Path = path
+				failed = append(failed, ge)
+			}
+		}
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("valid") == "-" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			name = f.Name
+		}
+		fv := v.Field(i)
+		switch {
+		case nested(f.Type):
+			failed = append(failed, structErrors(fv, append(path[:len(path):len(path)], name))...)
+		case (f.Type.Kind() == reflect.Slice || f.Type.Kind() == reflect.Array) && nested(f.Type.Elem()):
+			for j := 0; j < fv.Len(); j++ {
+				failed = append(failed, structErrors(fv.Index(j), append(path[:len(path):len(path)], name, strconv.Itoa(j)))...)
+			}
+		}
+	}
+	return failed
+}
+
+// nested reports whether values of t are structs with fields of their own to validate.
+func nested(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || decodesItself(t) {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// check walks the JSON value along the type it is decoded into and reports unknown fields
+// and values which do not decode, by their path.
+func check(raw json.RawMessage, t reflect.Type, path []string) govalidator.Errors {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if string(bytes.TrimSpace(raw)) == "null" || decodesItself(t) {
+		return decodes(raw, t, path)
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return govalidator.Errors{fieldError(path, "must be an object")}
+		}
+		fields := jsonFields(t)
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var failed govalidator.Errors
+		for _, name := range names {
+			f, ok := fields[name]
+			if !ok {
+				// encoding/json matches names case-insensitively.
+				for n, field := range fields {
+					if strings.EqualFold(n, name) {
+						f, ok = field, true
+						break
+					}
+				}
+			}
+			if !ok {
+				failed = append(failed, fieldError(append(path[:len(path):len(path)], name), "unknown field"))
+				continue
+			}
+			failed = append(failed, check(obj[name], f.Type, append(path[:len(path):len(path)], name))...)
+		}
+		return failed
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return decodes(raw, t, path)
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return govalidator.Errors{fieldError(path, "must be an array")}
+		}
+		var failed govalidator.Errors
+		for i, item := range items {
+			failed = append(failed, check(item, t.Elem(), append(path[:len(path):len(path)], strconv.Itoa(i)))...)
+		}
+		return failed
+	}
+	return decodes(raw, t, path)
+}
+
+// decodesItself reports whether values of t are decoded by methods of their own.
+func decodesItself(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(unmarshaler) || reflect.PtrTo(t).Implements(textType)
+}
+
+// decodes reports the value failing to decode into t.
+func decodes(raw json.RawMessage, t reflect.Type, path []string) govalidator.Errors {
+	if err := json.Unmarshal(raw, reflect.New(t).Interface()); err != nil {
+		return govalidator.Errors{fieldError(path, "must be "+describe(t))}
+	}
+	return nil
+}
+
+// describe names the JSON values of t for error messages.
+func describe(t reflect.Type) string {
+	switch t {
+	case decimalType:
+		return "a decimal number"
+	case uuidType:
+		return "a UUID"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return "a valid value"
+}
+
+// jsonFields maps JSON names of fields of t, embedded structs included, to the fields.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for n, embedded := range jsonFields(f.Type) {
+				fields[n] = embedded
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// fieldError reports a failing field in the form of govalidator errors, see errs.ValidationError.
+func fieldError(path []string, msg string) govalidator.Error {
+	if len(path) == 0 {
+		return govalidator.Error{Name: "body", Err: errors.New(msg)}
+	}
+	return govalidator.Error{Name: path[len(path)-1], Path: path[:len(path)-1], Err: errors.New(msg)}
+}
+
+// Fields collects failing fields of requests decoded by hand, like those of gRPC, so that they
+// are reported together with the fields failing validation.
+type Fields struct {
+	failed govalidator.Errors
+}
+
+// Decimal parses the decimal at path, treating empty string as zero.
+func (f *Fields) Decimal(s string, path ...string) decimal.Decimal {
+	if s == "" {
+		return decimal.Zero
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		f.failed = append(f.failed, fieldError(path, "must be "+describe(decimalType)))
+	}
+	return d
+}
+
+// UUID parses the UUID at path.
+func (f *Fields) UUID(s string, path ...string) uuid.UUID {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		f.failed = append(f.failed, fieldError(path, "must be "+describe(uuidType)))
+	}
+	return id
+}
+
+// Struct validates v like Struct does, fields which failed to parse are reported once.
+func (f *Fields) Struct(v interface{}) error {
+	failed := append(govalidator.Errors(nil), f.failed...)
+	parsed := make(map[string]bool, len(f.failed))
+	for _, e := range f.failed {
+		parsed[fieldName(e.(govalidator.Error))] = true
+	}
+	for _, e := range structErrors(reflect.ValueOf(v), nil) {
+		if !parsed[fieldName(e.(govalidator.Error))] {
+			failed = append(failed, e)
+		}
+	}
+	if len(failed) > 0 {
+		return errs.ValidationError{Err: failed}
+	}
+	return nil
+}
+
+// flatten lists the errors of nested govalidator errors.
+func flatten(err error) []error {
+	list, ok := err.(govalidator.Errors)
+	if !ok {
+		return []error{err}
+	}
+	var flat []error
+	for _, e := range list {
+		flat = append(flat, flatten(e)...)
+	}
+	return flat
+}
+
+func fieldName(e govalidator.Error) string {
+	return strings.Join(append(e.Path[:len(e.Path):len(e.Path)], e.Name), ".")
+}
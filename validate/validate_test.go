@@ -0,0 +1,151 @@
+package validate
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ilyareist/task1/errs"
+	"github.com/shopspring/decimal"
+)
+
+type leg struct {
+	To     string          `json:"to" valid:"alphanum,required"`
+	Amount decimal.Decimal `json:"amount" valid:"nonnegative,money"`
+}
+
+type splitRequest struct {
+	From   string          `json:"from" valid:"alphanum,required"`
+	Amount decimal.Decimal `json:"amount" valid:"required,positive,money"`
+	Legs   []leg           `json:"legs" valid:"required"`
+}
+
+func decode(body, contentType string) (*splitRequest, error) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	req := new(splitRequest)
+	return req, DecodeJSON(r, req)
+}
+
+// fields returns the failing fields of a validation error by name.
+func fields(err error) map[string]string {
+	var ve errs.ValidationError
+	if !errors.As(err, &ve) {
+		return nil
+	}
+	failed := make(map[string]string)
+	for _, f := range ve.Fields() {
+		failed[f.Field] = f.Message
+	}
+	return failed
+}
+
+func names(m map[string]string) []string {
+	var names []string
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDecodeJSON(t *testing.T) {
+	req, err := decode(`{"from":"alice","amount":"10.5","legs":[{"to":"bob","amount":10.5}]}`, "application/json; charset=utf-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.From != "alice" || !req.Amount.Equal(decimal.RequireFromString("10.5")) || len(req.Legs) != 1 || req.Legs[0].To != "bob" {
+		t.Errorf("got %+v", req)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want map[string]string
+	}{
+		{"unknown field", `{"from":"alice","amount":1,"legs":[{"to":"bob"}],"memo":"x"}`,
+			map[string]string{"memo": "unknown field"}},
+		{"unknown nested field", `{"from":"alice","amount":1,"legs":[{"to":"bob"},{"to":"carol","fee":1}]}`,
+			map[string]string{"legs.1.fee": "unknown field"}},
+		{"wrong types", `{"from":1,"amount":"ten","legs":{"to":"bob"}}`,
+			map[string]string{"from": "must be a string", "amount": "must be a decimal number", "legs": "must be an array"}},
+		{"every failing field", `{"from":"al-ice","amount":-1,"legs":[{"to":"bob"},{"to":"","amount":-2}]}`,
+			map[string]string{"from": "", "amount": "", "legs.1.to": "", "legs.1.amount": ""}},
+		{"too many fractional digits", `{"from":"alice","amount":"1.00001","legs":[{"to":"bob"}]}`,
+			map[string]string{"amount": ""}},
+		{"too many integer digits", `{"from":"alice","amount":"1000000000000","legs":[{"to":"bob"}]}`,
+			map[string]string{"amount": ""}},
+		{"missing fields", `{}`,
+			map[string]string{"from": "", "amount": "", "legs": ""}},
+		{"not an object", `[1]`,
+			map[string]string{"body": "must be an object"}},
+	}
+	for _, tt := range tests {
+		_, err := decode(tt.body, "application/json")
+		got := fields(err)
+		if got == nil {
+			t.Errorf("%s: got %v", tt.name, err)
+			continue
+		}
+		if strings.Join(names(got), ",") != strings.Join(names(tt.want), ",") {
+			t.Errorf("%s: got fields %v, want %v", tt.name, got, names(tt.want))
+			continue
+		}
+		for name, msg := range tt.want {
+			if msg != "" && got[name] != msg {
+				t.Errorf("%s: %s: got %q, want %q", tt.name, name, got[name], msg)
+			}
+		}
+	}
+}
+
+func TestDecodeJSONRejects(t *testing.T) {
+	defer func(size int64) { MaxBodySize = size }(MaxBodySize)
+	MaxBodySize = 64
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        error
+	}{
+		{"empty body", " \n", "application/json", io.EOF},
+		{"malformed", `{"from":`, "application/json", errs.ErrMalformedRequest},
+		{"other content type", `{"from":"alice"}`, "text/plain", errs.ErrUnsupportedMediaType},
+		{"no content type", `{"from":"alice"}`, "", errs.ErrUnsupportedMediaType},
+		{"too large", `{"from":"` + strings.Repeat("a", 64) + `"}`, "application/json", errs.ErrRequestTooLarge},
+	}
+	for _, tt := range tests {
+		if _, err := decode(tt.body, tt.contentType); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	var f Fields
+	req := splitRequest{
+		From:   "alice",
+		Amount: f.Decimal("ten", "amount"),
+		Legs:   []leg{{To: "bob", Amount: f.Decimal("", "legs", "0", "amount")}, {To: "car-ol"}},
+	}
+	id := f.UUID("not-a-uuid", "reference")
+	if id != uuid.Nil {
+		t.Errorf("got id %v", id)
+	}
+
+	got := fields(f.Struct(&req))
+	want := map[string]string{"amount": "must be a decimal number", "reference": "must be a UUID", "legs.1.to": ""}
+	if strings.Join(names(got), ",") != strings.Join(names(want), ",") {
+		t.Fatalf("got fields %v, want %v", got, names(want))
+	}
+	for name, msg := range want {
+		if msg != "" && got[name] != msg {
+			t.Errorf("%s: got %q, want %q", name, got[name], msg)
+		}
+	}
+}